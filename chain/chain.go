@@ -0,0 +1,91 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package chain
+
+import (
+	"crypto/x509"
+
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// Result is a certificate chain reconstructed from an unordered pool of
+// certificates.
+type Result struct {
+	// Path is the reconstructed certificate chain, starting with the leaf
+	// certificate and ending with the root certificate if present in the
+	// pool.
+	Path []*x509.Certificate
+
+	// Unused is the collection of certificates from the pool which are not
+	// part of the reconstructed certificate chain.
+	Unused []*x509.Certificate
+
+	// Complete indicates whether the reconstructed certificate chain ends
+	// with a self-signed (root) certificate.
+	Complete bool
+}
+
+// Build reconstructs the canonical leaf to root certificate chain from the
+// given unordered pool of certificates. Issuers are linked to the
+// certificates they issued using the Authority and Subject Key Identifiers
+// (when present) and signature verification. Nil entries in the pool are
+// ignored.
+func Build(pool []*x509.Certificate) Result {
+	filtered := withoutNil(pool)
+
+	built := certs.BuildChain(filtered)
+
+	result := Result{
+		Path:     make([]*x509.Certificate, 0, len(built.Path)),
+		Unused:   make([]*x509.Certificate, 0, len(built.Unused)),
+		Complete: built.Complete,
+	}
+
+	for _, idx := range built.Path {
+		result.Path = append(result.Path, filtered[idx])
+	}
+
+	for _, idx := range built.Unused {
+		result.Unused = append(result.Unused, filtered[idx])
+	}
+
+	return result
+}
+
+// Reordered indicates whether the reconstructed certificate chain differs
+// from the order of the given pool of certificates, ignoring unused
+// certificates listed after the reconstructed chain. As with Build, nil
+// entries in the pool are ignored.
+func (r Result) Reordered(pool []*x509.Certificate) bool {
+	filtered := withoutNil(pool)
+
+	if len(r.Path) > len(filtered) {
+		return true
+	}
+
+	for i, cert := range r.Path {
+		if filtered[i] != cert {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutNil is a helper function that returns the given pool of
+// certificates without nil entries.
+func withoutNil(pool []*x509.Certificate) []*x509.Certificate {
+	filtered := make([]*x509.Certificate, 0, len(pool))
+	for _, cert := range pool {
+		if cert != nil {
+			filtered = append(filtered, cert)
+		}
+	}
+
+	return filtered
+}
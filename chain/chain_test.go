@@ -0,0 +1,202 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package chain_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/chain"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// testPKI is a leaf certificate issued by an intermediate which is
+// cross-signed by a current and a legacy root.
+type testPKI struct {
+	leaf *x509.Certificate
+
+	// intermediate is issued by root; crossSigned is the same intermediate
+	// (same subject and key) issued by legacyRoot and expiring earlier.
+	intermediate *x509.Certificate
+	crossSigned  *x509.Certificate
+
+	root       *x509.Certificate
+	legacyRoot *x509.Certificate
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+
+	now := time.Now()
+
+	ca := func(serial int64, name string, notAfter time.Time) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.AddDate(-1, 0, 0),
+			NotAfter:              notAfter,
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+	}
+
+	root, rootKey := testutil.IssueCert(t, ca(1, "Test Root CA", now.AddDate(15, 0, 0)), nil, nil)
+	legacyRoot, legacyRootKey := testutil.IssueCert(t, ca(2, "Test Legacy Root CA", now.AddDate(1, 0, 0)), nil, nil)
+
+	intermediate, intermediateKey := testutil.IssueCert(
+		t, ca(3, "Test Intermediate CA", now.AddDate(5, 0, 0)), root, rootKey,
+	)
+	crossSigned := testutil.IssueCertForKey(
+		t, ca(4, "Test Intermediate CA", now.AddDate(0, 6, 0)), legacyRoot, legacyRootKey, intermediateKey.Public(),
+	)
+
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(5),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.AddDate(0, 0, -30),
+		NotAfter:     now.AddDate(0, 0, 60),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	return testPKI{
+		leaf:         leaf,
+		intermediate: intermediate,
+		crossSigned:  crossSigned,
+		root:         root,
+		legacyRoot:   legacyRoot,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name          string
+		pool          []*x509.Certificate
+		wantPath      []*x509.Certificate
+		wantUnused    []*x509.Certificate
+		wantComplete  bool
+		wantReordered bool
+	}{
+		{
+			name:         "ordered chain",
+			pool:         []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantPath:     []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantComplete: true,
+		},
+		{
+			name:          "reversed chain",
+			pool:          []*x509.Certificate{pki.root, pki.intermediate, pki.leaf},
+			wantPath:      []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantComplete:  true,
+			wantReordered: true,
+		},
+		{
+			name:          "intermediate listed first",
+			pool:          []*x509.Certificate{pki.intermediate, pki.leaf},
+			wantPath:      []*x509.Certificate{pki.leaf, pki.intermediate},
+			wantReordered: true,
+		},
+		{
+			name:         "unused certificate listed after chain",
+			pool:         []*x509.Certificate{pki.leaf, pki.intermediate, pki.legacyRoot},
+			wantPath:     []*x509.Certificate{pki.leaf, pki.intermediate},
+			wantUnused:   []*x509.Certificate{pki.legacyRoot},
+			wantComplete: false,
+		},
+		{
+			// The current (later expiring) version of the cross-signed
+			// intermediate is selected regardless of pool order.
+			name: "cross-signed intermediate",
+			pool: []*x509.Certificate{
+				pki.leaf, pki.crossSigned, pki.legacyRoot, pki.intermediate, pki.root,
+			},
+			wantPath:      []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantUnused:    []*x509.Certificate{pki.crossSigned, pki.legacyRoot},
+			wantComplete:  true,
+			wantReordered: true,
+		},
+		{
+			name: "cross-signed intermediate without current root",
+			pool: []*x509.Certificate{
+				pki.leaf, pki.crossSigned, pki.legacyRoot,
+			},
+			wantPath:     []*x509.Certificate{pki.leaf, pki.crossSigned, pki.legacyRoot},
+			wantComplete: true,
+		},
+		{
+			// Nil entries are ignored by both Build and Reordered.
+			name:         "nil entries in ordered chain",
+			pool:         []*x509.Certificate{nil, pki.leaf, nil, pki.intermediate, pki.root, nil},
+			wantPath:     []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantComplete: true,
+		},
+		{
+			name:          "nil entries in reversed chain",
+			pool:          []*x509.Certificate{pki.root, nil, pki.intermediate, pki.leaf},
+			wantPath:      []*x509.Certificate{pki.leaf, pki.intermediate, pki.root},
+			wantComplete:  true,
+			wantReordered: true,
+		},
+		{
+			name: "only nil entries",
+			pool: []*x509.Certificate{nil, nil},
+		},
+		{
+			name: "empty pool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := chain.Build(tt.pool)
+
+			assertCerts(t, "path", result.Path, tt.wantPath)
+			assertCerts(t, "unused", result.Unused, tt.wantUnused)
+
+			if result.Complete != tt.wantComplete {
+				t.Errorf("got complete %t, want %t", result.Complete, tt.wantComplete)
+			}
+
+			if got := result.Reordered(tt.pool); got != tt.wantReordered {
+				t.Errorf("got reordered %t, want %t", got, tt.wantReordered)
+			}
+		})
+	}
+}
+
+// assertCerts asserts that the given certificates match the expected
+// certificates in order.
+func assertCerts(t *testing.T, desc string, got []*x509.Certificate, want []*x509.Certificate) {
+	t.Helper()
+
+	names := func(certs []*x509.Certificate) []string {
+		result := make([]string, 0, len(certs))
+		for _, cert := range certs {
+			result = append(result, cert.Subject.CommonName+" (issuer: "+cert.Issuer.CommonName+")")
+		}
+
+		return result
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %s %q, want %q", desc, names(got), names(want))
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %s %q, want %q", desc, names(got), names(want))
+		}
+	}
+}
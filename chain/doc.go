@@ -0,0 +1,17 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package chain provides support for reconstructing a certificate chain from
+// an unordered pool of certificates.
+//
+// This is intended for evaluating certificate bundle files and certificate
+// chains served by misconfigured services. The reconstructed leaf to root
+// order may be used to correct the service configuration; certificates
+// which are not part of the reconstructed chain (e.g., duplicate or
+// unrelated certificates) are listed separately so that they may be
+// removed.
+package chain
@@ -0,0 +1,83 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cache is an on-disk cache of retrieved revocation information. A zero
+// value cache (no directory) is disabled.
+type cache struct {
+	dir string
+}
+
+// cacheKey is a helper function that returns the cache key for the given URL
+// and optional request body.
+func cacheKey(rawURL string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(rawURL))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the cached data for the given key if present and if the
+// NextUpdate value returned by the given function is after the given time.
+func (c cache) get(key string, nextUpdate func([]byte) (time.Time, bool), now time.Time) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, key)) // #nosec G304 -- derived from hash
+	if err != nil {
+		return nil, false
+	}
+
+	expires, ok := nextUpdate(data)
+	if !ok || !now.Before(expires) {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// put stores the given data in the cache using the given key. Errors are
+// ignored as caching is a best-effort optimization; the entry is written to
+// a temporary file and renamed to avoid exposing partial entries.
+func (c cache) put(key string, data []byte) {
+	if c.dir == "" {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, key)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
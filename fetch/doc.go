@@ -0,0 +1,29 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package fetch provides optional support for retrieving revocation
+// information for a certificate chain over HTTP.
+//
+// The CRL Distribution Points and Authority Information Access OCSP
+// responder URLs listed by each certificate are used to retrieve CRLs and
+// OCSP responses which are then added to the revocation fields of the input
+// data used to generate a certificate metadata payload. Requests are
+// performed using a caller provided http.RoundTripper (or the default
+// transport) with a per-request timeout and a response size limit.
+// Retrieved revocation information may be cached on disk; cached entries are
+// reused until their NextUpdate value.
+//
+// Issuer certificates missing from a certificate chain may also be retrieved
+// by following the Authority Information Access CA Issuers URLs. These are
+// recorded separately from the certificate chain so that the payload can
+// report them as not served by the endpoint.
+//
+// Only HTTP and HTTPS URLs are supported. Other URLs, such as LDAP CRL
+// distribution points, are not retrieved; an error wrapping
+// ErrUnsupportedURL is returned for each so that callers can record or
+// filter them.
+package fetch
@@ -0,0 +1,358 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/revocation"
+)
+
+// Default settings used when a Config value is not specified.
+const (
+	// DefaultTimeout is the default timeout for each HTTP request.
+	DefaultTimeout time.Duration = 10 * time.Second
+
+	// DefaultMaxResponseSize is the default maximum size in bytes of a
+	// retrieved CRL or OCSP response.
+	DefaultMaxResponseSize int64 = 20 * 1024 * 1024
+)
+
+// OCSP HTTP content types (RFC 6960, appendix A).
+const (
+	contentTypeOCSPRequest  string = "application/ocsp-request"
+	contentTypeOCSPResponse string = "application/ocsp-response"
+)
+
+var (
+	// ErrResponseTooLarge indicates that a response exceeded the configured
+	// maximum response size.
+	ErrResponseTooLarge = errors.New("response exceeds maximum size")
+
+	// ErrUnexpectedStatusCode indicates that a server returned a non-200
+	// HTTP status code.
+	ErrUnexpectedStatusCode = errors.New("unexpected HTTP status code")
+
+	// ErrUnsupportedURL indicates that a URL uses an unsupported scheme.
+	ErrUnsupportedURL = errors.New("unsupported URL")
+)
+
+// Config controls how revocation information is retrieved.
+type Config struct {
+	// Transport is the http.RoundTripper used to perform requests. If not
+	// specified, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Timeout is the timeout for each HTTP request. If not specified,
+	// DefaultTimeout is used.
+	Timeout time.Duration
+
+	// MaxResponseSize is the maximum size in bytes of a retrieved CRL or
+	// OCSP response. If not specified, DefaultMaxResponseSize is used.
+	MaxResponseSize int64
+
+	// CacheDir is the optional path to a directory used to cache retrieved
+	// revocation information. Cached entries are reused until their
+	// NextUpdate value; entries without a NextUpdate value are not reused.
+	CacheDir string
+
+	// Now is an optional function returning the current time used to
+	// evaluate cached entries. If not specified, time.Now is used.
+	Now func() time.Time
+}
+
+// Fetcher retrieves revocation information over HTTP.
+type Fetcher struct {
+	client          *http.Client
+	maxResponseSize int64
+	cache           cache
+	now             func() time.Time
+}
+
+// New creates a Fetcher using the given configuration.
+func New(cfg Config) *Fetcher {
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	maxResponseSize := cfg.MaxResponseSize
+	if maxResponseSize <= 0 {
+		maxResponseSize = DefaultMaxResponseSize
+	}
+
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		maxResponseSize: maxResponseSize,
+		cache:           cache{dir: cfg.CacheDir},
+		now:             now,
+	}
+}
+
+// FetchCRLs retrieves the CRLs listed in the CRL Distribution Points of each
+// certificate in the given certificate chain. Each distribution point is
+// retrieved once. The DER or PEM encoded CRLs are returned along with any
+// errors encountered while retrieving individual CRLs; distribution points
+// using an unsupported scheme (e.g., LDAP) are reported as an error wrapping
+// ErrUnsupportedURL.
+func (f *Fetcher) FetchCRLs(ctx context.Context, certChain []*x509.Certificate) ([][]byte, []error) {
+	var crls [][]byte
+	var errs []error
+
+	seen := make(map[string]bool)
+
+	for _, cert := range certChain {
+		if cert == nil || certs.IsSelfSigned(cert) {
+			continue
+		}
+
+		for _, crlURL := range cert.CRLDistributionPoints {
+			if seen[crlURL] {
+				continue
+			}
+			seen[crlURL] = true
+
+			crl, err := f.fetchCRL(ctx, crlURL)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error retrieving CRL %s: %w", crlURL, err))
+				continue
+			}
+
+			crls = append(crls, crl)
+		}
+	}
+
+	return crls, errs
+}
+
+// FetchOCSP retrieves an OCSP response for each certificate in the given
+// certificate chain from the OCSP responders listed in the Authority
+// Information Access extension of the certificate. The issuer of each
+// certificate must be present in the chain. The first successful response
+// for each certificate is used. The DER encoded OCSP responses are returned
+// along with any errors encountered while retrieving individual responses.
+func (f *Fetcher) FetchOCSP(ctx context.Context, certChain []*x509.Certificate) ([][]byte, []error) {
+	var responses [][]byte
+	var errs []error
+
+	for _, cert := range certChain {
+		if cert == nil || len(cert.OCSPServer) == 0 || certs.IsSelfSigned(cert) {
+			continue
+		}
+
+		issuerIdx := certs.IssuerIndex(cert, certChain)
+		if issuerIdx == -1 {
+			errs = append(errs, fmt.Errorf(
+				"unable to create OCSP request for %q: %w",
+				cert.Subject.String(),
+				revocation.ErrIssuerNotPresent,
+			))
+
+			continue
+		}
+
+		req, err := revocation.CreateOCSPRequest(cert, certChain[issuerIdx])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error creating OCSP request for %q: %w", cert.Subject.String(), err))
+			continue
+		}
+
+		for _, responderURL := range cert.OCSPServer {
+			resp, err := f.fetchOCSP(ctx, responderURL, req)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error retrieving OCSP response from %s: %w", responderURL, err))
+				continue
+			}
+
+			responses = append(responses, resp)
+
+			break
+		}
+	}
+
+	return responses, errs
+}
+
+// Populate retrieves the CRLs and OCSP responses for the certificate chain
+// in the given input data and adds them to the CRLs and OCSPResponses
+// fields. Errors encountered while retrieving individual CRLs or OCSP
+// responses are returned; callers may add these to the Errors field of the
+// input data to record them in the generated payload.
+func (f *Fetcher) Populate(ctx context.Context, inputData *input.Values) []error {
+	crls, crlErrs := f.FetchCRLs(ctx, inputData.CertChain)
+	responses, ocspErrs := f.FetchOCSP(ctx, inputData.CertChain)
+
+	inputData.CRLs = append(inputData.CRLs, crls...)
+	inputData.OCSPResponses = append(inputData.OCSPResponses, responses...)
+
+	return append(crlErrs, ocspErrs...)
+}
+
+// fetchCRL is a helper method that retrieves and validates the CRL at the
+// given URL, using the cache when possible.
+func (f *Fetcher) fetchCRL(ctx context.Context, crlURL string) ([]byte, error) {
+	key := cacheKey(crlURL, nil)
+	if cached, ok := f.cache.get(key, crlNextUpdate, f.now()); ok {
+		return cached, nil
+	}
+
+	req, err := newRequest(ctx, http.MethodGet, crlURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := f.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := revocation.ParseCRLs(data); err != nil {
+		return nil, err
+	}
+
+	f.cache.put(key, data)
+
+	return data, nil
+}
+
+// fetchOCSP is a helper method that submits the given OCSP request to the
+// given responder URL and validates the response, using the cache when
+// possible.
+func (f *Fetcher) fetchOCSP(ctx context.Context, responderURL string, ocspReq []byte) ([]byte, error) {
+	key := cacheKey(responderURL, ocspReq)
+	if cached, ok := f.cache.get(key, ocspNextUpdate, f.now()); ok {
+		return cached, nil
+	}
+
+	req, err := newRequest(ctx, http.MethodPost, responderURL, ocspReq)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentTypeOCSPRequest)
+	req.Header.Set("Accept", contentTypeOCSPResponse)
+
+	data, err := f.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := revocation.ParseOCSPResponse(data); err != nil {
+		return nil, err
+	}
+
+	f.cache.put(key, data)
+
+	return data, nil
+}
+
+// do is a helper method that performs the given request and returns the
+// response body, enforcing the maximum response size.
+func (f *Fetcher) do(req *http.Request) ([]byte, error) {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %w", resp.Status, ErrUnexpectedStatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if int64(len(data)) > f.maxResponseSize {
+		return nil, fmt.Errorf("limit of %d bytes: %w", f.maxResponseSize, ErrResponseTooLarge)
+	}
+
+	return data, nil
+}
+
+// newRequest is a helper function that creates a HTTP request for the given
+// URL after asserting that it uses a supported scheme.
+func newRequest(ctx context.Context, method string, rawURL string, body []byte) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrUnsupportedURL)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("scheme %q: %w", u.Scheme, ErrUnsupportedURL)
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	return http.NewRequestWithContext(ctx, method, rawURL, bodyReader)
+}
+
+// crlNextUpdate is a helper function that returns the earliest NextUpdate
+// value of the given CRL data.
+func crlNextUpdate(data []byte) (time.Time, bool) {
+	crls, err := revocation.ParseCRLs(data)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var earliest time.Time
+	for i, crl := range crls {
+		if crl.NextUpdate.IsZero() {
+			return time.Time{}, false
+		}
+
+		if i == 0 || crl.NextUpdate.Before(earliest) {
+			earliest = crl.NextUpdate
+		}
+	}
+
+	return earliest, true
+}
+
+// ocspNextUpdate is a helper function that returns the NextUpdate value of
+// the given OCSP response data.
+func ocspNextUpdate(data []byte) (time.Time, bool) {
+	resp, err := revocation.ParseOCSPResponse(data)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	nextUpdate := resp.NextUpdate()
+
+	return nextUpdate, !nextUpdate.IsZero()
+}
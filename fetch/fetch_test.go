@@ -0,0 +1,341 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package fetch_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "crypto/sha1" //nolint:gosec // used for OCSP CertID values only
+
+	"github.com/atc0005/cert-payload/fetch"
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// The following types mirror the OCSP ASN.1 structures (RFC 6960) needed by
+// the test responder.
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		RequestList []struct {
+			Cert ocspCertID
+		}
+	}
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time `asn1:"generalized"`
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Revoked    ocspRevokedInfo `asn1:"tag:1"`
+	ThisUpdate time.Time       `asn1:"generalized"`
+	NextUpdate time.Time       `asn1:"generalized,explicit,tag:0"`
+}
+
+type ocspResponseData struct {
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+}
+
+type basicOCSPResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0"`
+}
+
+// testPKI is a throwaway CA with a leaf certificate which lists a CRL
+// distribution point and OCSP responder served by a local HTTP server. The
+// leaf certificate is revoked.
+type testPKI struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+	leaf    *x509.Certificate
+	server  *httptest.Server
+	crl     []byte
+
+	crlRequests  atomic.Int32
+	ocspRequests atomic.Int32
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+
+	pki := &testPKI{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/crl", func(w http.ResponseWriter, _ *http.Request) {
+		pki.crlRequests.Add(1)
+		_, _ = w.Write(pki.crl)
+	})
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		pki.ocspRequests.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.Method != http.MethodPost {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := pki.ocspResponse(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(resp)
+	})
+
+	pki.server = httptest.NewServer(mux)
+	t.Cleanup(pki.server.Close)
+
+	now := time.Now()
+
+	pki.root, pki.rootKey = testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Throwaway Root CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	pki.leaf, _ = testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1001),
+		Subject:               pkix.Name{CommonName: "leaf.example.com"},
+		DNSNames:              []string{"leaf.example.com"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(0, 3, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		CRLDistributionPoints: []string{pki.server.URL + "/crl", "ldap://ldap.example.com/crl"},
+		OCSPServer:            []string{pki.server.URL + "/ocsp"},
+	}, pki.root, pki.rootKey)
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now.Add(-time.Hour),
+		NextUpdate: now.Add(24 * time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: pki.leaf.SerialNumber, RevocationTime: now.Add(-time.Hour)},
+		},
+	}, pki.root, pki.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki.crl = crl
+
+	return pki
+}
+
+// ocspResponse builds a signed OCSP response reporting each certificate in
+// the given DER encoded OCSP request as revoked.
+func (pki *testPKI) ocspResponse(reqDER []byte) ([]byte, error) {
+	var req ocspRequest
+	if _, err := asn1.Unmarshal(reqDER, &req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	data := ocspResponseData{
+		// byName responder ID ([1] EXPLICIT Name).
+		ResponderID: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        1,
+			IsCompound: true,
+			Bytes:      pki.root.RawSubject,
+		},
+		ProducedAt: now,
+	}
+
+	for _, single := range req.TBSRequest.RequestList {
+		data.Responses = append(data.Responses, ocspSingleResponse{
+			CertID:     single.Cert,
+			Revoked:    ocspRevokedInfo{RevocationTime: now.Add(-time.Hour)},
+			ThisUpdate: now.Add(-time.Minute),
+			NextUpdate: now.Add(24 * time.Hour),
+		})
+	}
+
+	tbs, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(tbs)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, pki.rootKey, digest.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	basic, err := asn1.Marshal(basicOCSPResponse{
+		TBSResponseData: asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2},
+		},
+		Signature: asn1.BitString{Bytes: sig, BitLength: len(sig) * 8},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(ocspResponse{
+		Response: ocspResponseBytes{
+			ResponseType: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1},
+			Response:     basic,
+		},
+	})
+}
+
+func TestPopulateRevokedLeaf(t *testing.T) {
+	pki := newTestPKI(t)
+
+	inputData := input.Values{
+		CertChain:                            []*x509.Certificate{pki.leaf, pki.root},
+		ExpirationAgeInDaysWarningThreshold:  30,
+		ExpirationAgeInDaysCriticalThreshold: 15,
+	}
+
+	errs := fetch.New(fetch.Config{}).Populate(context.Background(), &inputData)
+
+	// The LDAP distribution point is not retrieved and is reported as an
+	// unsupported URL.
+	if len(errs) != 1 || !errors.Is(errs[0], fetch.ErrUnsupportedURL) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if len(inputData.CRLs) != 1 || len(inputData.OCSPResponses) != 1 {
+		t.Fatalf(
+			"got %d CRLs and %d OCSP responses, want 1 of each",
+			len(inputData.CRLs),
+			len(inputData.OCSPResponses),
+		)
+	}
+
+	encoded, err := format2.Encode(inputData)
+	if err != nil {
+		t.Fatalf("error encoding payload: %v", err)
+	}
+
+	var payload format2.CertChainPayload
+	if err := format2.Decode(&payload, bytes.NewReader(encoded), false); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+
+	revocation := payload.CertChainSubset[0].Revocation
+
+	switch {
+	case revocation.Status != input.RevocationStatusRevoked:
+		t.Errorf("got leaf revocation status %q, want %q", revocation.Status, input.RevocationStatusRevoked)
+	case revocation.CRL.Status != input.RevocationStatusRevoked:
+		t.Errorf("got leaf CRL status %q, want %q", revocation.CRL.Status, input.RevocationStatusRevoked)
+	case revocation.OCSP.Status != input.RevocationStatusRevoked:
+		t.Errorf("got leaf OCSP status %q (%s), want %q", revocation.OCSP.Status, revocation.OCSP.Error, input.RevocationStatusRevoked)
+	case !payload.Issues.RevokedCerts:
+		t.Error("revoked certs issue not flagged")
+	}
+}
+
+func TestFetchCache(t *testing.T) {
+	pki := newTestPKI(t)
+	certChain := []*x509.Certificate{pki.leaf, pki.root}
+	cfg := fetch.Config{CacheDir: t.TempDir()}
+
+	for i := 0; i < 2; i++ {
+		fetcher := fetch.New(cfg)
+
+		crls, _ := fetcher.FetchCRLs(context.Background(), certChain)
+		responses, errs := fetcher.FetchOCSP(context.Background(), certChain)
+
+		if len(crls) != 1 || len(responses) != 1 || len(errs) != 0 {
+			t.Fatalf("fetch %d: got %d CRLs, %d OCSP responses, errors %v", i, len(crls), len(responses), errs)
+		}
+	}
+
+	if got := pki.crlRequests.Load(); got != 1 {
+		t.Errorf("got %d CRL requests, want 1", got)
+	}
+
+	if got := pki.ocspRequests.Load(); got != 1 {
+		t.Errorf("got %d OCSP requests, want 1", got)
+	}
+
+	// Cached entries are not reused after their NextUpdate value.
+	expired := cfg
+	expired.Now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+	if _, errs := fetch.New(expired).FetchOCSP(context.Background(), certChain); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if got := pki.ocspRequests.Load(); got != 2 {
+		t.Errorf("got %d OCSP requests after NextUpdate, want 2", got)
+	}
+}
+
+func TestFetchResponseSizeLimit(t *testing.T) {
+	pki := newTestPKI(t)
+
+	fetcher := fetch.New(fetch.Config{MaxResponseSize: 16})
+
+	crls, errs := fetcher.FetchCRLs(context.Background(), []*x509.Certificate{pki.leaf, pki.root})
+	if len(crls) != 0 {
+		t.Fatalf("got %d CRLs, want 0", len(crls))
+	}
+
+	var tooLarge bool
+	for _, err := range errs {
+		if errors.Is(err, fetch.ErrResponseTooLarge) {
+			tooLarge = true
+		}
+	}
+
+	if !tooLarge {
+		t.Errorf("response size limit not enforced: %v", errs)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package fetch
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// MaxIssuerDepth is the maximum number of issuer certificates retrieved
+// when completing a certificate chain.
+const MaxIssuerDepth int = 5
+
+var (
+	// ErrInvalidIssuerCert indicates that the data retrieved from an AIA CA
+	// Issuers URL does not contain a certificate.
+	ErrInvalidIssuerCert = errors.New("invalid issuer certificate data")
+
+	// ErrIssuerNotFound indicates that none of the certificates retrieved
+	// from the AIA CA Issuers URLs of a certificate issued it.
+	ErrIssuerNotFound = errors.New("issuer certificate not found")
+)
+
+// oidSignedData is the PKCS#7 signed data content type.
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// pkcs7ContentInfo is the PKCS#7 ContentInfo ASN.1 structure (RFC 2315,
+// section 7).
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7SignedData is the leading portion of the PKCS#7 SignedData ASN.1
+// structure (RFC 2315, section 9.1) needed to extract certificates. The
+// remaining fields are ignored.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// FetchIssuers retrieves the issuer certificates missing from the given
+// certificate chain by following the Authority Information Access CA
+// Issuers URLs, starting with the topmost certificate of the reconstructed
+// chain (see the chain package). Retrieval continues with each retrieved
+// certificate until a self-signed certificate or an issuer present in the
+// chain is reached or MaxIssuerDepth certificates have been retrieved.
+//
+// The retrieved certificates are returned in issuing order along with any
+// errors encountered while retrieving individual URLs.
+func (f *Fetcher) FetchIssuers(ctx context.Context, certChain []*x509.Certificate) ([]input.FetchedCert, []error) {
+	var fetched []input.FetchedCert
+	var errs []error
+
+	pool := make([]*x509.Certificate, 0, len(certChain)+MaxIssuerDepth)
+	for _, cert := range certChain {
+		if cert != nil {
+			pool = append(pool, cert)
+		}
+	}
+
+	path := certs.BuildChain(pool).Path
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	current := pool[path[len(path)-1]]
+
+	for len(fetched) < MaxIssuerDepth {
+		if certs.IsSelfSigned(current) || certs.IssuerIndex(current, pool) != -1 {
+			break
+		}
+
+		issuer, source, issuerErrs := f.fetchIssuer(ctx, current)
+		errs = append(errs, issuerErrs...)
+
+		if issuer == nil {
+			errs = append(errs, fmt.Errorf(
+				"unable to retrieve issuer of %q: %w",
+				current.Subject.String(),
+				ErrIssuerNotFound,
+			))
+
+			break
+		}
+
+		fetched = append(fetched, input.FetchedCert{Cert: issuer, Source: source})
+		pool = append(pool, issuer)
+		current = issuer
+	}
+
+	return fetched, errs
+}
+
+// PopulateIssuers retrieves the issuer certificates missing from the
+// certificate chain in the given input data and adds them to the
+// FetchedIssuers field. Errors encountered while retrieving individual
+// certificates are returned; callers may add these to the Errors field of
+// the input data to record them in the generated payload.
+func (f *Fetcher) PopulateIssuers(ctx context.Context, inputData *input.Values) []error {
+	fetched, errs := f.FetchIssuers(ctx, inputData.CertChain)
+
+	inputData.FetchedIssuers = append(inputData.FetchedIssuers, fetched...)
+
+	return errs
+}
+
+// fetchIssuer is a helper method that retrieves the issuer of the given
+// certificate from its AIA CA Issuers URLs. The first retrieved certificate
+// which issued the given certificate is returned along with the URL it was
+// retrieved from.
+func (f *Fetcher) fetchIssuer(ctx context.Context, cert *x509.Certificate) (*x509.Certificate, string, []error) {
+	var errs []error
+
+	for _, issuerURL := range cert.IssuingCertificateURL {
+		req, err := newRequest(ctx, http.MethodGet, issuerURL, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error retrieving issuer %s: %w", issuerURL, err))
+			continue
+		}
+
+		data, err := f.do(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error retrieving issuer %s: %w", issuerURL, err))
+			continue
+		}
+
+		candidates, err := ParseIssuerCerts(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error parsing issuer %s: %w", issuerURL, err))
+			continue
+		}
+
+		for _, candidate := range candidates {
+			if certs.IssuerLinked(cert, candidate) && cert.CheckSignatureFrom(candidate) == nil {
+				return candidate, issuerURL, errs
+			}
+		}
+	}
+
+	return nil, "", errs
+}
+
+// ParseIssuerCerts parses the certificates retrieved from an AIA CA Issuers
+// URL. A single DER encoded certificate (RFC 5280, section 4.2.2.1), a DER
+// encoded PKCS#7 "certs-only" bundle or PEM encoded certificates are
+// supported.
+func ParseIssuerCerts(data []byte) ([]*x509.Certificate, error) {
+	if cert, err := x509.ParseCertificate(data); err == nil {
+		return []*x509.Certificate{cert}, nil
+	}
+
+	if block, rest := pem.Decode(data); block != nil {
+		var pemCerts []*x509.Certificate
+
+		for block != nil {
+			if block.Type == "CERTIFICATE" {
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %w", err, ErrInvalidIssuerCert)
+				}
+
+				pemCerts = append(pemCerts, cert)
+			}
+
+			block, rest = pem.Decode(rest)
+		}
+
+		if len(pemCerts) == 0 {
+			return nil, ErrInvalidIssuerCert
+		}
+
+		return pemCerts, nil
+	}
+
+	return parsePKCS7Certs(data)
+}
+
+// parsePKCS7Certs is a helper function that extracts the certificates from
+// a DER encoded PKCS#7 SignedData structure.
+func parsePKCS7Certs(data []byte) ([]*x509.Certificate, error) {
+	var contentInfo pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &contentInfo); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidIssuerCert)
+	}
+
+	if !contentInfo.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf(
+			"unsupported PKCS#7 content type %s: %w",
+			contentInfo.ContentType,
+			ErrInvalidIssuerCert,
+		)
+	}
+
+	var signedData pkcs7SignedData
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidIssuerCert)
+	}
+
+	pkcs7Certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrInvalidIssuerCert)
+	}
+
+	if len(pkcs7Certs) == 0 {
+		return nil, fmt.Errorf("no certificates in PKCS#7 data: %w", ErrInvalidIssuerCert)
+	}
+
+	return pkcs7Certs, nil
+}
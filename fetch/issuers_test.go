@@ -0,0 +1,289 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package fetch_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/fetch"
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// pkcs7SignedData is a degenerate "certs-only" PKCS#7 SignedData structure
+// (RFC 2315, section 9.1).
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+	Certificates     asn1.RawValue
+	SignerInfos      asn1.RawValue
+}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+// certsOnlyPKCS7 returns a DER encoded PKCS#7 bundle containing the given
+// certificate.
+func certsOnlyPKCS7(t *testing.T, cert *x509.Certificate) []byte {
+	t.Helper()
+
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+
+	signedData := pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      cert.Raw,
+		},
+		SignerInfos: emptySet,
+	}
+	signedData.ContentInfo.ContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+
+	content, err := asn1.Marshal(signedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2},
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      content,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+func TestFetchIssuersCompletesChain(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	now := time.Now()
+
+	caTmpl := func(serial int64, cn string, aiaPath string) *x509.Certificate {
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.AddDate(5, 0, 0),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		if aiaPath != "" {
+			tmpl.IssuingCertificateURL = []string{server.URL + aiaPath}
+		}
+
+		return tmpl
+	}
+
+	root, rootKey := testutil.IssueCert(t, caTmpl(1, "Throwaway Root CA", ""), nil, nil)
+	intermediate, intermediateKey := testutil.IssueCert(
+		t, caTmpl(2, "Throwaway Intermediate CA", "/root.cer"), root, rootKey,
+	)
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "leaf.example.com"},
+		DNSNames:              []string{"leaf.example.com"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(0, 3, 0),
+		BasicConstraintsValid: true,
+		IssuingCertificateURL: []string{
+			server.URL + "/missing.cer",
+			server.URL + "/intermediate.p7c",
+		},
+	}, intermediate, intermediateKey)
+
+	mux.HandleFunc("/missing.cer", http.NotFound)
+	mux.HandleFunc("/intermediate.p7c", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(certsOnlyPKCS7(t, intermediate))
+	})
+	mux.HandleFunc("/root.cer", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(root.Raw)
+	})
+
+	// The endpoint serves the leaf certificate only.
+	inputData := input.Values{
+		CertChain:                            []*x509.Certificate{leaf},
+		ExpirationAgeInDaysWarningThreshold:  30,
+		ExpirationAgeInDaysCriticalThreshold: 15,
+	}
+
+	errs := fetch.New(fetch.Config{}).PopulateIssuers(context.Background(), &inputData)
+
+	// The first AIA URL of the leaf certificate fails.
+	if len(errs) != 1 {
+		t.Errorf("got errors %v, want 1 error", errs)
+	}
+
+	if len(inputData.FetchedIssuers) != 2 ||
+		!inputData.FetchedIssuers[0].Cert.Equal(intermediate) ||
+		!inputData.FetchedIssuers[1].Cert.Equal(root) {
+		t.Fatalf("unexpected fetched issuers: %+v", inputData.FetchedIssuers)
+	}
+
+	encoded, err := format2.Encode(inputData)
+	if err != nil {
+		t.Fatalf("error encoding payload: %v", err)
+	}
+
+	var payload format2.CertChainPayload
+	if err := format2.Decode(&payload, bytes.NewReader(encoded), false); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+
+	if !payload.Issues.MissingIntermediateCerts {
+		t.Error("missing intermediate certs issue not flagged")
+	}
+
+	completion := payload.ChainCompletion
+
+	switch {
+	case !completion.Completed || !completion.Complete:
+		t.Fatalf("chain not completed: %+v", completion)
+	case len(completion.Path) != 3:
+		t.Fatalf("got completed path of %d certs, want 3", len(completion.Path))
+	case completion.Path[0].NotServed || completion.Path[0].ChainIndex != 0:
+		t.Errorf("leaf certificate marked as not served: %+v", completion.Path[0])
+	case !completion.Path[1].NotServed || !completion.Path[2].NotServed:
+		t.Errorf("fetched certificates not marked as not served: %+v", completion.Path)
+	}
+
+	fetched := completion.FetchedIssuers[0]
+	if !fetched.NotServed || fetched.Source != server.URL+"/intermediate.p7c" {
+		t.Errorf("unexpected fetched issuer: %+v", fetched)
+	}
+
+	block, _ := pem.Decode([]byte(fetched.PEM))
+	if block == nil || !bytes.Equal(block.Bytes, intermediate.Raw) {
+		t.Error("fetched issuer PEM does not match the intermediate certificate")
+	}
+}
+
+func TestParseIssuerCerts(t *testing.T) {
+	now := time.Now()
+
+	caTmpl := func(serial int64, cn string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.AddDate(5, 0, 0),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+	}
+
+	root, rootKey := testutil.IssueCert(t, caTmpl(1, "Throwaway Root CA"), nil, nil)
+	intermediate, _ := testutil.IssueCert(t, caTmpl(2, "Throwaway Intermediate CA"), root, rootKey)
+
+	pemCert := func(cert *x509.Certificate) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []*x509.Certificate
+		wantErr bool
+	}{
+		{
+			name: "DER certificate",
+			data: intermediate.Raw,
+			want: []*x509.Certificate{intermediate},
+		},
+		{
+			name: "PKCS#7 bundle",
+			data: certsOnlyPKCS7(t, intermediate),
+			want: []*x509.Certificate{intermediate},
+		},
+		{
+			name: "single PEM certificate",
+			data: pemCert(intermediate),
+			want: []*x509.Certificate{intermediate},
+		},
+		{
+			name: "two PEM certificates",
+			data: append(pemCert(intermediate), pemCert(root)...),
+			want: []*x509.Certificate{intermediate, root},
+		},
+		{
+			name: "PEM certificates with other blocks",
+			data: bytes.Join([][]byte{
+				pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: []byte{0x30, 0x00}}),
+				pemCert(intermediate),
+				pemCert(root),
+			}, nil),
+			want: []*x509.Certificate{intermediate, root},
+		},
+		{
+			name:    "PEM without certificates",
+			data:    pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: []byte{0x30, 0x00}}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid data",
+			data:    []byte("not a certificate"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fetch.ParseIssuerCerts(tt.data)
+
+			if tt.wantErr {
+				if !errors.Is(err, fetch.ErrInvalidIssuerCert) {
+					t.Fatalf("got error %v, want %v", err, fetch.ErrInvalidIssuerCert)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d certificates, want %d", len(got), len(tt.want))
+			}
+
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("certificate %d: got %q, want %q", i, got[i].Subject, tt.want[i].Subject)
+				}
+			}
+		})
+	}
+}
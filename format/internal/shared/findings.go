@@ -0,0 +1,533 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/revocation"
+	"github.com/atc0005/cert-payload/internal/textutils"
+	"github.com/atc0005/cert-payload/internal/weakkeys"
+)
+
+// Finding is evidence for a certificate chain issue attributed to one or more
+// certificates in the chain.
+type Finding struct {
+	// Issue is the name of the certificate chain issue (e.g.,
+	// `misordered_certs`).
+	Issue string
+
+	// CertIndexes is the list of chain positions (zero-based) for the
+	// certificates involved. The first entry is the certificate the finding
+	// is attributed to.
+	CertIndexes []int
+
+	// Evidence is a human readable explanation of the finding.
+	Evidence string
+
+	// Ignored indicates that the finding matches an active suppression and
+	// does not affect the confirmed certificate chain issues or the service
+	// state.
+	Ignored bool
+
+	// Suppression is the suppression applied to an ignored finding.
+	Suppression input.Suppression
+}
+
+// EvalOptions is the collection of settings used when evaluating a
+// certificate chain for issues.
+type EvalOptions struct {
+	// HostnameValue is the host name used to evaluate the leaf certificate
+	// for a hostname mismatch.
+	HostnameValue string
+
+	// ClockSkewTolerance is the amount of time that a certificate NotBefore
+	// value may be in the future before the certificate is considered not
+	// yet valid.
+	ClockSkewTolerance time.Duration
+
+	// Trust is the result of verifying the certificate chain against a
+	// trust store.
+	Trust TrustResult
+
+	// Revocation is the revocation status of each certificate in the chain
+	// indexed by chain position.
+	Revocation []revocation.Result
+
+	// OCSPStapleMissing indicates that the certificate chain was retrieved
+	// from a service without a stapled OCSP response.
+	OCSPStapleMissing bool
+
+	// Thresholds is the effective set of expiration thresholds for each
+	// certificate role. The warning threshold for the role of a certificate
+	// determines whether its expiration is considered to be expiring.
+	Thresholds RoleThresholds
+
+	// KeyPolicy is the policy used to evaluate the strength of the public
+	// key of each certificate.
+	KeyPolicy input.KeyPolicy
+
+	// DebianBlocklist is the Debian weak key blocklist used to check the
+	// public key of each certificate.
+	DebianBlocklist *weakkeys.Blocklist
+
+	// RenewalPolicy is the policy used to determine when each leaf
+	// certificate is expected to be renewed.
+	RenewalPolicy input.RenewalPolicy
+
+	// Now is the point in time the certificate chain is evaluated at.
+	Now time.Time
+
+	// Suppressions is the list of suppressions applied to the findings for
+	// the certificate chain.
+	Suppressions []input.Suppression
+
+	// Hosts is the list of host values (e.g., server host value, IP address
+	// and DNS name) used to match suppressions limited to specific hosts.
+	Hosts []string
+}
+
+// ChainFindings evaluates the given certificate chain using the given
+// options and returns the findings for all supported certificate chain
+// issues.
+func ChainFindings(certChain []*x509.Certificate, opts EvalOptions) []Finding {
+	var findings []Finding
+
+	findings = append(findings, MissingIntermediateCertsFindings(certChain)...)
+	findings = append(findings, MissingSANsEntriesFindings(certChain)...)
+	findings = append(findings, DuplicateCertsFindings(certChain)...)
+	findings = append(findings, MisorderedCertsFindings(certChain)...)
+	findings = append(findings, ExpiredCertsFindings(certChain)...)
+	findings = append(findings, HostnameMismatchFindings(opts.HostnameValue, certChain)...)
+	findings = append(findings, SelfSignedLeafFindings(certChain)...)
+	findings = append(findings, WeakSignatureAlgorithmFindings(certChain)...)
+	findings = append(findings, NotYetValidCertsFindings(certChain, opts.ClockSkewTolerance, opts.Now)...)
+	findings = append(findings, LeafOutlivesIssuerFindings(certChain)...)
+	findings = append(findings, UntrustedChainFindings(opts.Trust)...)
+	findings = append(findings, RevokedCertsFindings(opts.Revocation)...)
+	findings = append(findings, MissingOCSPStapleFindings(certChain, opts.OCSPStapleMissing)...)
+	findings = append(findings, ExpiringCrossSignFindings(certChain, opts.Trust.AllPaths, opts.Thresholds, opts.Now)...)
+	findings = append(findings, WeakKeyFindings(certChain, opts.KeyPolicy)...)
+	findings = append(findings, ROCAVulnerableKeyFindings(certChain)...)
+	findings = append(findings, DebianWeakKeyFindings(certChain, opts.DebianBlocklist)...)
+	findings = append(findings, RenewalOverdueFindings(certChain, opts.RenewalPolicy, opts.Now)...)
+
+	return SuppressFindings(findings, certChain, opts)
+}
+
+// FindingsForCert returns the findings attributed to the certificate at the
+// given chain position.
+func FindingsForCert(findings []Finding, certIndex int) []Finding {
+	var matches []Finding
+
+	for _, finding := range findings {
+		if len(finding.CertIndexes) > 0 && finding.CertIndexes[0] == certIndex {
+			matches = append(matches, finding)
+		}
+	}
+
+	return matches
+}
+
+// CertIndexesForIssue returns the sorted, unique chain positions of all
+// certificates involved in findings for the given issue.
+func CertIndexesForIssue(findings []Finding, issue string) []int {
+	seen := make(map[int]struct{})
+	indexes := make([]int, 0, len(findings))
+
+	for _, finding := range findings {
+		if finding.Issue != issue {
+			continue
+		}
+
+		for _, idx := range finding.CertIndexes {
+			if _, ok := seen[idx]; !ok {
+				seen[idx] = struct{}{}
+				indexes = append(indexes, idx)
+			}
+		}
+	}
+
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// leafCertIndexes is a helper function that returns the chain positions of
+// all leaf certificates in the given certificate chain.
+func leafCertIndexes(certChain []*x509.Certificate) []int {
+	var indexes []int
+
+	for idx, cert := range certChain {
+		switch certs.ChainPosition(cert, certChain) {
+		case certs.CertChainPositionLeaf, certs.CertChainPositionLeafSelfSigned:
+			indexes = append(indexes, idx)
+		}
+	}
+
+	return indexes
+}
+
+// MissingIntermediateCertsFindings returns a finding attributed to the first
+// certificate in the chain if the chain does not contain any intermediate
+// certificates.
+func MissingIntermediateCertsFindings(certChain []*x509.Certificate) []Finding {
+	if len(certChain) == 0 || certs.NumIntermediateCerts(certChain) != 0 {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueMissingIntermediateCerts,
+			CertIndexes: []int{0},
+			Evidence: fmt.Sprintf(
+				"no intermediate certificates present; cert 0 issued by %q",
+				certChain[0].Issuer.String(),
+			),
+		},
+	}
+}
+
+// MissingSANsEntriesFindings returns a finding if the first leaf certificate
+// in the chain does not contain Subject Alternate Names (SANs) entries.
+func MissingSANsEntriesFindings(certChain []*x509.Certificate) []Finding {
+	leafIndexes := leafCertIndexes(certChain)
+	if len(leafIndexes) == 0 {
+		return nil
+	}
+
+	leafIdx := leafIndexes[0]
+	if len(certChain[leafIdx].DNSNames) > 0 {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueMissingSANsEntries,
+			CertIndexes: []int{leafIdx},
+			Evidence: fmt.Sprintf(
+				"cert %d (leaf) has no DNS SANs entries; subject is %q",
+				leafIdx,
+				certChain[leafIdx].Subject.String(),
+			),
+		},
+	}
+}
+
+// DuplicateCertsFindings returns a finding for each certificate serial number
+// present more than once in the certificate chain.
+func DuplicateCertsFindings(certChain []*x509.Certificate) []Finding {
+	serialIdx := make(map[string][]int, len(certChain))
+	serials := make([]string, 0, len(certChain))
+
+	for idx, cert := range certChain {
+		serial := certs.FormatCertSerialNumber(cert.SerialNumber)
+		if _, ok := serialIdx[serial]; !ok {
+			serials = append(serials, serial)
+		}
+
+		serialIdx[serial] = append(serialIdx[serial], idx)
+	}
+
+	var findings []Finding
+
+	for _, serial := range serials {
+		indexes := serialIdx[serial]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		// Attribute the finding to the duplicate copy, not the original.
+		attributed := append([]int{indexes[1]}, indexes[0])
+		attributed = append(attributed, indexes[2:]...)
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueDuplicateCerts,
+			CertIndexes: attributed,
+			Evidence: fmt.Sprintf(
+				"certs %s share serial number %s",
+				strings.Join(textutils.IntSliceToStringSlice(indexes), ", "),
+				serial,
+			),
+		})
+	}
+
+	return findings
+}
+
+// MisorderedCertsFindings returns a finding for each certificate in the
+// chain which is not issued by the certificate which follows it.
+func MisorderedCertsFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(certChain)-1; i++ {
+		currentCert := certChain[i]
+		nextCert := certChain[i+1]
+
+		// Check if the issuer of the current certificate refers to the next
+		// certificate by key identifier or raw subject name.
+		if !certs.IssuerLinked(currentCert, nextCert) {
+			findings = append(findings, Finding{
+				Issue:       input.IssueMisorderedCerts,
+				CertIndexes: []int{i, i + 1},
+				Evidence: fmt.Sprintf(
+					"cert %d issuer %q does not refer to cert %d subject %q",
+					i,
+					currentCert.Issuer.String(),
+					i+1,
+					nextCert.Subject.String(),
+				),
+			})
+
+			continue
+		}
+
+		// Verify the current certificate is signed by the next certificate's
+		// public key.
+		sigVerifyErr := nextCert.CheckSignature(
+			currentCert.SignatureAlgorithm,
+			currentCert.RawTBSCertificate,
+			currentCert.Signature,
+		)
+
+		switch {
+		case errors.Is(sigVerifyErr, x509.InsecureAlgorithmError(currentCert.SignatureAlgorithm)):
+			// NOTE: We ignore x509.InsecureAlgorithmError errors and instead
+			// rely solely on issuer/subject mismatches as we could be
+			// evaluating a certificate with a deprecated signature algorithm
+			// that current versions of Go object to.
+			//
+			// https://github.com/atc0005/cert-payload/issues/72
+			continue
+
+		case sigVerifyErr != nil:
+			findings = append(findings, Finding{
+				Issue:       input.IssueMisorderedCerts,
+				CertIndexes: []int{i, i + 1},
+				Evidence: fmt.Sprintf(
+					"cert %d signature not verified by cert %d public key: %v",
+					i,
+					i+1,
+					sigVerifyErr,
+				),
+			})
+		}
+	}
+
+	return findings
+}
+
+// ExpiredCertsFindings returns a finding for each expired certificate in the
+// chain.
+func ExpiredCertsFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		if !certs.IsExpiredCert(cert) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueExpiredCerts,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d expired on %s",
+				idx,
+				cert.NotAfter.UTC().Format(time.RFC3339),
+			),
+		})
+	}
+
+	return findings
+}
+
+// NotYetValidCertsFindings returns a finding for each certificate in the
+// chain which is not yet valid as of the given time, allowing for the given
+// clock skew tolerance.
+func NotYetValidCertsFindings(certChain []*x509.Certificate, skewTolerance time.Duration, now time.Time) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		if !certs.IsNotYetValidCert(cert, skewTolerance, now) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueNotYetValidCerts,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d not valid before %s (clock skew tolerance %s)",
+				idx,
+				cert.NotBefore.UTC().Format(time.RFC3339),
+				skewTolerance,
+			),
+		})
+	}
+
+	return findings
+}
+
+// HasNotYetValidCerts asserts that the given certificate chain has one or
+// more certificates which are not yet valid as of the given time, allowing
+// for the given clock skew tolerance.
+func HasNotYetValidCerts(certChain []*x509.Certificate, skewTolerance time.Duration, now time.Time) bool {
+	return len(NotYetValidCertsFindings(certChain, skewTolerance, now)) > 0
+}
+
+// HostnameMismatchFindings returns a finding if the given hostname value is
+// not valid for the first certificate in the chain. If an empty hostname
+// value or empty certificate chain is provided a mismatch cannot be
+// determined and no findings are returned.
+func HostnameMismatchFindings(hostnameValue string, certChain []*x509.Certificate) []Finding {
+	if len(certChain) == 0 || hostnameValue == "" {
+		return nil
+	}
+
+	verifyErr := certChain[0].VerifyHostname(hostnameValue)
+	if verifyErr == nil {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueHostnameMismatch,
+			CertIndexes: []int{0},
+			Evidence: fmt.Sprintf(
+				"host name %q not valid for cert 0 (SANs entries: %s)",
+				hostnameValue,
+				strings.Join(certChain[0].DNSNames, ", "),
+			),
+		},
+	}
+}
+
+// SelfSignedLeafFindings returns a finding for each self-signed leaf
+// certificate in the chain.
+func SelfSignedLeafFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for _, idx := range leafCertIndexes(certChain) {
+		leafCert := certChain[idx]
+
+		if !certs.IsSelfSigned(leafCert) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueSelfSignedLeafCert,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d (leaf) is signed by its own key; subject %q",
+				idx,
+				leafCert.Subject.String(),
+			),
+		})
+	}
+
+	return findings
+}
+
+// WeakSignatureAlgorithmFindings returns a finding for each non-root
+// certificate in the chain signed using a cryptographically weak signature
+// algorithm.
+func WeakSignatureAlgorithmFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		if !certs.HasWeakSignatureAlgorithm(cert, certChain, false) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueWeakSignatureAlgorithm,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d signed using weak signature algorithm %s",
+				idx,
+				cert.SignatureAlgorithm,
+			),
+		})
+	}
+
+	return findings
+}
+
+// LeafOutlivesIssuerFindings returns a finding for each leaf certificate in
+// the chain which expires after the certificate in the chain which issued it.
+func LeafOutlivesIssuerFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for _, idx := range leafCertIndexes(certChain) {
+		leafCert := certChain[idx]
+
+		issuerIdx := certs.IssuerIndex(leafCert, certChain)
+		if issuerIdx == -1 {
+			continue
+		}
+
+		issuerCert := certChain[issuerIdx]
+		if !leafCert.NotAfter.After(issuerCert.NotAfter) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueLeafOutlivesIssuer,
+			CertIndexes: []int{idx, issuerIdx},
+			Evidence: fmt.Sprintf(
+				"cert %d (leaf) expires on %s after cert %d (issuer) expires on %s",
+				idx,
+				leafCert.NotAfter.UTC().Format(time.RFC3339),
+				issuerIdx,
+				issuerCert.NotAfter.UTC().Format(time.RFC3339),
+			),
+		})
+	}
+
+	return findings
+}
+
+// HasLeafOutlivingIssuer asserts that the given certificate chain has one or
+// more leaf certificates which expire after the certificate which issued
+// them.
+func HasLeafOutlivingIssuer(certChain []*x509.Certificate) bool {
+	return len(LeafOutlivesIssuerFindings(certChain)) > 0
+}
+
+// RevokedCertsFindings returns a finding for each revoked certificate using
+// the given revocation status of each certificate indexed by chain position.
+func RevokedCertsFindings(results []revocation.Result) []Finding {
+	var findings []Finding
+
+	for idx, result := range results {
+		check := result.Combined()
+		if !check.Revoked() {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueRevokedCerts,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d revoked on %s (%s) per %q",
+				idx,
+				check.RevokedAt.UTC().Format(time.RFC3339),
+				check.Reason,
+				check.Signer,
+			),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/weakkeys"
+)
+
+// WeakKeyReason evaluates the given public key details against the given
+// policy and returns the reason the key is considered weak or an empty
+// string if the key satisfies the policy. Default thresholds are used for
+// any policy values which are not specified.
+func WeakKeyReason(info certs.PublicKeyInfo, policy input.KeyPolicy) string {
+	policy = policy.WithDefaults()
+
+	switch info.Algorithm {
+	case certs.KeyAlgorithmRSA:
+		switch {
+		case info.Bits < policy.MinRSAKeyBits:
+			return fmt.Sprintf(
+				"RSA key size of %d bits is below the minimum of %d bits",
+				info.Bits,
+				policy.MinRSAKeyBits,
+			)
+
+		case info.RSAExponent < policy.MinRSAExponent:
+			return fmt.Sprintf(
+				"RSA public exponent %d is below the minimum of %d",
+				info.RSAExponent,
+				policy.MinRSAExponent,
+			)
+		}
+
+	case certs.KeyAlgorithmECDSA:
+		for _, curve := range policy.AllowedCurves {
+			if strings.EqualFold(curve, info.Curve) {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf(
+			"ECDSA curve %s is not one of the allowed curves (%s)",
+			info.Curve,
+			strings.Join(policy.AllowedCurves, ", "),
+		)
+
+	case certs.KeyAlgorithmDSA:
+		if !policy.AllowDSA {
+			return fmt.Sprintf("DSA key (%d bits) is not allowed", info.Bits)
+		}
+
+	case certs.KeyAlgorithmUnknown:
+		return "public key algorithm is not recognized"
+	}
+
+	return ""
+}
+
+// WeakKeyFindings returns a finding for each certificate in the chain with
+// a public key which does not satisfy the given policy. Root certificates
+// are included as a weak root key allows forging any certificate in the
+// chain.
+func WeakKeyFindings(certChain []*x509.Certificate, policy input.KeyPolicy) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		reason := WeakKeyReason(certs.KeyInfo(cert), policy)
+		if reason == "" {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueWeakKey,
+			CertIndexes: []int{idx},
+			Evidence:    fmt.Sprintf("cert %d has a weak public key: %s", idx, reason),
+		})
+	}
+
+	return findings
+}
+
+// ROCAVulnerableKeyFindings returns a finding for each certificate in the
+// chain with a RSA public key matching the fingerprint of keys generated by
+// the vulnerable Infineon RSA Library (ROCA).
+func ROCAVulnerableKeyFindings(certChain []*x509.Certificate) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok || !weakkeys.IsROCAVulnerable(pub) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueROCAVulnerableKey,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d RSA key (%d bits) matches the ROCA fingerprint",
+				idx,
+				pub.N.BitLen(),
+			),
+		})
+	}
+
+	return findings
+}
+
+// DebianWeakKeyFindings returns a finding for each certificate in the chain
+// with a RSA public key listed in the given Debian weak key blocklist.
+func DebianWeakKeyFindings(certChain []*x509.Certificate, blocklist *weakkeys.Blocklist) []Finding {
+	var findings []Finding
+
+	for idx, cert := range certChain {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok || !blocklist.Contains(pub) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueDebianWeakKey,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d RSA key (%d bits) is listed in the Debian weak key blocklist",
+				idx,
+				pub.N.BitLen(),
+			),
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,300 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// Limits applied when enumerating the paths from a leaf certificate to a
+// trust anchor.
+const (
+	maxTrustPathLength int = 8
+	maxTrustPaths      int = 32
+)
+
+// TrustPath is a path from a leaf certificate to a trust anchor.
+type TrustPath struct {
+	// Certs is the list of certificates in the path, starting with the leaf
+	// certificate and ending with the trust anchor.
+	Certs []*x509.Certificate
+
+	// Served indicates whether the path starts with the certificates served
+	// with the chain which are needed to validate the leaf certificate (see
+	// certs.ValidationPath).
+	Served bool
+
+	// ExpirationIndex is the path position (zero-based) of the certificate
+	// which determines the effective expiration of the path.
+	ExpirationIndex int
+
+	// CrossSignIndex is the path position (zero-based) of the first
+	// cross-signed certificate in the path or -1 if the path does not
+	// include a cross-signed certificate.
+	CrossSignIndex int
+}
+
+// ExpiresOn returns the effective expiration of the path; the earliest
+// expiration among the certificates in the path, including the trust
+// anchor as some clients reject expired trust anchors.
+func (tp TrustPath) ExpiresOn() time.Time {
+	return tp.Certs[tp.ExpirationIndex].NotAfter
+}
+
+// CrossSignExpiresOn returns the earliest expiration among the cross-signed
+// certificate in the path and the certificates above it (e.g., the root
+// used to cross-sign) or the zero value if the path does not include a
+// cross-signed certificate.
+func (tp TrustPath) CrossSignExpiresOn() time.Time {
+	if tp.CrossSignIndex == -1 {
+		return time.Time{}
+	}
+
+	earliest := tp.Certs[tp.CrossSignIndex].NotAfter
+	for _, cert := range tp.Certs[tp.CrossSignIndex:] {
+		if cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+
+	return earliest
+}
+
+// TrustPaths returns every path from the first leaf certificate of the given
+// certificate chain to a trust anchor in the given pool of trusted
+// certificates. Intermediate certificates are taken from the chain.
+//
+// Validity periods are not considered when building paths so that paths
+// which depend on an expired certificate (e.g., an expired cross-signing
+// root) are included. The number and length of enumerated paths is limited.
+func TrustPaths(certChain []*x509.Certificate, roots *x509.CertPool) []TrustPath {
+	leafIdx := certs.FirstLeafIndex(certChain)
+	if leafIdx == -1 || roots == nil {
+		return nil
+	}
+
+	served := make([]*x509.Certificate, 0, len(certChain))
+	for _, idx := range certs.ValidationPath(certChain) {
+		served = append(served, certChain[idx])
+	}
+
+	var found [][]*x509.Certificate
+
+	var walk func(path []*x509.Certificate)
+	walk = func(path []*x509.Certificate) {
+		if len(found) >= maxTrustPaths || len(path) > maxTrustPathLength {
+			return
+		}
+
+		current := path[len(path)-1]
+
+		anchors, isAnchor := trustAnchors(current, roots)
+		if isAnchor {
+			found = append(found, path)
+			return
+		}
+
+		if certs.IsSelfSigned(current) {
+			return
+		}
+
+		for _, idx := range certs.IssuerIndexes(current, certChain) {
+			issuer := certChain[idx]
+			if ChainIndex(issuer, path) != -1 {
+				continue
+			}
+
+			walk(appendCert(path, issuer))
+		}
+
+		for _, anchor := range anchors {
+			if ChainIndex(anchor, path) != -1 || ChainIndex(anchor, certChain) != -1 {
+				continue
+			}
+
+			walk(appendCert(path, anchor))
+		}
+	}
+
+	walk([]*x509.Certificate{certChain[leafIdx]})
+
+	pool := append([]*x509.Certificate{}, certChain...)
+	for _, path := range found {
+		pool = append(pool, path...)
+	}
+
+	paths := make([]TrustPath, 0, len(found))
+	for _, path := range found {
+		paths = append(paths, newTrustPath(path, served, pool))
+	}
+
+	return paths
+}
+
+// PathCerts returns the unique certificates from the given trust paths
+// which are not present in the given certificate chain (e.g., trust
+// anchors).
+func PathCerts(paths []TrustPath, certChain []*x509.Certificate) []*x509.Certificate {
+	var pathCerts []*x509.Certificate
+
+	for _, path := range paths {
+		for _, cert := range path.Certs {
+			if ChainIndex(cert, certChain) == -1 && ChainIndex(cert, pathCerts) == -1 {
+				pathCerts = append(pathCerts, cert)
+			}
+		}
+	}
+
+	return pathCerts
+}
+
+// CrossSignExpiring indicates whether the cross-signed certificate in the
+// path or a certificate above it has reached the expiration warning
+// threshold for its role within the path as of the given time. The
+// threshold is evaluated for each certificate so that lifetime percentage
+// thresholds apply to the validity period of that certificate. False is
+// returned if the path does not include a cross-signed certificate.
+func (tp TrustPath) CrossSignExpiring(thresholds RoleThresholds, now time.Time) bool {
+	if tp.CrossSignIndex == -1 {
+		return false
+	}
+
+	for _, cert := range tp.Certs[tp.CrossSignIndex:] {
+		warning := thresholds.ForCert(cert, tp.Certs).Warning
+		if cert.NotAfter.Before(now.Add(ThresholdDuration(cert, warning))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpiringCrossSignFindings returns a finding if every path to a trust
+// anchor which starts with the served certificates depends on a
+// cross-signed certificate and the cross-sign is expiring as of the given
+// time per the given expiration thresholds (see CrossSignExpiring). The
+// finding is attributed to the cross-signed certificate of the path which
+// remains valid the longest.
+func ExpiringCrossSignFindings(certChain []*x509.Certificate, paths []TrustPath, thresholds RoleThresholds, now time.Time) []Finding {
+	var best *TrustPath
+
+	for i := range paths {
+		path := paths[i]
+
+		if !path.Served {
+			continue
+		}
+
+		if path.CrossSignIndex == -1 {
+			// The served certificates reach a trust anchor without a
+			// cross-sign.
+			return nil
+		}
+
+		if best == nil || path.CrossSignExpiresOn().After(best.CrossSignExpiresOn()) {
+			best = &paths[i]
+		}
+	}
+
+	if best == nil || !best.CrossSignExpiring(thresholds, now) {
+		return nil
+	}
+
+	crossSigned := best.Certs[best.CrossSignIndex]
+
+	certIndex := ChainIndex(crossSigned, certChain)
+	if certIndex == -1 {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueExpiringCrossSign,
+			CertIndexes: []int{certIndex},
+			Evidence: fmt.Sprintf(
+				"served path depends on cross-signed cert %d (%s) which stops working on %s",
+				certIndex,
+				crossSigned.Subject.String(),
+				best.CrossSignExpiresOn().UTC().Format(time.RFC3339),
+			),
+		},
+	}
+}
+
+// trustAnchors is a helper function that returns the trusted certificates
+// in the given pool which issued the given certificate and whether the
+// given certificate is itself trusted.
+//
+// Verification is performed as of the time the given certificate was issued
+// so that issuers which have since expired are found.
+func trustAnchors(cert *x509.Certificate, roots *x509.CertPool) ([]*x509.Certificate, bool) {
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: cert.NotBefore,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	var anchors []*x509.Certificate
+
+	for _, chain := range chains {
+		switch {
+		case len(chain) == 1:
+			return nil, true
+		case ChainIndex(chain[len(chain)-1], anchors) == -1:
+			anchors = append(anchors, chain[len(chain)-1])
+		}
+	}
+
+	return anchors, false
+}
+
+// newTrustPath is a helper function that evaluates the given path of
+// certificates against the given served certificates. The given pool of
+// certificates is used to identify cross-signed certificates.
+func newTrustPath(path []*x509.Certificate, served []*x509.Certificate, pool []*x509.Certificate) TrustPath {
+	trustPath := TrustPath{
+		Certs:          path,
+		Served:         len(served) > 0 && len(path) >= len(served),
+		CrossSignIndex: -1,
+	}
+
+	for i, cert := range served {
+		if trustPath.Served && !path[i].Equal(cert) {
+			trustPath.Served = false
+		}
+	}
+
+	for i, cert := range path {
+		if cert.NotAfter.Before(path[trustPath.ExpirationIndex].NotAfter) {
+			trustPath.ExpirationIndex = i
+		}
+
+		if trustPath.CrossSignIndex == -1 && certs.IsCrossSigned(cert, pool) {
+			trustPath.CrossSignIndex = i
+		}
+	}
+
+	return trustPath
+}
+
+// appendCert is a helper function that returns a copy of the given path
+// with the given certificate appended.
+func appendCert(path []*x509.Certificate, cert *x509.Certificate) []*x509.Certificate {
+	extended := make([]*x509.Certificate, len(path), len(path)+1)
+	copy(extended, path)
+
+	return append(extended, cert)
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+// RenewalRuleDefault is the rule name recorded for a certificate evaluated
+// using the default renewal rule.
+const RenewalRuleDefault string = "default"
+
+// Renewal is the expected renewal of a leaf certificate as determined by a
+// renewal policy.
+type Renewal struct {
+	// Evaluated indicates whether a renewal rule applied to the
+	// certificate.
+	Evaluated bool
+
+	// RuleName is the issuer value of the matching renewal rule or
+	// RenewalRuleDefault if the default rule was used.
+	RuleName string
+
+	// Rule is the renewal rule applied to the certificate.
+	Rule input.RenewalRule
+
+	// LifetimeFraction is the effective fraction of the validity period
+	// after which the certificate is expected to be renewed or zero if the
+	// rule uses a fixed RenewBefore value.
+	LifetimeFraction float64
+
+	// ExpectedOn is the point at which the certificate is expected to be
+	// renewed.
+	ExpectedOn time.Time
+
+	// OverdueOn is the point after which renewal of the certificate is
+	// considered to be overdue (i.e., ExpectedOn plus the grace period).
+	OverdueOn time.Time
+}
+
+// Overdue indicates whether renewal of the certificate is overdue as of the
+// given time.
+func (r Renewal) Overdue(now time.Time) bool {
+	return r.Evaluated && now.After(r.OverdueOn)
+}
+
+// CertRenewal evaluates the expected renewal of the given leaf certificate
+// using the given renewal policy. The renewal is not evaluated if no rule of
+// the policy applies to the certificate.
+func CertRenewal(cert *x509.Certificate, policy input.RenewalPolicy) Renewal {
+	rule, ruleName, ok := renewalRule(cert, policy)
+	if !ok {
+		return Renewal{}
+	}
+
+	var expectedOn time.Time
+	var fraction float64
+
+	switch {
+	case rule.RenewBefore > 0:
+		expectedOn = cert.NotAfter.Add(-rule.RenewBefore)
+		if expectedOn.Before(cert.NotBefore) {
+			expectedOn = cert.NotBefore
+		}
+
+	default:
+		fraction = rule.LifetimeFraction
+		if fraction <= 0 {
+			fraction = input.DefaultRenewalLifetimeFraction
+		}
+
+		if fraction > 1 {
+			fraction = 1
+		}
+
+		lifetime := cert.NotAfter.Sub(cert.NotBefore)
+		expectedOn = cert.NotBefore.Add(time.Duration(float64(lifetime) * fraction))
+	}
+
+	return Renewal{
+		Evaluated:        true,
+		RuleName:         ruleName,
+		Rule:             rule,
+		LifetimeFraction: fraction,
+		ExpectedOn:       expectedOn,
+		OverdueOn:        expectedOn.Add(rule.GracePeriod),
+	}
+}
+
+// ChainRenewals evaluates the expected renewal of each leaf certificate in
+// the given certificate chain using the given renewal policy. The results
+// are indexed by chain position; other certificates are not evaluated.
+func ChainRenewals(certChain []*x509.Certificate, policy input.RenewalPolicy) []Renewal {
+	renewals := make([]Renewal, len(certChain))
+
+	if !policy.Enabled() {
+		return renewals
+	}
+
+	for _, idx := range leafCertIndexes(certChain) {
+		renewals[idx] = CertRenewal(certChain[idx], policy)
+	}
+
+	return renewals
+}
+
+// RenewalOverdueFindings returns a finding for each leaf certificate in the
+// chain which has passed the expected renewal point determined by the given
+// renewal policy as of the given time.
+func RenewalOverdueFindings(certChain []*x509.Certificate, policy input.RenewalPolicy, now time.Time) []Finding {
+	var findings []Finding
+
+	for idx, renewal := range ChainRenewals(certChain, policy) {
+		if !renewal.Overdue(now) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Issue:       input.IssueRenewalOverdue,
+			CertIndexes: []int{idx},
+			Evidence: fmt.Sprintf(
+				"cert %d expected to be renewed by %s using %s renewal rule; %.2f days ago",
+				idx,
+				renewal.ExpectedOn.UTC().Format(time.RFC3339),
+				renewal.RuleName,
+				now.Sub(renewal.ExpectedOn).Hours()/24,
+			),
+		})
+	}
+
+	return findings
+}
+
+// renewalRule is a helper function that returns the renewal rule of the
+// given policy which applies to the given certificate along with the name
+// of the rule.
+func renewalRule(cert *x509.Certificate, policy input.RenewalPolicy) (input.RenewalRule, string, bool) {
+	for _, rule := range policy.Rules {
+		if renewalRuleMatches(cert, rule) {
+			return rule, rule.Issuer, true
+		}
+	}
+
+	if policy.UseDefault {
+		return policy.Default, RenewalRuleDefault, true
+	}
+
+	return input.RenewalRule{}, "", false
+}
+
+// renewalRuleMatches is a helper function that indicates whether the issuer
+// value of the given renewal rule matches the issuer of the given
+// certificate.
+func renewalRuleMatches(cert *x509.Certificate, rule input.RenewalRule) bool {
+	if rule.Issuer == "" {
+		return false
+	}
+
+	if strings.EqualFold(rule.Issuer, cert.Issuer.String()) ||
+		strings.EqualFold(rule.Issuer, cert.Issuer.CommonName) {
+		return true
+	}
+
+	for _, org := range cert.Issuer.Organization {
+		if strings.EqualFold(rule.Issuer, org) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+func TestCertRenewal(t *testing.T) {
+	const day = 24 * time.Hour
+
+	issued := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// A 90 day certificate issued by Let's Encrypt.
+	cert := &x509.Certificate{
+		Issuer: pkix.Name{
+			CommonName:   "R11",
+			Organization: []string{"Let's Encrypt"},
+		},
+		NotBefore: issued,
+		NotAfter:  issued.Add(90 * day),
+	}
+
+	acmeRule := input.RenewalRule{Issuer: "let's encrypt", GracePeriod: 7 * day}
+
+	tests := []struct {
+		name          string
+		policy        input.RenewalPolicy
+		wantEvaluated bool
+		wantRuleName  string
+		wantFraction  float64
+		wantExpected  time.Time
+		wantOverdue   time.Time
+	}{
+		{
+			name: "no policy",
+		},
+		{
+			name: "no matching rule without default",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{{Issuer: "Example CA", LifetimeFraction: 0.5}},
+			},
+		},
+		{
+			name:          "default fraction",
+			policy:        input.RenewalPolicy{Rules: []input.RenewalRule{acmeRule}},
+			wantEvaluated: true,
+			wantRuleName:  "let's encrypt",
+			wantFraction:  input.DefaultRenewalLifetimeFraction,
+			wantExpected:  issued.Add(60 * day),
+			wantOverdue:   issued.Add(67 * day),
+		},
+		{
+			name: "explicit fraction",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{{Issuer: "R11", LifetimeFraction: 0.5}},
+			},
+			wantEvaluated: true,
+			wantRuleName:  "R11",
+			wantFraction:  0.5,
+			wantExpected:  issued.Add(45 * day),
+			wantOverdue:   issued.Add(45 * day),
+		},
+		{
+			name: "fraction limited to validity period",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{{Issuer: "R11", LifetimeFraction: 1.5}},
+			},
+			wantEvaluated: true,
+			wantRuleName:  "R11",
+			wantFraction:  1,
+			wantExpected:  issued.Add(90 * day),
+			wantOverdue:   issued.Add(90 * day),
+		},
+		{
+			name: "RenewBefore takes precedence over fraction",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{
+					{Issuer: "R11", LifetimeFraction: 0.5, RenewBefore: 30 * day, GracePeriod: day},
+				},
+			},
+			wantEvaluated: true,
+			wantRuleName:  "R11",
+			wantExpected:  issued.Add(60 * day),
+			wantOverdue:   issued.Add(61 * day),
+		},
+		{
+			name: "RenewBefore longer than validity period",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{{Issuer: "R11", RenewBefore: 120 * day}},
+			},
+			wantEvaluated: true,
+			wantRuleName:  "R11",
+			wantExpected:  issued,
+			wantOverdue:   issued,
+		},
+		{
+			name: "first matching rule is used",
+			policy: input.RenewalPolicy{
+				Rules: []input.RenewalRule{
+					{Issuer: "Example CA", RenewBefore: 10 * day},
+					{Issuer: "R11", RenewBefore: 20 * day},
+					{Issuer: "Let's Encrypt", RenewBefore: 30 * day},
+				},
+			},
+			wantEvaluated: true,
+			wantRuleName:  "R11",
+			wantExpected:  issued.Add(70 * day),
+			wantOverdue:   issued.Add(70 * day),
+		},
+		{
+			name: "default rule",
+			policy: input.RenewalPolicy{
+				Rules:      []input.RenewalRule{{Issuer: "Example CA", RenewBefore: 10 * day}},
+				Default:    input.RenewalRule{Issuer: "ignored", RenewBefore: 14 * day, GracePeriod: 2 * day},
+				UseDefault: true,
+			},
+			wantEvaluated: true,
+			wantRuleName:  RenewalRuleDefault,
+			wantExpected:  issued.Add(76 * day),
+			wantOverdue:   issued.Add(78 * day),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renewal := CertRenewal(cert, tt.policy)
+
+			if renewal.Evaluated != tt.wantEvaluated {
+				t.Fatalf("got evaluated %t, want %t", renewal.Evaluated, tt.wantEvaluated)
+			}
+
+			if !tt.wantEvaluated {
+				if renewal.Overdue(cert.NotAfter) {
+					t.Error("renewal not evaluated but reported as overdue")
+				}
+
+				return
+			}
+
+			if renewal.RuleName != tt.wantRuleName {
+				t.Errorf("got rule name %q, want %q", renewal.RuleName, tt.wantRuleName)
+			}
+
+			if renewal.LifetimeFraction != tt.wantFraction {
+				t.Errorf("got lifetime fraction %v, want %v", renewal.LifetimeFraction, tt.wantFraction)
+			}
+
+			if !renewal.ExpectedOn.Equal(tt.wantExpected) {
+				t.Errorf("got expected on %v, want %v", renewal.ExpectedOn, tt.wantExpected)
+			}
+
+			if !renewal.OverdueOn.Equal(tt.wantOverdue) {
+				t.Errorf("got overdue on %v, want %v", renewal.OverdueOn, tt.wantOverdue)
+			}
+
+			// Renewal becomes overdue after the grace period ends.
+			if renewal.Overdue(tt.wantOverdue) {
+				t.Error("renewal overdue at end of grace period")
+			}
+
+			if !renewal.Overdue(tt.wantOverdue.Add(time.Second)) {
+				t.Error("renewal not overdue after end of grace period")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/revocation"
+)
+
+// ChainRevocation evaluates the revocation status of each certificate in the
+// given certificate chain using the revocation information provided by the
+// given input data as of the given time. The results are indexed by chain
+// position. An error is returned if the provided OCSP responses cannot be
+// loaded.
+//
+// A stapled OCSP response which cannot be parsed is recorded as an unknown
+// OCSP status for the leaf certificate. Provided CRLs which cannot be
+// loaded are recorded as an unknown CRL status for each certificate (other
+// than self-signed certificates) without a usable CRL, as the unusable CRL
+// may have been the one issued for the certificate.
+func ChainRevocation(certChain []*x509.Certificate, inputData input.Values, now time.Time) ([]revocation.Result, error) {
+	crls, crlErrs := revocation.LoadCRLs(inputData.CRLFiles, inputData.CRLs)
+
+	responses, err := revocation.ParseOCSPResponses(inputData.OCSPResponses)
+	if err != nil {
+		return nil, err
+	}
+
+	var staple *revocation.OCSPResponse
+	var stapleErr error
+	if len(inputData.OCSPStaple) > 0 {
+		staple, stapleErr = revocation.ParseOCSPResponse(inputData.OCSPStaple)
+	}
+
+	results := make([]revocation.Result, 0, len(certChain))
+	for idx, cert := range certChain {
+		result := revocation.Result{
+			CRL: revocation.CheckCRL(cert, certChain, crls, now),
+		}
+
+		if len(crlErrs) > 0 &&
+			result.CRL.Status == input.RevocationStatusNotChecked &&
+			!certs.IsSelfSigned(cert) {
+			result.CRL = revocation.Check{
+				Status: input.RevocationStatusUnknown,
+				Err:    crlLoadError(crlErrs),
+			}
+		}
+
+		certResponses := responses
+		if idx == 0 && len(inputData.OCSPStaple) > 0 {
+			result.Stapled = true
+
+			if staple != nil {
+				certResponses = append([]*revocation.OCSPResponse{staple}, responses...)
+			}
+		}
+
+		result.OCSP = revocation.CheckOCSP(cert, certChain, certResponses, now)
+
+		if idx == 0 && stapleErr != nil && !result.OCSP.Revoked() {
+			result.OCSP = revocation.Check{
+				Status: input.RevocationStatusUnknown,
+				Err:    fmt.Errorf("stapled OCSP response: %w", stapleErr),
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// crlLoadError is a helper function that summarizes the given errors
+// encountered while loading the provided CRLs. The first error is wrapped.
+func crlLoadError(errs []error) error {
+	if len(errs) == 1 {
+		return fmt.Errorf("provided CRL could not be loaded: %w", errs[0])
+	}
+
+	return fmt.Errorf(
+		"%d provided CRLs could not be loaded; first error: %w",
+		len(errs),
+		errs[0],
+	)
+}
+
+// OCSPStapleMissing indicates whether the certificate chain provided by the
+// given input data was retrieved from a service without a stapled OCSP
+// response.
+func OCSPStapleMissing(inputData input.Values) bool {
+	return inputData.Server.HostValue != "" && len(inputData.OCSPStaple) == 0
+}
+
+// MissingOCSPStapleFindings returns a finding if the leaf certificate (the
+// first certificate in the chain) requires OCSP stapling and the given
+// value indicates that a stapled OCSP response was not provided.
+func MissingOCSPStapleFindings(certChain []*x509.Certificate, stapleMissing bool) []Finding {
+	if !stapleMissing || len(certChain) == 0 || !revocation.MustStaple(certChain[0]) {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueMissingOCSPStaple,
+			CertIndexes: []int{0},
+			Evidence:    "cert 0 carries the TLS Feature (must-staple) extension but no OCSP response was stapled",
+		},
+	}
+}
@@ -107,7 +107,7 @@ func LookupValidityPeriodDescription(cert *x509.Certificate) string {
 	// | -------------- | ---------------------------- | ------------- |
 	// | March 15, 2026 | 200 Days                     | 6 Month       |
 	// | March 15, 2027 | 100 Days                     | 3 Months      |
-	// | March 15, 2028 | 47 days                      | 1 Month       |
+	// | March 15, 2029 | 47 days                      | 1 Month       |
 	//
 	// See also:
 	//  - https://github.com/cabforum/servercert/pull/553
@@ -185,7 +185,9 @@ func HasWeakSignatureAlgorithm(certChain []*x509.Certificate) bool {
 		return false
 	}
 
-	nonRootCerts := certs.NonRootCerts(certChain)
+	nonRootCerts := legacyCertsWhere(certChain, func(chainPos string) bool {
+		return chainPos != certs.CertChainPositionRoot
+	})
 
 	// log := cfg.Log.With().Logger()
 
@@ -231,7 +233,8 @@ func HasWeakSignatureAlgorithm(certChain []*x509.Certificate) bool {
 		// 			logOK(cert)
 		// 		}
 
-		if certs.HasWeakSignatureAlgorithm(cert, certChain, false) {
+		// Root certificates were excluded above.
+		if certs.HasWeakSignatureAlgorithm(cert, certChain, true) {
 			return true
 		}
 
@@ -247,7 +250,7 @@ func HasSelfSignedLeaf(certChain []*x509.Certificate) bool {
 		return false
 	}
 
-	leafCerts := certs.LeafCerts(certChain)
+	leafCerts := legacyCertsWhere(certChain, isLeafPosition)
 	for _, leafCert := range leafCerts {
 		// NOTE: We may need to perform actual signature verification here for
 		// the most reliable results.
@@ -289,7 +292,7 @@ func HasMissingSANsEntries(certChain []*x509.Certificate) bool {
 		return false
 	}
 
-	leafCerts := certs.LeafCerts(certChain)
+	leafCerts := legacyCertsWhere(certChain, isLeafPosition)
 
 	if len(leafCerts) == 0 {
 		return false
@@ -344,7 +347,11 @@ func HasMissingIntermediateCerts(certChain []*x509.Certificate) bool {
 		return false
 	}
 
-	return certs.NumIntermediateCerts(certChain) == 0
+	intermediateCerts := legacyCertsWhere(certChain, func(chainPos string) bool {
+		return chainPos == certs.CertChainPositionIntermediate
+	})
+
+	return len(intermediateCerts) == 0
 }
 
 // HasMisorderedCerts asserts that a given certificate chain contains
@@ -409,6 +416,30 @@ func pkixNameEqual(name1 pkix.Name, name2 pkix.Name) bool {
 		strings.Join(name1.Country, ",") == strings.Join(name2.Country, ",")
 }
 
+// legacyCertsWhere is a helper function that returns the certificates from
+// the given certificate chain whose chain position satisfies the given
+// predicate. Chain positions are determined using certs.LegacyChainPosition
+// to retain the results of earlier releases for the stable payload format
+// versions.
+func legacyCertsWhere(certChain []*x509.Certificate, match func(chainPos string) bool) []*x509.Certificate {
+	var matched []*x509.Certificate
+
+	for _, cert := range certChain {
+		if match(certs.LegacyChainPosition(cert, certChain)) {
+			matched = append(matched, cert)
+		}
+	}
+
+	return matched
+}
+
+// isLeafPosition is a helper function that indicates whether the given chain
+// position is for a leaf certificate, self-signed or otherwise.
+func isLeafPosition(chainPos string) bool {
+	return chainPos == certs.CertChainPositionLeaf ||
+		chainPos == certs.CertChainPositionLeafSelfSigned
+}
+
 // ErrorsToStrings converts a collection of error interfaces to string values.
 func ErrorsToStrings(errs []error) []string {
 	stringErrs := make([]string, 0, len(errs))
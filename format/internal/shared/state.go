@@ -0,0 +1,205 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/issues"
+)
+
+// ErrServiceStateMismatch indicates that the caller provided service state
+// does not match the service state evaluated by this library.
+var ErrServiceStateMismatch = errors.New("service state mismatch")
+
+// ErrInvalidIssueState indicates that a caller specified service state for
+// a certificate chain issue is not a recognized service state label.
+var ErrInvalidIssueState = errors.New("invalid issue service state")
+
+// DefaultIssueStates is the default mapping of certificate chain issue name
+// to service state used when evaluating the service state for a certificate
+// chain. The service state for each issue is derived from the default
+// severity listed in the issue catalogue.
+func DefaultIssueStates() map[string]string {
+	catalog := issues.Catalog()
+	states := make(map[string]string, len(catalog))
+
+	for _, def := range catalog {
+		states[def.Name] = issues.ServiceState(def.Severity)
+	}
+
+	return states
+}
+
+// ValidateIssueStates asserts that each service state in the given mapping
+// of certificate chain issue name to service state is one of the recognized
+// service state labels (e.g., "WARNING"). Labels are case-sensitive. An
+// error is returned for the first invalid entry (in issue name order).
+func ValidateIssueStates(issueStates map[string]string) error {
+	names := make([]string, 0, len(issueStates))
+	for name := range issueStates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch issueStates[name] {
+		case certs.StateOKLabel,
+			certs.StateWARNINGLabel,
+			certs.StateCRITICALLabel,
+			certs.StateUNKNOWNLabel:
+		default:
+			return fmt.Errorf(
+				"service state %q for issue %q is not one of %s, %s, %s or %s: %w",
+				issueStates[name],
+				name,
+				certs.StateOKLabel,
+				certs.StateWARNINGLabel,
+				certs.StateCRITICALLabel,
+				certs.StateUNKNOWNLabel,
+				ErrInvalidIssueState,
+			)
+		}
+	}
+
+	return nil
+}
+
+// stateSeverity is a helper function that ranks the given service state
+// label so that the most severe state can be selected. Unrecognized labels
+// are ranked as UNKNOWN.
+func stateSeverity(state string) int {
+	switch state {
+	case certs.StateOKLabel:
+		return 0
+	case certs.StateWARNINGLabel:
+		return 1
+	case certs.StateCRITICALLabel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// WorstServiceState returns the most severe of the given service state
+// labels. The UNKNOWN state is considered more severe than WARNING but less
+// severe than CRITICAL.
+func WorstServiceState(states ...string) string {
+	worst := certs.StateOKLabel
+
+	for _, state := range states {
+		if stateSeverity(state) > stateSeverity(worst) {
+			worst = state
+		}
+	}
+
+	return worst
+}
+
+// EvaluateServiceState evaluates the given certificate chain, expiration
+// thresholds and list of detected certificate chain issue names and returns
+// the resulting service state label (e.g., OK, WARNING, CRITICAL, UNKNOWN).
+//
+// The service state for each detected issue is taken from the given
+// issueStates mapping, falling back to DefaultIssueStates for any issue not
+// listed. An UNKNOWN state is returned for an empty certificate chain.
+func EvaluateServiceState(
+	certChain []*x509.Certificate,
+	ageCritical time.Time,
+	ageWarning time.Time,
+	detectedIssues []string,
+	issueStates map[string]string,
+) string {
+	cutoffs := make([]ExpirationCutoffs, len(certChain))
+	for idx := range cutoffs {
+		cutoffs[idx] = ExpirationCutoffs{Critical: ageCritical, Warning: ageWarning}
+	}
+
+	return EvaluateServiceStateWithCutoffs(certChain, cutoffs, detectedIssues, issueStates)
+}
+
+// EvaluateServiceStateWithCutoffs evaluates the given certificate chain
+// using the given expiration cutoffs for each certificate (indexed by chain
+// position) along with the list of detected certificate chain issue names
+// and returns the resulting service state label (e.g., OK, WARNING,
+// CRITICAL, UNKNOWN). See EvaluateServiceState for details.
+func EvaluateServiceStateWithCutoffs(
+	certChain []*x509.Certificate,
+	cutoffs []ExpirationCutoffs,
+	detectedIssues []string,
+	issueStates map[string]string,
+) string {
+	if len(certChain) == 0 {
+		return certs.StateUNKNOWNLabel
+	}
+
+	state := certs.StateOKLabel
+
+	for idx, cert := range certChain {
+		switch {
+		case cutoffs[idx].Ignored:
+		case certs.IsExpiredCert(cert):
+			state = WorstServiceState(state, certs.StateCRITICALLabel)
+		case cert.NotAfter.Before(cutoffs[idx].Critical):
+			state = WorstServiceState(state, certs.StateCRITICALLabel)
+		case cert.NotAfter.Before(cutoffs[idx].Warning):
+			state = WorstServiceState(state, certs.StateWARNINGLabel)
+		}
+	}
+
+	defaultIssueStates := DefaultIssueStates()
+
+	for _, issue := range detectedIssues {
+		issueState, ok := issueStates[issue]
+		if !ok {
+			issueState, ok = defaultIssueStates[issue]
+		}
+
+		if !ok {
+			issueState = certs.StateWARNINGLabel
+		}
+
+		state = WorstServiceState(state, issueState)
+	}
+
+	return state
+}
+
+// ResolveServiceState applies the given service state mode to the caller
+// provided and library evaluated service state values. The service state to
+// record in the payload is returned along with an error if a mismatch
+// between the two values should be recorded.
+func ResolveServiceState(mode input.ServiceStateMode, callerState string, evaluatedState string) (string, error) {
+	var mismatchErr error
+	if callerState != "" && callerState != evaluatedState {
+		mismatchErr = fmt.Errorf(
+			"caller provided %s, evaluated %s: %w",
+			callerState,
+			evaluatedState,
+			ErrServiceStateMismatch,
+		)
+	}
+
+	switch mode {
+	case input.ServiceStateEvaluated:
+		return evaluatedState, mismatchErr
+
+	case input.ServiceStateVerified:
+		return callerState, mismatchErr
+
+	default:
+		return callerState, nil
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+func TestValidateIssueStates(t *testing.T) {
+	tests := []struct {
+		name        string
+		issueStates map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "no mapping",
+		},
+		{
+			name: "recognized labels",
+			issueStates: map[string]string{
+				input.IssueExpiredCerts:           "CRITICAL",
+				input.IssueHostnameMismatch:       "WARNING",
+				input.IssueMisorderedCerts:        "OK",
+				input.IssueSelfSignedLeafCert:     "UNKNOWN",
+				input.IssueMissingSANsEntries:     "CRITICAL",
+				input.IssueDuplicateCerts:         "OK",
+				input.IssueWeakSignatureAlgorithm: "WARNING",
+			},
+		},
+		{
+			name:        "lowercase label",
+			issueStates: map[string]string{input.IssueHostnameMismatch: "warning"},
+			wantErr:     true,
+		},
+		{
+			name:        "misspelled label",
+			issueStates: map[string]string{input.IssueExpiredCerts: "CRITCAL"},
+			wantErr:     true,
+		},
+		{
+			name:        "empty label",
+			issueStates: map[string]string{input.IssueExpiredCerts: ""},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIssueStates(tt.issueStates)
+
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrInvalidIssueState):
+				t.Errorf("got error %v, want %v", err, ErrInvalidIssueState)
+			case !tt.wantErr && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
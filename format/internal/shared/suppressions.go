@@ -0,0 +1,216 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/textutils"
+)
+
+// CertRole returns the role (e.g., input.CertRoleIntermediate) of the given
+// certificate within the given certificate chain.
+func CertRole(cert *x509.Certificate, certChain []*x509.Certificate) string {
+	chainPos := certs.ChainPosition(cert, certChain)
+
+	switch {
+	case certs.IsIntermediatePosition(chainPos):
+		return input.CertRoleIntermediate
+	case chainPos == certs.CertChainPositionRoot:
+		return input.CertRoleRoot
+	default:
+		return input.CertRoleLeaf
+	}
+}
+
+// SuppressionHosts returns the host values from the given input data used to
+// match suppressions limited to specific hosts.
+func SuppressionHosts(inputData input.Values) []string {
+	var hosts []string
+
+	for _, host := range []string{
+		inputData.Server.HostValue,
+		inputData.Server.IPAddress,
+		inputData.DNSName,
+	} {
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// SuppressFindings marks each of the given findings for the given
+// certificate chain which matches an active suppression as ignored. The
+// first matching suppression is recorded for each ignored finding. A
+// suppression limited to specific roles or serial numbers only applies to a
+// finding if every certificate affected by the finding matches.
+func SuppressFindings(findings []Finding, certChain []*x509.Certificate, opts EvalOptions) []Finding {
+	for idx, finding := range findings {
+		suppression, ok := matchSuppression(opts, certChain, finding.CertIndexes, func(s input.Suppression) bool {
+			if s.IgnoreExpiration && finding.Issue == input.IssueExpiredCerts {
+				return true
+			}
+
+			return textutils.InList(finding.Issue, s.Issues, true)
+		})
+		if ok {
+			findings[idx].Ignored = true
+			findings[idx].Suppression = suppression
+		}
+	}
+
+	return findings
+}
+
+// ExpirationSuppression returns the first active suppression which ignores
+// the expiration of the certificate at the given chain position and a
+// boolean value indicating whether a suppression was found.
+func ExpirationSuppression(certChain []*x509.Certificate, certIndex int, opts EvalOptions) (input.Suppression, bool) {
+	return matchSuppression(opts, certChain, []int{certIndex}, func(s input.Suppression) bool {
+		return s.IgnoreExpiration
+	})
+}
+
+// IgnoredIssues returns the names of the certificate chain issues for which
+// all of the given findings are ignored. Issues without findings are not
+// included.
+func IgnoredIssues(findings []Finding) map[string]bool {
+	active := make(map[string]bool)
+	ignored := make(map[string]bool)
+
+	for _, finding := range findings {
+		if finding.Ignored {
+			ignored[finding.Issue] = true
+			continue
+		}
+
+		active[finding.Issue] = true
+	}
+
+	for issue := range active {
+		delete(ignored, issue)
+	}
+
+	return ignored
+}
+
+// ActiveIssues returns the names of the certificate chain issues with at
+// least one of the given findings not ignored per a suppression.
+func ActiveIssues(findings []Finding) map[string]bool {
+	active := make(map[string]bool)
+
+	for _, finding := range findings {
+		if !finding.Ignored {
+			active[finding.Issue] = true
+		}
+	}
+
+	return active
+}
+
+// matchSuppression returns the first active suppression from the given
+// options accepted by the given selector which applies to all of the
+// certificates at the given chain positions. No chain positions indicates a
+// finding for the certificate chain as a whole; suppressions limited to
+// specific roles or serial numbers do not apply to these findings.
+func matchSuppression(
+	opts EvalOptions,
+	certChain []*x509.Certificate,
+	certIndexes []int,
+	selector func(input.Suppression) bool,
+) (input.Suppression, bool) {
+	for _, suppression := range opts.Suppressions {
+		switch {
+		case !suppression.Active(opts.Now):
+			continue
+		case !selector(suppression):
+			continue
+		case len(suppression.Hosts) > 0 && !anyInList(opts.Hosts, suppression.Hosts):
+			continue
+		}
+
+		if !certsMatchSuppression(suppression, certChain, certIndexes) {
+			continue
+		}
+
+		return suppression, true
+	}
+
+	return input.Suppression{}, false
+}
+
+// certsMatchSuppression indicates whether the certificates at the given
+// chain positions all match the roles and serial numbers the given
+// suppression is limited to. Suppressions which are not limited to specific
+// roles or serial numbers match any certificates.
+func certsMatchSuppression(suppression input.Suppression, certChain []*x509.Certificate, certIndexes []int) bool {
+	if len(suppression.Roles) == 0 && len(suppression.SerialNumbers) == 0 {
+		return true
+	}
+
+	if len(certIndexes) == 0 {
+		return false
+	}
+
+	for _, certIndex := range certIndexes {
+		if certIndex < 0 || certIndex >= len(certChain) {
+			return false
+		}
+
+		cert := certChain[certIndex]
+
+		if len(suppression.Roles) > 0 &&
+			!textutils.InList(CertRole(cert, certChain), suppression.Roles, true) {
+			return false
+		}
+
+		if len(suppression.SerialNumbers) > 0 && !serialNumberInList(cert, suppression.SerialNumbers) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyInList indicates whether any of the given values is present in the
+// given list. Values are compared case-insensitively.
+func anyInList(values []string, list []string) bool {
+	for _, value := range values {
+		if textutils.InList(value, list, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serialNumberInList indicates whether the serial number of the given
+// certificate is present in the given list of serial numbers. Serial numbers
+// are compared case-insensitively without colon delimiters or leading zeros.
+func serialNumberInList(cert *x509.Certificate, serialNumbers []string) bool {
+	normalize := func(sn string) string {
+		sn = strings.ReplaceAll(strings.TrimSpace(sn), ":", "")
+
+		return strings.TrimLeft(strings.ToUpper(sn), "0")
+	}
+
+	certSerial := normalize(certs.FormatCertSerialNumber(cert.SerialNumber))
+
+	for _, sn := range serialNumbers {
+		if normalize(sn) == certSerial {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,277 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// newSuppressionTestChain returns a leaf, intermediate and root certificate
+// chain. The leaf certificate has serial number 04:A1:B2.
+func newSuppressionTestChain(t *testing.T, now time.Time) []*x509.Certificate {
+	t.Helper()
+
+	ca := func(serial int64, name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.AddDate(-1, 0, 0),
+			NotAfter:              now.AddDate(5, 0, 0),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+	}
+
+	root, rootKey := testutil.IssueCert(t, ca(1, "Test Root CA"), nil, nil)
+	intermediate, intermediateKey := testutil.IssueCert(t, ca(2, "Test Intermediate CA"), root, rootKey)
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0x04a1b2),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.AddDate(0, 0, -30),
+		NotAfter:     now.AddDate(0, 0, 60),
+	}, intermediate, intermediateKey)
+
+	return []*x509.Certificate{leaf, intermediate, root}
+}
+
+func TestMatchSuppression(t *testing.T) {
+	now := time.Now()
+	certChain := newSuppressionTestChain(t, now)
+
+	mismatch := []string{input.IssueHostnameMismatch}
+
+	tests := []struct {
+		name         string
+		suppressions []input.Suppression
+		certIndexes  []int
+		wantReason   string
+		wantMatch    bool
+	}{
+		{
+			name:        "no suppressions",
+			certIndexes: []int{0},
+		},
+		{
+			name:         "any certificate",
+			suppressions: []input.Suppression{{Issues: mismatch, Reason: "any"}},
+			certIndexes:  []int{0},
+			wantReason:   "any",
+			wantMatch:    true,
+		},
+		{
+			name:         "other issue",
+			suppressions: []input.Suppression{{Issues: []string{input.IssueWeakKey}}},
+			certIndexes:  []int{0},
+		},
+		{
+			name: "expired suppression",
+			suppressions: []input.Suppression{
+				{Issues: mismatch, Expires: now.Add(-time.Minute), Reason: "expired"},
+			},
+			certIndexes: []int{0},
+		},
+		{
+			name: "suppression expiring later",
+			suppressions: []input.Suppression{
+				{Issues: mismatch, Expires: now.Add(time.Minute), Reason: "expiring"},
+			},
+			certIndexes: []int{0},
+			wantReason:  "expiring",
+			wantMatch:   true,
+		},
+		{
+			name: "first active suppression is used",
+			suppressions: []input.Suppression{
+				{Issues: mismatch, Expires: now.Add(-time.Minute), Reason: "expired"},
+				{Issues: mismatch, Reason: "first"},
+				{Issues: mismatch, Reason: "second"},
+			},
+			certIndexes: []int{0},
+			wantReason:  "first",
+			wantMatch:   true,
+		},
+		{
+			name:         "serial number with colons",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B2"}, Reason: "serial"}},
+			certIndexes:  []int{0},
+			wantReason:   "serial",
+			wantMatch:    true,
+		},
+		{
+			name:         "serial number lowercase without leading zero",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"4a1b2"}, Reason: "serial"}},
+			certIndexes:  []int{0},
+			wantReason:   "serial",
+			wantMatch:    true,
+		},
+		{
+			name:         "serial number with extra leading zeros",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{" 0004A1B2 "}, Reason: "serial"}},
+			certIndexes:  []int{0},
+			wantReason:   "serial",
+			wantMatch:    true,
+		},
+		{
+			name:         "other serial number",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B3"}}},
+			certIndexes:  []int{0},
+		},
+		{
+			name:         "serial number of other certificate",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B2"}}},
+			certIndexes:  []int{1},
+		},
+		{
+			name:         "serial number of one of two certificates",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B2"}}},
+			certIndexes:  []int{0, 1},
+		},
+		{
+			name:         "serial numbers of both certificates",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B2", "02"}, Reason: "serials"}},
+			certIndexes:  []int{0, 1},
+			wantReason:   "serials",
+			wantMatch:    true,
+		},
+		{
+			name:         "matching role",
+			suppressions: []input.Suppression{{Issues: mismatch, Roles: []string{"Intermediate"}, Reason: "role"}},
+			certIndexes:  []int{1},
+			wantReason:   "role",
+			wantMatch:    true,
+		},
+		{
+			name:         "other role",
+			suppressions: []input.Suppression{{Issues: mismatch, Roles: []string{input.CertRoleRoot}}},
+			certIndexes:  []int{0},
+		},
+		{
+			name:         "role for chain finding",
+			suppressions: []input.Suppression{{Issues: mismatch, Roles: []string{input.CertRoleLeaf}}},
+		},
+		{
+			name:         "serial number for chain finding",
+			suppressions: []input.Suppression{{Issues: mismatch, SerialNumbers: []string{"04:A1:B2"}}},
+		},
+		{
+			name:         "unrestricted suppression for chain finding",
+			suppressions: []input.Suppression{{Issues: mismatch, Reason: "chain"}},
+			wantReason:   "chain",
+			wantMatch:    true,
+		},
+		{
+			name:         "matching host",
+			suppressions: []input.Suppression{{Issues: mismatch, Hosts: []string{"WWW.EXAMPLE.COM"}, Reason: "host"}},
+			certIndexes:  []int{0},
+			wantReason:   "host",
+			wantMatch:    true,
+		},
+		{
+			name:         "other host",
+			suppressions: []input.Suppression{{Issues: mismatch, Hosts: []string{"mail.example.com"}}},
+			certIndexes:  []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := EvalOptions{
+				Now:          now,
+				Suppressions: tt.suppressions,
+				Hosts:        []string{"www.example.com", "192.0.2.10"},
+			}
+
+			selector := func(s input.Suppression) bool {
+				return len(s.Issues) > 0 && s.Issues[0] == input.IssueHostnameMismatch
+			}
+
+			suppression, found := matchSuppression(opts, certChain, tt.certIndexes, selector)
+
+			if found != tt.wantMatch {
+				t.Fatalf("got match %t, want %t", found, tt.wantMatch)
+			}
+
+			if suppression.Reason != tt.wantReason {
+				t.Errorf("got suppression reason %q, want %q", suppression.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSuppressFindings(t *testing.T) {
+	now := time.Now()
+	certChain := newSuppressionTestChain(t, now)
+
+	// The suppression names the serial number of the intermediate
+	// certificate only.
+	opts := EvalOptions{
+		Now: now,
+		Suppressions: []input.Suppression{
+			{
+				Issues:        []string{input.IssueMisorderedCerts, input.IssueWeakKey},
+				SerialNumbers: []string{"02"},
+				Reason:        "intermediate",
+			},
+		},
+	}
+
+	findings := SuppressFindings([]Finding{
+		{Issue: input.IssueMisorderedCerts, CertIndexes: []int{0, 1}},
+		{Issue: input.IssueMisorderedCerts, CertIndexes: []int{1, 0}},
+		{Issue: input.IssueWeakKey, CertIndexes: []int{1}},
+		{Issue: input.IssueWeakKey},
+	}, certChain, opts)
+
+	wantIgnored := []bool{false, false, true, false}
+
+	for idx, finding := range findings {
+		if finding.Ignored != wantIgnored[idx] {
+			t.Errorf("finding %d (%s %v): got ignored %t, want %t",
+				idx, finding.Issue, finding.CertIndexes, finding.Ignored, wantIgnored[idx])
+		}
+
+		if finding.Ignored && finding.Suppression.Reason != "intermediate" {
+			t.Errorf("finding %d: got suppression reason %q, want %q", idx, finding.Suppression.Reason, "intermediate")
+		}
+	}
+}
+
+func TestActiveIssues(t *testing.T) {
+	findings := []Finding{
+		{Issue: input.IssueHostnameMismatch, Ignored: true},
+		{Issue: input.IssueExpiredCerts, Ignored: true},
+		{Issue: input.IssueExpiredCerts},
+		{Issue: input.IssueWeakKey},
+	}
+
+	active := ActiveIssues(findings)
+
+	want := map[string]bool{
+		input.IssueExpiredCerts: true,
+		input.IssueWeakKey:      true,
+	}
+
+	if len(active) != len(want) {
+		t.Fatalf("got active issues %v, want %v", active, want)
+	}
+
+	for issue := range want {
+		if !active[issue] {
+			t.Errorf("issue %s not active", issue)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+// RoleThresholds is the effective set of expiration thresholds for each
+// certificate role.
+type RoleThresholds struct {
+	Leaf         input.ExpirationThresholds
+	Intermediate input.ExpirationThresholds
+	Root         input.ExpirationThresholds
+}
+
+// ExpirationCutoffs is the pair of points in time used to evaluate whether
+// a specific certificate is expiring. A certificate expiring before a cutoff
+// is in the associated state.
+type ExpirationCutoffs struct {
+	// Critical is the point in time before which a certificate expiration
+	// is considered to be in a CRITICAL state.
+	Critical time.Time
+
+	// Warning is the point in time before which a certificate expiration is
+	// considered to be in a WARNING state.
+	Warning time.Time
+
+	// Ignored indicates that the expiration of the certificate is ignored
+	// per a suppression and does not affect the service state.
+	Ignored bool
+}
+
+// EffectiveThresholds returns the expiration thresholds for each
+// certificate role from the given input data. Roles without specified
+// thresholds use the ExpirationAgeInDaysWarningThreshold and
+// ExpirationAgeInDaysCriticalThreshold values.
+func EffectiveThresholds(inputData input.Values) RoleThresholds {
+	fallback := input.ExpirationThresholds{
+		Warning:  input.ExpirationThreshold{Days: inputData.ExpirationAgeInDaysWarningThreshold},
+		Critical: input.ExpirationThreshold{Days: inputData.ExpirationAgeInDaysCriticalThreshold},
+	}
+
+	thresholds := RoleThresholds{
+		Leaf:         inputData.LeafExpirationThresholds,
+		Intermediate: inputData.IntermediateExpirationThresholds,
+		Root:         inputData.RootExpirationThresholds,
+	}
+
+	if !thresholds.Leaf.Enabled() {
+		thresholds.Leaf = fallback
+	}
+
+	if !thresholds.Intermediate.Enabled() {
+		thresholds.Intermediate = fallback
+	}
+
+	if !thresholds.Root.Enabled() {
+		thresholds.Root = fallback
+	}
+
+	return thresholds
+}
+
+// ForCert returns the expiration thresholds for the role of the given
+// certificate within the given certificate chain. Certificates with an
+// unknown role use the leaf thresholds.
+func (rt RoleThresholds) ForCert(cert *x509.Certificate, certChain []*x509.Certificate) input.ExpirationThresholds {
+	switch CertRole(cert, certChain) {
+	case input.CertRoleIntermediate:
+		return rt.Intermediate
+	case input.CertRoleRoot:
+		return rt.Root
+	default:
+		return rt.Leaf
+	}
+}
+
+// ThresholdDuration returns the amount of time before expiration of the
+// given certificate at which the given threshold is reached. This is the
+// largest of the specified day, hour and lifetime percentage values. If the
+// given certificate is nil the lifetime percentage is not evaluated.
+func ThresholdDuration(cert *x509.Certificate, threshold input.ExpirationThreshold) time.Duration {
+	duration := time.Duration(threshold.Days) * 24 * time.Hour
+
+	if hours := time.Duration(threshold.Hours) * time.Hour; hours > duration {
+		duration = hours
+	}
+
+	if cert != nil && threshold.LifetimePercent > 0 {
+		lifetime := cert.NotAfter.Sub(cert.NotBefore)
+		if percent := time.Duration(float64(lifetime) * threshold.LifetimePercent / 100); percent > duration {
+			duration = percent
+		}
+	}
+
+	return duration
+}
+
+// CertExpirationCutoffs returns the expiration cutoffs for the given
+// certificate using the given thresholds as of the given time.
+func CertExpirationCutoffs(cert *x509.Certificate, thresholds input.ExpirationThresholds, now time.Time) ExpirationCutoffs {
+	return ExpirationCutoffs{
+		Critical: now.Add(ThresholdDuration(cert, thresholds.Critical)),
+		Warning:  now.Add(ThresholdDuration(cert, thresholds.Warning)),
+	}
+}
+
+// ChainExpirationCutoffs returns the expiration cutoffs for each
+// certificate in the given chain using the thresholds for the role of each
+// certificate as of the given time. The results are indexed by chain
+// position.
+func ChainExpirationCutoffs(certChain []*x509.Certificate, thresholds RoleThresholds, now time.Time) []ExpirationCutoffs {
+	cutoffs := make([]ExpirationCutoffs, len(certChain))
+
+	for idx, cert := range certChain {
+		cutoffs[idx] = CertExpirationCutoffs(cert, thresholds.ForCert(cert, certChain), now)
+	}
+
+	return cutoffs
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+func TestThresholdDuration(t *testing.T) {
+	const day = 24 * time.Hour
+
+	issued := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// A 90 day certificate.
+	cert := &x509.Certificate{
+		NotBefore: issued,
+		NotAfter:  issued.Add(90 * day),
+	}
+
+	tests := []struct {
+		name      string
+		cert      *x509.Certificate
+		threshold input.ExpirationThreshold
+		want      time.Duration
+	}{
+		{
+			name: "no threshold",
+			cert: cert,
+			want: 0,
+		},
+		{
+			name:      "days only",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Days: 30},
+			want:      30 * day,
+		},
+		{
+			name:      "hours only",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Hours: 12},
+			want:      12 * time.Hour,
+		},
+		{
+			name:      "days larger than hours",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Days: 2, Hours: 36},
+			want:      2 * day,
+		},
+		{
+			name:      "hours larger than days",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Days: 1, Hours: 36},
+			want:      36 * time.Hour,
+		},
+		{
+			name:      "percent only",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{LifetimePercent: 10},
+			want:      9 * day,
+		},
+		{
+			name:      "percent larger than days and hours",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Days: 7, Hours: 48, LifetimePercent: 33},
+			want:      time.Duration(float64(90*day) * 0.33),
+		},
+		{
+			name:      "days larger than percent",
+			cert:      cert,
+			threshold: input.ExpirationThreshold{Days: 30, Hours: 48, LifetimePercent: 10},
+			want:      30 * day,
+		},
+		{
+			name:      "percent ignored without certificate",
+			threshold: input.ExpirationThreshold{Days: 7, LifetimePercent: 33},
+			want:      7 * day,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ThresholdDuration(tt.cert, tt.threshold); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertExpirationCutoffs(t *testing.T) {
+	const day = 24 * time.Hour
+
+	now := time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	cert := &x509.Certificate{
+		NotBefore: now.Add(-30 * day),
+		NotAfter:  now.Add(70 * day),
+	}
+
+	thresholds := input.ExpirationThresholds{
+		Warning:  input.ExpirationThreshold{Days: 14, LifetimePercent: 25},
+		Critical: input.ExpirationThreshold{Days: 7, Hours: 240},
+	}
+
+	cutoffs := CertExpirationCutoffs(cert, thresholds, now)
+
+	if want := now.Add(25 * day); !cutoffs.Warning.Equal(want) {
+		t.Errorf("got warning cutoff %v, want %v", cutoffs.Warning, want)
+	}
+
+	if want := now.Add(10 * day); !cutoffs.Critical.Equal(want) {
+		t.Errorf("got critical cutoff %v, want %v", cutoffs.Critical, want)
+	}
+
+	if cutoffs.Ignored {
+		t.Error("cutoffs unexpectedly marked as ignored")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// ErrInvalidCABundle indicates that a CA bundle used for trust verification
+// could not be used.
+var ErrInvalidCABundle = errors.New("invalid CA bundle")
+
+// Trust store source labels recorded for trust verification results.
+const (
+	TrustStoreSystem string = "system"
+	TrustStorePEM    string = "pem"
+)
+
+// TrustResult is the result of verifying a certificate chain against a
+// trust store.
+type TrustResult struct {
+	// Status is the trust verification status (e.g., verified,
+	// untrusted_root).
+	Status string
+
+	// Err is the error returned by the verification process, if any.
+	Err error
+
+	// Sources is the list of trust store sources used for verification
+	// (e.g., system, the path to a CA bundle file).
+	Sources []string
+
+	// Paths is the list of verified paths from the leaf certificate to a
+	// trusted root certificate.
+	Paths [][]*x509.Certificate
+
+	// CertIndex is the chain position of the certificate a verification
+	// failure is attributed to or -1 if not applicable.
+	CertIndex int
+
+	// AllPaths is the list of all paths from the leaf certificate to a trust
+	// anchor, irrespective of certificate validity periods (see
+	// TrustPaths).
+	AllPaths []TrustPath
+}
+
+// Performed indicates whether trust verification was performed.
+func (tr TrustResult) Performed() bool {
+	return tr.Status != "" && tr.Status != input.TrustStatusNotPerformed
+}
+
+// Verified indicates whether the certificate chain was verified to a trusted
+// root certificate.
+func (tr TrustResult) Verified() bool {
+	return tr.Status == input.TrustStatusVerified
+}
+
+// LoadTrustPool builds a pool of trusted root certificates from the given
+// trust store sources. The pool is returned along with the list of sources
+// used. An error is returned if a source cannot be loaded or does not
+// contain any certificates.
+func LoadTrustPool(ts input.TrustStore) (*x509.CertPool, []string, error) {
+	pool := x509.NewCertPool()
+	sources := make([]string, 0, len(ts.CABundleFiles)+2)
+
+	if ts.SystemRoots {
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading system trust store: %w", err)
+		}
+
+		pool = systemPool
+		sources = append(sources, TrustStoreSystem)
+	}
+
+	for _, filename := range ts.CABundleFiles {
+		data, err := os.ReadFile(filename) // #nosec G304 -- caller specified CA bundle
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading CA bundle %s: %w", filename, err)
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf(
+				"no certificates found in CA bundle %s: %w",
+				filename,
+				ErrInvalidCABundle,
+			)
+		}
+
+		sources = append(sources, filename)
+	}
+
+	if len(ts.CABundlePEM) > 0 {
+		if !pool.AppendCertsFromPEM(ts.CABundlePEM) {
+			return nil, nil, fmt.Errorf(
+				"no certificates found in provided PEM CA bundle: %w",
+				ErrInvalidCABundle,
+			)
+		}
+
+		sources = append(sources, TrustStorePEM)
+	}
+
+	return pool, sources, nil
+}
+
+// VerifyTrust verifies the given certificate chain against the given trust
+// store sources as of the given time. The first leaf certificate in the
+// chain is verified using the remaining certificates in the chain as
+// intermediates. If trust verification was not requested a result with a
+// not_performed status is returned. An error is returned if the trust store
+// cannot be loaded.
+func VerifyTrust(certChain []*x509.Certificate, ts input.TrustStore, now time.Time) (TrustResult, error) {
+	result := TrustResult{
+		Status:    input.TrustStatusNotPerformed,
+		CertIndex: -1,
+	}
+
+	leafIdx := certs.FirstLeafIndex(certChain)
+	if !ts.Enabled() || leafIdx == -1 {
+		return result, nil
+	}
+
+	roots, sources, err := LoadTrustPool(ts)
+	if err != nil {
+		return result, err
+	}
+
+	result.Sources = sources
+	result.AllPaths = TrustPaths(certChain, roots)
+
+	intermediates := x509.NewCertPool()
+	for idx, cert := range certChain {
+		if idx != leafIdx {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	paths, verifyErr := certChain[leafIdx].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+
+		// Verification of the intended use of the leaf certificate is out of
+		// scope; only the path to a trusted root is of interest here.
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	result.Err = verifyErr
+
+	var uaErr x509.UnknownAuthorityError
+
+	switch {
+	case verifyErr == nil:
+		result.Status = input.TrustStatusVerified
+		result.Paths = paths
+
+	case errors.As(verifyErr, &uaErr) && uaErr.Cert != nil:
+		result.Status = input.TrustStatusUnknownAuthority
+		result.CertIndex = ChainIndex(uaErr.Cert, certChain)
+
+		if certs.IsSelfSigned(uaErr.Cert) {
+			result.Status = input.TrustStatusUntrustedRoot
+		}
+
+		// Attribute the failure to the topmost certificate of the validation
+		// path when the unverified certificate is not part of the chain.
+		if result.CertIndex == -1 {
+			path := certs.ValidationPath(certChain)
+			result.CertIndex = path[len(path)-1]
+		}
+
+	default:
+		result.Status = input.TrustStatusInvalid
+		result.CertIndex = leafIdx
+
+		var invalidErr x509.CertificateInvalidError
+		if errors.As(verifyErr, &invalidErr) && invalidErr.Cert != nil {
+			if idx := ChainIndex(invalidErr.Cert, certChain); idx != -1 {
+				result.CertIndex = idx
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// UntrustedChainFindings returns a finding if trust verification was
+// performed for the certificate chain and the chain could not be verified to
+// a trusted root certificate.
+func UntrustedChainFindings(result TrustResult) []Finding {
+	if !result.Performed() || result.Verified() || result.CertIndex == -1 {
+		return nil
+	}
+
+	return []Finding{
+		{
+			Issue:       input.IssueUntrustedChain,
+			CertIndexes: []int{result.CertIndex},
+			Evidence: fmt.Sprintf(
+				"cert %d not verified to a trusted root (%s): %v",
+				result.CertIndex,
+				result.Status,
+				result.Err,
+			),
+		},
+	}
+}
+
+// ChainIndex returns the position of the given certificate in the given
+// certificate chain or -1 if not present.
+func ChainIndex(cert *x509.Certificate, certChain []*x509.Certificate) int {
+	for idx, candidate := range certChain {
+		if candidate.Equal(cert) {
+			return idx
+		}
+	}
+
+	return -1
+}
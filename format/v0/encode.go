@@ -22,6 +22,10 @@ import (
 // the specified format version. An error is returned if one occurs during
 // processing or if an invalid payload version format is specified.
 func Encode(inputData input.Values) ([]byte, error) {
+	if err := shared.ValidateIssueStates(inputData.IssueStates); err != nil {
+		return nil, err
+	}
+
 	// FIXME: We may want to accept this as an argument for testing purposes.
 	now := time.Now().UTC()
 
@@ -82,24 +86,20 @@ func Encode(inputData input.Values) ([]byte, error) {
 			Summary:                   expiresText,
 			Status:                    certStatus,
 			SignatureAlgorithm:        origCert.SignatureAlgorithm.String(),
-			Type:                      certs.ChainPosition(origCert, certChain),
+			Type:                      certs.LegacyChainPosition(origCert, certChain),
 		}
 
 		certChainSubset = append(certChainSubset, certSubset)
 	}
 
-	hostVal := hostnameValue(inputData)
+	certChainIssues := chainIssues(inputData)
 
-	certChainIssues := CertificateChainIssues{
-		MissingIntermediateCerts: shared.HasMissingIntermediateCerts(certChain),
-		MissingSANsEntries:       shared.HasMissingSANsEntries(certChain),
-		DuplicateCerts:           shared.HasDuplicateCertsInChain(certChain),
-		MisorderedCerts:          shared.HasMisorderedCerts(certChain),
-		ExpiredCerts:             shared.HasExpiredCerts(certChain),
-		HostnameMismatch:         shared.HasHostnameMismatch(hostVal, certChain),
-		SelfSignedLeafCert:       shared.HasSelfSignedLeaf(certChain),
-		WeakSignatureAlgorithm:   shared.HasWeakSignatureAlgorithm(certChain),
-	}
+	serviceState, errs := serviceState(
+		inputData,
+		certsExpireAgeCritical,
+		certsExpireAgeWarning,
+		certChainIssues,
+	)
 
 	// Only if the user explicitly requested the full cert payload do we
 	// include it (due to significant payload size increase and risk of
@@ -125,14 +125,14 @@ func Encode(inputData input.Values) ([]byte, error) {
 
 	payload := CertChainPayload{
 		FormatVersion:     FormatVersion,
-		Errors:            shared.ErrorsToStrings(inputData.Errors),
+		Errors:            shared.ErrorsToStrings(errs),
 		CertChainOriginal: certChainOriginal,
 		CertChainSubset:   certChainSubset,
 		Server:            server,
 		DNSName:           inputData.DNSName,
 		TCPPort:           inputData.TCPPort,
 		Issues:            certChainIssues,
-		ServiceState:      inputData.ServiceState,
+		ServiceState:      serviceState,
 	}
 
 	payloadJSON, err := json.Marshal(payload)
@@ -146,6 +146,24 @@ func Encode(inputData input.Values) ([]byte, error) {
 	return payloadJSON, nil
 }
 
+// chainIssues is a helper function that evaluates the certificate chain
+// provided by the given input data and returns the detected problems.
+func chainIssues(inputData input.Values) CertificateChainIssues {
+	certChain := inputData.CertChain
+	hostVal := hostnameValue(inputData)
+
+	return CertificateChainIssues{
+		MissingIntermediateCerts: shared.HasMissingIntermediateCerts(certChain),
+		MissingSANsEntries:       shared.HasMissingSANsEntries(certChain),
+		DuplicateCerts:           shared.HasDuplicateCertsInChain(certChain),
+		MisorderedCerts:          shared.HasMisorderedCerts(certChain),
+		ExpiredCerts:             shared.HasExpiredCerts(certChain),
+		HostnameMismatch:         shared.HasHostnameMismatch(hostVal, certChain),
+		SelfSignedLeafCert:       shared.HasSelfSignedLeaf(certChain),
+		WeakSignatureAlgorithm:   shared.HasWeakSignatureAlgorithm(certChain),
+	}
+}
+
 // sansEntries evaluates given input options and either returns all Subject
 // Alternate Names for a given certificate or nil to indicate that a sysadmin
 // opted out of recording SANs entries.
@@ -0,0 +1,58 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format1_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// linkageGolden is the subset of the payload which depends on how issuer and
+// subject names are linked. The golden files were generated using the v1
+// format implementation predating issuer linkage by raw names and key
+// identifiers and assert that the output of this stable format version is
+// unchanged.
+type linkageGolden struct {
+	CertTypes []string                       `json:"cert_types"`
+	Issues    format1.CertificateChainIssues `json:"cert_chain_issues"`
+}
+
+func TestEncodeIssuerLinkageUnchanged(t *testing.T) {
+	for _, fixture := range testutil.IssuerLinkageChains(t) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			payloadJSON, err := format1.Encode(input.Values{
+				CertChain: fixture.Chain,
+				Server:    input.Server{HostValue: "www.example.com"},
+			})
+			if err != nil {
+				t.Fatalf("failed to encode payload: %v", err)
+			}
+
+			var payload format1.CertChainPayload
+			if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+
+			got := linkageGolden{Issues: payload.Issues}
+			for _, cert := range payload.CertChainSubset {
+				got.CertTypes = append(got.CertTypes, cert.Type)
+			}
+
+			gotJSON, err := json.MarshalIndent(got, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			testutil.AssertGolden(t, fixture.Name+".golden", append(gotJSON, '\n'))
+		})
+	}
+}
@@ -7,6 +7,8 @@
 
 package format1
 
+import "github.com/atc0005/cert-payload/issues"
+
 // Confirmed is a helper function to indicate whether issues are present
 // with the evaluated certificate chain.
 func (cci CertificateChainIssues) Confirmed() bool {
@@ -39,3 +41,9 @@ func (cci CertificateChainIssues) Confirmed() bool {
 		return false
 	}
 }
+
+// Detected returns the names of all detected certificate chain issues.
+// Names match the JSON field names of the CertificateChainIssues type.
+func (cci CertificateChainIssues) Detected() []string {
+	return issues.Detected(cci)
+}
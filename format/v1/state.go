@@ -0,0 +1,90 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/atc0005/cert-payload/format/internal/shared"
+	"github.com/atc0005/cert-payload/input"
+)
+
+// EvaluateServiceState evaluates the certificate chain, expiration
+// thresholds and certificate chain issues for the given input data and
+// returns the resulting service state (e.g., OK, WARNING, CRITICAL,
+// UNKNOWN). The caller provided ServiceState value is not consulted.
+//
+// An error is returned if the certificate chain contains a nil certificate or
+// if an invalid service state is specified for a certificate chain issue.
+func EvaluateServiceState(inputData input.Values) (string, error) {
+	if err := shared.ValidateIssueStates(inputData.IssueStates); err != nil {
+		return "", err
+	}
+
+	for certNumber, cert := range inputData.CertChain {
+		if cert == nil {
+			return "", fmt.Errorf(
+				"cert in chain position %d of %d is nil: %w",
+				certNumber,
+				len(inputData.CertChain),
+				ErrMissingValue,
+			)
+		}
+	}
+
+	now := time.Now().UTC()
+
+	return shared.EvaluateServiceState(
+		inputData.CertChain,
+		now.AddDate(0, 0, inputData.ExpirationAgeInDaysCriticalThreshold),
+		now.AddDate(0, 0, inputData.ExpirationAgeInDaysWarningThreshold),
+		chainIssues(inputData).Detected(),
+		inputData.IssueStates,
+	), nil
+}
+
+// serviceState is a helper function that applies the requested service state
+// mode to the given input data. The service state to record in the payload
+// is returned along with the collection of errors to record. If requested, a
+// mismatch between the caller provided and evaluated service state is
+// appended to a copy of the caller provided errors.
+func serviceState(
+	inputData input.Values,
+	ageCritical time.Time,
+	ageWarning time.Time,
+	issues CertificateChainIssues,
+) (string, []error) {
+	if inputData.ServiceStateMode == input.ServiceStateCallerProvided {
+		return inputData.ServiceState, inputData.Errors
+	}
+
+	evaluatedState := shared.EvaluateServiceState(
+		inputData.CertChain,
+		ageCritical,
+		ageWarning,
+		issues.Detected(),
+		inputData.IssueStates,
+	)
+
+	state, mismatchErr := shared.ResolveServiceState(
+		inputData.ServiceStateMode,
+		inputData.ServiceState,
+		evaluatedState,
+	)
+
+	if mismatchErr == nil {
+		return state, inputData.Errors
+	}
+
+	errs := make([]error, 0, len(inputData.Errors)+1)
+	errs = append(errs, inputData.Errors...)
+	errs = append(errs, mismatchErr)
+
+	return state, errs
+}
@@ -0,0 +1,551 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/lint"
+)
+
+// Certificate evaluation status values.
+const (
+	// CertNotPresent indicates that a certificate chain was successfully
+	// retrieved, but a specific certificate was not present in the chain.
+	CertNotPresentInChain string = "not present"
+
+	// CertChainNotFound indicates that a certificate chain was not
+	// successfully retrieved, so we can not make a determination whether a
+	// specific certificate is present in the chain.
+	CertChainNotFound string = "cert chain not found"
+)
+
+// LowestCertLifetimeValue returns the lowest remaining lifetime between
+// certificates in the certificate chain.
+func (cs Certificates) LowestCertLifetimeValue() float64 {
+	var lowest float64
+
+	// Seed starting value
+	if len(cs) > 0 {
+		lowest = cs[0].DaysRemaining
+	}
+
+	for _, cert := range cs {
+		if cert.DaysRemaining < lowest {
+			lowest = cert.DaysRemaining
+		}
+	}
+
+	return lowest
+}
+
+// HighestCertLifetimeValue returns the highest remaining lifetime between
+// certificates in the certificate chain.
+func (cs Certificates) HighestCertLifetimeValue() float64 {
+	var highest float64
+
+	for _, cert := range cs {
+		if cert.DaysRemaining > highest {
+			highest = cert.DaysRemaining
+		}
+	}
+
+	return highest
+}
+
+// LowestLeafCertLifetimeValue returns the lowest remaining lifetime between
+// leaf certificates in the certificate chain.
+func (cs Certificates) LowestLeafCertLifetimeValue() float64 {
+	var lowest float64
+
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			if lowest == 0 {
+				lowest = cert.DaysRemaining
+			}
+
+			if cert.DaysRemaining < lowest {
+				lowest = cert.DaysRemaining
+			}
+		}
+	}
+
+	return lowest
+}
+
+// HighestLeafCertLifetimeValue returns the highest remaining lifetime between
+// leaf certificates in the certificate chain.
+func (cs Certificates) HighestLeafCertLifetimeValue() float64 {
+	var highest float64
+
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			if cert.DaysRemaining > highest {
+				highest = cert.DaysRemaining
+			}
+		}
+	}
+
+	return highest
+}
+
+// HasExpiringLeafs indicates that there is an expiring intermediate
+// certificate in the certificate chain.
+func (cs Certificates) HasExpiringLeafs() bool {
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			if cert.Status.Expiring {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasExpiredLeafs indicates that there is an expired leaf certificate
+// in the certificate chain.
+func (cs Certificates) HasExpiredLeafs() bool {
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			if cert.Status.Expired {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// LowestIntermediateCertLifetimeValue returns the lowest remaining lifetime
+// between intermediate certificates in the certificate chain.
+func (cs Certificates) LowestIntermediateCertLifetimeValue() float64 {
+	var lowest float64
+
+	for _, cert := range cs {
+		if certs.IsIntermediatePosition(cert.Type) {
+			if lowest == 0 {
+				lowest = cert.DaysRemaining
+			}
+
+			if cert.DaysRemaining < lowest {
+				lowest = cert.DaysRemaining
+			}
+		}
+	}
+
+	return lowest
+}
+
+// HighestIntermediateCertLifetimeValue returns the highest remaining lifetime
+// between intermediate certificates in the certificate chain.
+func (cs Certificates) HighestIntermediateCertLifetimeValue() float64 {
+	var highest float64
+
+	for _, cert := range cs {
+		if certs.IsIntermediatePosition(cert.Type) {
+			if cert.DaysRemaining > highest {
+				highest = cert.DaysRemaining
+			}
+		}
+	}
+
+	return highest
+}
+
+// HasExpiringIntermediates indicates that there is an expiring intermediate
+// certificate in the certificate chain.
+func (cs Certificates) HasExpiringIntermediates() bool {
+	for _, cert := range cs {
+		if certs.IsIntermediatePosition(cert.Type) {
+			if cert.Status.Expiring {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasExpiredIntermediates indicates that there is an expired intermediate
+// certificate in the certificate chain.
+func (cs Certificates) HasExpiredIntermediates() bool {
+	for _, cert := range cs {
+		if certs.IsIntermediatePosition(cert.Type) {
+			if cert.Status.Expired {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasCrossSignedIntermediates indicates that there is a cross-signed
+// intermediate certificate in the certificate chain.
+func (cs Certificates) HasCrossSignedIntermediates() bool {
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionIntermediateCrossSigned {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasLintErrors indicates that there is a certificate in the certificate
+// chain with a failed lint of ERROR severity.
+func (cs Certificates) HasLintErrors() bool {
+	for _, cert := range cs {
+		for _, certLint := range cert.Lints {
+			if certLint.Severity == lint.SeverityError {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasNonCompliantValidityPeriods indicates that there is a leaf certificate
+// in the certificate chain with a validity period exceeding the maximum in
+// force when it was issued.
+func (cs Certificates) HasNonCompliantValidityPeriods() bool {
+	for _, cert := range cs {
+		if cert.ValidityCompliance.Evaluated && !cert.ValidityCompliance.Compliant {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NonCompliantRenewalOn returns the earliest date from which a renewal of a
+// leaf certificate in the certificate chain using the same validity period
+// is no longer compliant with the schedule of maximum validity periods. The
+// zero value is returned if all renewals remain compliant.
+func (cs Certificates) NonCompliantRenewalOn() time.Time {
+	var earliest time.Time
+
+	for _, cert := range cs {
+		renewalOn := cert.ValidityCompliance.NonCompliantRenewalOn
+		if renewalOn.IsZero() {
+			continue
+		}
+
+		if earliest.IsZero() || renewalOn.Before(earliest) {
+			earliest = renewalOn
+		}
+	}
+
+	return earliest
+}
+
+// IntermediateExpiringFirst returns the intermediate certificate expiring
+// first in the certificate chain or a zero value Certificate.
+func (cs Certificates) IntermediateExpiringFirst() Certificate {
+	var lowestIntermediate Certificate
+
+	for _, cert := range cs {
+		if certs.IsIntermediatePosition(cert.Type) {
+			if lowestIntermediate.IssuedOn.IsZero() {
+				lowestIntermediate = cert
+			}
+
+			if cert.DaysRemaining < lowestIntermediate.DaysRemaining {
+				lowestIntermediate = cert
+			}
+		}
+	}
+
+	return lowestIntermediate
+}
+
+// FirstLeaf returns the first leaf certificate in the certificate chain or a
+// zero value Certificate if there isn't one (e.g., a manually constructed
+// chain).
+func (cs Certificates) FirstLeaf() Certificate {
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			return cert
+		}
+	}
+
+	return Certificate{}
+}
+
+// LeafExpirationDescription returns a human readable version of the
+// expiration details for the first leaf certificate in the certificate chain.
+func (cs Certificates) LeafExpirationDescription() string {
+	var firstLeaf Certificate
+
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			firstLeaf = cert
+		}
+	}
+
+	switch {
+	case len(cs) == 0:
+		return CertChainNotFound
+
+	case firstLeaf.IssuedOn.IsZero():
+		// We couldn't find a leaf cert. This could happen when we're
+		// monitoring an intermediates bundle on disk.
+		return CertNotPresentInChain
+
+	default:
+		return fmt.Sprintf(
+			"%s (%s)",
+			FormattedExpiration(firstLeaf, "", ""),
+			FormattedLifetime(firstLeaf),
+		)
+	}
+}
+
+// LeafLengthDescription returns a human readable version of the certificate
+// lifetime for the first leaf certificate in the certificate chain. If a leaf
+// certificate is not available (e.g., if monitoring an intermediates bundle)
+// "N/A" will be returned.
+func (cs Certificates) LeafLengthDescription() string {
+	var firstLeaf Certificate
+
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionLeaf || cert.Type == certs.CertChainPositionLeafSelfSigned {
+			firstLeaf = cert
+		}
+	}
+
+	switch {
+	case len(cs) == 0:
+		return CertChainNotFound
+
+	case firstLeaf.IssuedOn.IsZero():
+		// We couldn't find a leaf cert. This could happen when we're
+		// monitoring an intermediates bundle on disk.
+		return "N/A"
+
+	default:
+		return firstLeaf.ValidityPeriodDescription
+	}
+}
+
+// IntermediateExpirationDescription returns a human readable version of the
+// expiration details for the intermediate certificate expiring first in the
+// certificate chain.
+func (cs Certificates) IntermediateExpirationDescription() string {
+	oldestIntermediate := cs.IntermediateExpiringFirst()
+
+	switch {
+	case len(cs) == 0:
+		return CertChainNotFound
+
+	case oldestIntermediate.IssuedOn.IsZero():
+		return CertNotPresentInChain
+
+	default:
+		return fmt.Sprintf(
+			"%s (%s)",
+			FormattedExpiration(oldestIntermediate, "", ""),
+			FormattedLifetime(oldestIntermediate),
+		)
+	}
+}
+
+// FormattedExpiration formats the expiration date for the given certificate
+// using an optional custom unit of measurement and an optional precision
+// format string.
+func FormattedExpiration(cert Certificate, uom string, precisionFmtString string) string {
+	var leadInText string
+
+	defaultUOM := "d" // days
+	if uom == "" {
+		uom = defaultUOM
+	}
+
+	daysRemaining := cert.DaysRemaining
+
+	if daysRemaining < 0 {
+		// If negative value, flip to positive.
+		daysRemaining = float64(math.Abs(daysRemaining))
+
+		// Since we're tracking time (using 'd' as default uom for days),
+		// we'll use "ago" to communicate that the event has already occurred.
+		uom += " ago"
+
+		leadInText = "expired "
+	}
+
+	// Opt for one decimal place over two by default to reduce visual "noise".
+	defaultPrecisionFmtString := "%.1f"
+
+	if precisionFmtString == "" {
+		precisionFmtString = defaultPrecisionFmtString
+	}
+
+	fmtString := "%s" + precisionFmtString + "%s"
+
+	return fmt.Sprintf(fmtString, leadInText, daysRemaining, uom)
+}
+
+// FormattedLifetime formats the remaining (positive) lifetime for a given
+// certificate.
+func FormattedLifetime(cert Certificate) string {
+	uom := "%"
+	lifetime := cert.LifetimePercent
+
+	if lifetime < 0 {
+		lifetime = 0
+	}
+
+	return fmt.Sprintf("%d%s left", lifetime, uom)
+}
+
+// FormattedRenewal describes the expected renewal of the given certificate
+// (e.g., "should have renewed 5 days ago" or "renewal expected in 12 days").
+// Hours are used if the expected renewal is less than a day away (e.g.,
+// "renewal expected in 5 hours"). An empty string is returned if renewal was
+// not evaluated for the certificate.
+func FormattedRenewal(cert Certificate) string {
+	if !cert.Renewal.Evaluated {
+		return ""
+	}
+
+	remaining := int(math.Abs(cert.Renewal.DaysUntilExpected))
+	uom := "day"
+
+	if remaining == 0 {
+		remaining = int(math.Abs(cert.Renewal.DaysUntilExpected * 24))
+		uom = "hour"
+	}
+
+	if remaining != 1 {
+		uom += "s"
+	}
+
+	switch {
+	case remaining == 0:
+		return "renewal due now"
+	case cert.Renewal.DaysUntilExpected < 0:
+		return fmt.Sprintf("should have renewed %d %s ago", remaining, uom)
+	default:
+		return fmt.Sprintf("renewal expected in %d %s", remaining, uom)
+	}
+}
+
+// HasOverdueRenewals indicates that there is a leaf certificate in the
+// certificate chain which has passed the expected renewal point.
+func (cs Certificates) HasOverdueRenewals() bool {
+	return len(cs.OverdueRenewals()) > 0
+}
+
+// OverdueRenewals returns the leaf certificates in the certificate chain
+// which have passed the expected renewal point.
+func (cs Certificates) OverdueRenewals() Certificates {
+	var overdue Certificates
+
+	for _, cert := range cs {
+		if cert.Renewal.Evaluated && cert.Renewal.Overdue {
+			overdue = append(overdue, cert)
+		}
+	}
+
+	return overdue
+}
+
+// NextRenewal returns the leaf certificate in the certificate chain with the
+// earliest expected renewal point or a zero value Certificate if renewal was
+// not evaluated.
+func (cs Certificates) NextRenewal() Certificate {
+	var next Certificate
+
+	for _, cert := range cs {
+		if !cert.Renewal.Evaluated {
+			continue
+		}
+
+		if !next.Renewal.Evaluated || cert.Renewal.ExpectedOn.Before(next.Renewal.ExpectedOn) {
+			next = cert
+		}
+	}
+
+	return next
+}
+
+// EffectiveExpiration returns the certificate which determines when the
+// certificate chain as a whole stops working or a zero value Certificate if
+// the certificate chain is empty. This is the certificate expiring first
+// among the certificates needed to validate the leaf certificate.
+func (cs Certificates) EffectiveExpiration() Certificate {
+	var earliest Certificate
+
+	for _, cert := range cs {
+		if !cert.ValidationPath {
+			continue
+		}
+
+		if earliest.IssuedOn.IsZero() || cert.ExpiresOn.Before(earliest.ExpiresOn) {
+			earliest = cert
+		}
+	}
+
+	return earliest
+}
+
+// EffectiveExpirationDescription returns a human readable version of the
+// expiration details for the certificate which determines when the
+// certificate chain as a whole stops working.
+func (cs Certificates) EffectiveExpirationDescription() string {
+	effective := cs.EffectiveExpiration()
+
+	switch {
+	case len(cs) == 0:
+		return CertChainNotFound
+
+	case effective.IssuedOn.IsZero():
+		return CertNotPresentInChain
+
+	default:
+		return fmt.Sprintf(
+			"%s (%s, %s)",
+			FormattedExpiration(effective, "", ""),
+			effective.Type,
+			effective.CommonName,
+		)
+	}
+}
+
+// HasLeafOutlivingIssuer asserts that a leaf certificate in the certificate
+// chain expires after the certificate which issued it.
+func (cs Certificates) HasLeafOutlivingIssuer() bool {
+	for _, leaf := range cs {
+		if leaf.Type != certs.CertChainPositionLeaf {
+			continue
+		}
+
+		if issuer, ok := cs.IssuerOf(leaf); ok && issuer.ExpiresOn.Before(leaf.ExpiresOn) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IssuerOf returns the certificate in the certificate chain which issued the
+// given certificate and a boolean value indicating whether the issuer is
+// present in the chain.
+func (cs Certificates) IssuerOf(cert Certificate) (Certificate, bool) {
+	if cert.IssuerIndex < 0 || cert.IssuerIndex >= len(cs) {
+		return Certificate{}, false
+	}
+
+	return cs[cert.IssuerIndex], true
+}
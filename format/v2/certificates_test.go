@@ -0,0 +1,80 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2_test
+
+import (
+	"testing"
+
+	format2 "github.com/atc0005/cert-payload/format/v2"
+)
+
+func TestFormattedRenewal(t *testing.T) {
+	tests := []struct {
+		name              string
+		evaluated         bool
+		daysUntilExpected float64
+		want              string
+	}{
+		{
+			name:              "not evaluated",
+			daysUntilExpected: 12,
+			want:              "",
+		},
+		{
+			name:              "days until renewal",
+			evaluated:         true,
+			daysUntilExpected: 12.5,
+			want:              "renewal expected in 12 days",
+		},
+		{
+			name:              "one day until renewal",
+			evaluated:         true,
+			daysUntilExpected: 1.2,
+			want:              "renewal expected in 1 day",
+		},
+		{
+			name:              "hours until renewal",
+			evaluated:         true,
+			daysUntilExpected: 0.96,
+			want:              "renewal expected in 23 hours",
+		},
+		{
+			name:              "renewal due now",
+			evaluated:         true,
+			daysUntilExpected: 0.02,
+			want:              "renewal due now",
+		},
+		{
+			name:              "renewal overdue by hours",
+			evaluated:         true,
+			daysUntilExpected: -0.05,
+			want:              "should have renewed 1 hour ago",
+		},
+		{
+			name:              "renewal overdue by days",
+			evaluated:         true,
+			daysUntilExpected: -5.5,
+			want:              "should have renewed 5 days ago",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := format2.Certificate{
+				Renewal: format2.CertificateRenewal{
+					Evaluated:         tt.evaluated,
+					DaysUntilExpected: tt.daysUntilExpected,
+				},
+			}
+
+			if got := format2.FormattedRenewal(cert); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
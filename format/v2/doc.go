@@ -0,0 +1,18 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package format2 implements the second certificate payload format.
+//
+// This format version extends format 1 with additional certificate and
+// certificate chain metadata. Changes to behavior which are not compatible
+// with format 1 are provided by this format version so that format 1 remains
+// stable.
+//
+// NOTE: This format version is under active development and has not yet been
+// declared stable. You are encouraged to use a stable format version (e.g., 1)
+// if stability is a goal.
+package format2
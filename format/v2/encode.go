@@ -0,0 +1,746 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/atc0005/cert-payload/format/internal/shared"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/internal/revocation"
+	"github.com/atc0005/cert-payload/internal/weakkeys"
+	"github.com/atc0005/cert-payload/lint"
+)
+
+// Encode processes the given certificate chain and returns a JSON payload of
+// the specified format version. An error is returned if one occurs during
+// processing or if an invalid payload version format is specified.
+func Encode(inputData input.Values) ([]byte, error) {
+	if err := shared.ValidateIssueStates(inputData.IssueStates); err != nil {
+		return nil, err
+	}
+
+	// FIXME: We may want to accept this as an argument for testing purposes.
+	now := time.Now().UTC()
+
+	certChain := inputData.CertChain
+
+	// Assert that the chain is valid before evaluating it as a whole.
+	for certNumber, origCert := range certChain {
+		if origCert == nil {
+			return nil, fmt.Errorf(
+				"cert in chain position %d of %d is nil: %w",
+				certNumber,
+				len(certChain),
+				ErrMissingValue,
+			)
+		}
+	}
+
+	for fetchedNumber, fetched := range inputData.FetchedIssuers {
+		if fetched.Cert == nil {
+			return nil, fmt.Errorf(
+				"fetched issuer cert %d of %d is nil: %w",
+				fetchedNumber,
+				len(inputData.FetchedIssuers),
+				ErrMissingValue,
+			)
+		}
+	}
+
+	opts, optsErr := evalOptions(inputData, now)
+	if optsErr != nil {
+		return nil, optsErr
+	}
+
+	findings := shared.ChainFindings(certChain, opts)
+
+	lintProfile := inputData.LintProfile
+	if lintProfile == "" {
+		lintProfile = input.LintProfileInternalPKI
+	}
+
+	lintResults, err := lint.Chain(certChain, lintProfile)
+	if err != nil {
+		return nil, fmt.Errorf("error linting cert chain: %w", err)
+	}
+
+	renewals := shared.ChainRenewals(certChain, opts.RenewalPolicy)
+
+	thresholds := shared.EffectiveThresholds(inputData)
+	cutoffs := expirationCutoffs(certChain, thresholds, opts)
+
+	validationPath := certs.ValidationPath(certChain)
+
+	// Certificates from the trust store are considered when identifying
+	// cross-signed intermediate certificates.
+	positionPool := append(
+		append([]*x509.Certificate{}, certChain...),
+		shared.PathCerts(opts.Trust.AllPaths, certChain)...,
+	)
+	inValidationPath := make(map[int]bool, len(validationPath))
+	for _, idx := range validationPath {
+		inValidationPath[idx] = true
+	}
+
+	certChainSubset := make([]Certificate, 0, len(certChain))
+	for certNumber, origCert := range certChain {
+		certThresholds := thresholds.ForCert(origCert, certChain)
+
+		expirationSuppression, ignoreExpiration := shared.ExpirationSuppression(certChain, certNumber, opts)
+
+		lifetimePercent := lifetimeRemainingPercent(origCert, now)
+
+		expiresText := certs.ExpirationStatusWithLifetime(
+			origCert,
+			cutoffs[certNumber].Critical,
+			cutoffs[certNumber].Warning,
+			ignoreExpiration,
+			lifetimePercent,
+		)
+
+		isNotYetValid := certs.IsNotYetValidCert(origCert, inputData.ClockSkewTolerance, now)
+		if isNotYetValid {
+			expiresText = certs.NotYetValidStatus(origCert)
+		}
+
+		// Unlike earlier format versions, the status reflects this
+		// certificate only; see the chain status for the certificate chain
+		// as a whole.
+		isExpiring := certs.IsExpiringCert(origCert, cutoffs[certNumber].Critical, cutoffs[certNumber].Warning)
+		isExpired := certs.IsExpiredCert(origCert)
+		isExpirationIgnored := ignoreExpiration && (isExpiring || isExpired)
+		if !isExpirationIgnored {
+			expirationSuppression = input.Suppression{}
+		}
+
+		// An ignored expiration does not affect the overall status.
+		expirationOK := isExpirationIgnored || (!isExpired && !isExpiring)
+
+		keyInfo := certs.KeyInfo(origCert)
+
+		revocationStatus := certificateRevocation(opts.Revocation[certNumber])
+		isRevoked := revocationStatus.Status == input.RevocationStatusRevoked
+
+		certStatus := CertificateStatus{
+			OK:                expirationOK && !isNotYetValid && !isRevoked,
+			Expiring:          isExpiring,
+			Expired:           isExpired,
+			NotYetValid:       isNotYetValid,
+			Revoked:           isRevoked,
+			RevokedPerCRL:     revocationStatus.CRL.revoked(),
+			RevokedPerOCSP:    revocationStatus.OCSP.revoked(),
+			ExpirationIgnored: isExpirationIgnored,
+		}
+
+		daysUntilValid, validLookupErr := certs.ValidInDaysPrecise(origCert)
+		if validLookupErr != nil {
+			return nil, validLookupErr
+		}
+
+		certExpMeta, lookupErr := shared.LookupCertExpMetadata(origCert, certNumber, certChain)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+
+		validityPeriodDescription := shared.LookupValidityPeriodDescription(origCert)
+
+		certSubset := Certificate{
+			Subject:                   origCert.Subject.String(),
+			CommonName:                origCert.Subject.CommonName,
+			SANsEntries:               sansEntries(origCert, inputData),
+			SANsEntriesCount:          len(origCert.DNSNames),
+			Issuer:                    origCert.Issuer.String(),
+			IssuerShort:               origCert.Issuer.CommonName,
+			IssuerIndex:               certs.IssuerIndex(origCert, certChain),
+			SubjectKeyID:              certs.FormatKeyID(origCert.SubjectKeyId),
+			AuthorityKeyID:            certs.FormatKeyID(origCert.AuthorityKeyId),
+			SerialNumber:              certs.FormatCertSerialNumber(origCert.SerialNumber),
+			IssuedOn:                  origCert.NotBefore,
+			ExpiresOn:                 origCert.NotAfter,
+			DaysRemaining:             certExpMeta.DaysRemainingPrecise,
+			DaysRemainingTruncated:    certExpMeta.DaysRemainingTruncated,
+			DaysUntilValid:            daysUntilValid,
+			ExpiringWarningOn:         origCert.NotAfter.Add(-shared.ThresholdDuration(origCert, certThresholds.Warning)),
+			ExpiringCriticalOn:        origCert.NotAfter.Add(-shared.ThresholdDuration(origCert, certThresholds.Critical)),
+			LifetimePercent:           lifetimePercent,
+			ValidityPeriodDescription: validityPeriodDescription,
+			ValidityPeriodDays:        certExpMeta.ValidityPeriodDays,
+			ValidityCompliance:        validityCompliance(origCert, certChain),
+			Summary:                   expiresText,
+			Status:                    certStatus,
+			ExpirationIgnoredReason:   expirationSuppression.Reason,
+			ExpirationIgnoredUntil:    expirationSuppression.Expires,
+			SignatureAlgorithm:        origCert.SignatureAlgorithm.String(),
+			KeyAlgorithm:              keyInfo.Algorithm,
+			KeySize:                   keyInfo.Bits,
+			KeyCurve:                  keyInfo.Curve,
+			RSAExponent:               keyInfo.RSAExponent,
+			Type:                      certs.ChainPosition(origCert, positionPool),
+			ValidationPath:            inValidationPath[certNumber],
+			MustStaple:                revocation.MustStaple(origCert),
+			Revocation:                revocationStatus,
+			Renewal:                   certificateRenewal(renewals[certNumber], now),
+			Issues:                    certificateIssues(findings, certNumber),
+			Lints:                     certificateLints(lintResults[certNumber]),
+		}
+
+		certChainSubset = append(certChainSubset, certSubset)
+	}
+
+	certChainIssues := chainIssues(findings)
+
+	serviceState, errs := serviceState(inputData, cutoffs, certChainIssues)
+
+	// Only if the user explicitly requested the full cert payload do we
+	// include it (due to significant payload size increase and risk of
+	// exceeding size constraints).
+	var certChainOriginal []string
+	switch {
+	case inputData.IncludeFullCertChain:
+		pemCertChain, err := shared.CertChainToPEM(certChain)
+		if err != nil {
+			return nil, fmt.Errorf("error converting original cert chain to PEM format: %w", err)
+		}
+
+		certChainOriginal = pemCertChain
+
+	default:
+		certChainOriginal = nil
+	}
+
+	completion, err := chainCompletion(certChain, inputData.FetchedIssuers)
+	if err != nil {
+		return nil, err
+	}
+
+	server := Server{
+		HostValue: inputData.Server.HostValue,
+		IPAddress: inputData.Server.IPAddress,
+	}
+
+	payload := CertChainPayload{
+		FormatVersion:     FormatVersion,
+		Errors:            shared.ErrorsToStrings(errs),
+		CertChainOriginal: certChainOriginal,
+		CertChainSubset:   certChainSubset,
+		Server:            server,
+		DNSName:           inputData.DNSName,
+		TCPPort:           inputData.TCPPort,
+		ChainStatus:       chainStatus(certChainSubset),
+		EffectiveExpiration: effectiveExpiration(
+			certs.EffectiveExpirationIndex(certChain),
+			validationPath,
+			certChainSubset,
+		),
+		Issues:               certChainIssues,
+		IssueDetails:         issueDetails(reportedIssues(certChainIssues.Detected(), findings), inputData.IssueStates, findings),
+		TrustVerification:    trustVerification(opts.Trust, certChain),
+		DebianWeakKeyCheck:   debianWeakKeyCheck(opts.DebianBlocklist, inputData.KeyPolicy.DebianBlocklistFiles),
+		ExpirationThresholds: expirationThresholds(thresholds),
+		LintProfile:          lintProfile,
+		ChainOrder:           chainOrder(certChain),
+		ChainCompletion:      completion,
+		ServiceState:         serviceState,
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error marshaling cert chain payload as JSON: %w",
+			err,
+		)
+	}
+
+	return payloadJSON, nil
+}
+
+// chainStatus is a helper function that evaluates the status of each
+// certificate in the given certificate chain subset and returns the status
+// of the certificate chain as a whole. Certificates with an expiration
+// ignored per a suppression are counted separately and do not affect the
+// expiring or expired status.
+func chainStatus(certChainSubset []Certificate) CertificateChainStatus {
+	var status CertificateChainStatus
+
+	for _, cert := range certChainSubset {
+		if cert.Status.NotYetValid {
+			status.NotYetValidCertsCount++
+		}
+
+		switch {
+		case cert.Status.ExpirationIgnored:
+			status.ExpirationIgnoredCertsCount++
+		case cert.Status.Expired:
+			status.ExpiredCertsCount++
+		case cert.Status.Expiring:
+			status.ExpiringCertsCount++
+		}
+
+		if cert.Status.Revoked {
+			status.RevokedCertsCount++
+		}
+	}
+
+	status.Expiring = status.ExpiringCertsCount > 0
+	status.Expired = status.ExpiredCertsCount > 0
+	status.NotYetValid = status.NotYetValidCertsCount > 0
+	status.Revoked = status.RevokedCertsCount > 0
+	status.OK = !status.Expiring && !status.Expired && !status.NotYetValid && !status.Revoked
+
+	return status
+}
+
+// effectiveExpiration is a helper function that returns the effective
+// expiration of the certificate chain using the given chain position of the
+// certificate which determines it and the given validation path.
+func effectiveExpiration(certIndex int, validationPath []int, certChainSubset []Certificate) ChainExpiration {
+	if certIndex < 0 || certIndex >= len(certChainSubset) {
+		return ChainExpiration{ChainIndex: -1, ValidationPath: validationPath}
+	}
+
+	cert := certChainSubset[certIndex]
+
+	return ChainExpiration{
+		ExpiresOn:      cert.ExpiresOn,
+		DaysRemaining:  cert.DaysRemaining,
+		ChainIndex:     certIndex,
+		CommonName:     cert.CommonName,
+		SerialNumber:   cert.SerialNumber,
+		Type:           cert.Type,
+		ValidationPath: validationPath,
+	}
+}
+
+// chainIssues is a helper function that returns the certificate chain
+// issues detected per the given findings. Issues for which all findings are
+// ignored per a suppression are not reported.
+func chainIssues(findings []shared.Finding) CertificateChainIssues {
+	active := shared.ActiveIssues(findings)
+
+	return CertificateChainIssues{
+		MissingIntermediateCerts: active[input.IssueMissingIntermediateCerts],
+		MissingSANsEntries:       active[input.IssueMissingSANsEntries],
+		DuplicateCerts:           active[input.IssueDuplicateCerts],
+		MisorderedCerts:          active[input.IssueMisorderedCerts],
+		ExpiredCerts:             active[input.IssueExpiredCerts],
+		HostnameMismatch:         active[input.IssueHostnameMismatch],
+		SelfSignedLeafCert:       active[input.IssueSelfSignedLeafCert],
+		WeakSignatureAlgorithm:   active[input.IssueWeakSignatureAlgorithm],
+		NotYetValidCerts:         active[input.IssueNotYetValidCerts],
+		LeafOutlivesIssuer:       active[input.IssueLeafOutlivesIssuer],
+		UntrustedChain:           active[input.IssueUntrustedChain],
+		RevokedCerts:             active[input.IssueRevokedCerts],
+		MissingOCSPStaple:        active[input.IssueMissingOCSPStaple],
+		ExpiringCrossSign:        active[input.IssueExpiringCrossSign],
+		WeakKey:                  active[input.IssueWeakKey],
+		ROCAVulnerableKey:        active[input.IssueROCAVulnerableKey],
+		DebianWeakKey:            active[input.IssueDebianWeakKey],
+		RenewalOverdue:           active[input.IssueRenewalOverdue],
+	}
+}
+
+// expirationCutoffs is a helper function that returns the expiration
+// cutoffs for each certificate in the given certificate chain using the
+// given thresholds. Certificates with an expiration ignored per a
+// suppression are marked as such.
+func expirationCutoffs(certChain []*x509.Certificate, thresholds shared.RoleThresholds, opts shared.EvalOptions) []shared.ExpirationCutoffs {
+	cutoffs := shared.ChainExpirationCutoffs(certChain, thresholds, opts.Now)
+
+	for idx := range cutoffs {
+		_, cutoffs[idx].Ignored = shared.ExpirationSuppression(certChain, idx, opts)
+	}
+
+	return cutoffs
+}
+
+// evalOptions is a helper function that returns the settings used when
+// evaluating the certificate chain provided by the given input data as of
+// the given time. This includes verifying the certificate chain against the
+// requested trust store and evaluating the provided revocation information.
+// An error is returned if the trust store, OCSP responses or weak key
+// blocklists cannot be loaded; provided CRLs which cannot be loaded are
+// recorded as an unknown revocation status instead.
+func evalOptions(inputData input.Values, now time.Time) (shared.EvalOptions, error) {
+	trust, err := shared.VerifyTrust(inputData.CertChain, inputData.TrustStore, now)
+	if err != nil {
+		return shared.EvalOptions{}, fmt.Errorf("error verifying cert chain trust: %w", err)
+	}
+
+	revocationResults, err := shared.ChainRevocation(inputData.CertChain, inputData, now)
+	if err != nil {
+		return shared.EvalOptions{}, fmt.Errorf("error evaluating cert chain revocation status: %w", err)
+	}
+
+	debianBlocklist, err := weakkeys.LoadBlocklist(inputData.KeyPolicy.DebianBlocklistFiles)
+	if err != nil {
+		return shared.EvalOptions{}, fmt.Errorf("error loading Debian weak key blocklist: %w", err)
+	}
+
+	return shared.EvalOptions{
+		HostnameValue:      hostnameValue(inputData),
+		ClockSkewTolerance: inputData.ClockSkewTolerance,
+		Trust:              trust,
+		Revocation:         revocationResults,
+		OCSPStapleMissing:  shared.OCSPStapleMissing(inputData),
+		Thresholds:         shared.EffectiveThresholds(inputData),
+		KeyPolicy:          inputData.KeyPolicy,
+		DebianBlocklist:    debianBlocklist,
+		RenewalPolicy:      inputData.RenewalPolicy,
+		Now:                now,
+		Suppressions:       inputData.Suppressions,
+		Hosts:              shared.SuppressionHosts(inputData),
+	}, nil
+}
+
+// debianWeakKeyCheck is a helper function that returns the coverage of the
+// Debian weak key check performed using the given blocklist. The check is
+// incomplete unless blocklist files were provided in addition to the
+// embedded sample blocklist.
+func debianWeakKeyCheck(blocklist *weakkeys.Blocklist, files []string) DebianWeakKeyCheck {
+	status := input.DebianWeakKeyCheckIncomplete
+	if len(files) > 0 {
+		status = input.DebianWeakKeyCheckPerformed
+	}
+
+	return DebianWeakKeyCheck{
+		Status:           status,
+		BlocklistEntries: blocklist.Len(),
+		BlocklistFiles:   files,
+	}
+}
+
+// expirationThresholds is a helper function that converts the given
+// expiration thresholds for each certificate role into the payload format.
+func expirationThresholds(thresholds shared.RoleThresholds) ExpirationThresholds {
+	return ExpirationThresholds{
+		Leaf:         thresholdSet(thresholds.Leaf),
+		Intermediate: thresholdSet(thresholds.Intermediate),
+		Root:         thresholdSet(thresholds.Root),
+	}
+}
+
+// thresholdSet is a helper function that converts the given expiration
+// thresholds into the payload format.
+func thresholdSet(thresholds input.ExpirationThresholds) ThresholdSet {
+	return ThresholdSet{
+		Warning:  Threshold(thresholds.Warning),
+		Critical: Threshold(thresholds.Critical),
+	}
+}
+
+// lifetimeRemainingPercent is a helper function that returns the truncated
+// percentage of the validity period of the given certificate remaining as of
+// the given time. The percentage is calculated from durations so that
+// certificates with a validity period under a day are reported accurately. A
+// certificate which is not yet valid reports its full validity period (100%)
+// as remaining and an expired certificate reports 0%.
+//
+// Unlike earlier format versions, a value over 100% is not reported for a
+// certificate which is not yet valid.
+func lifetimeRemainingPercent(cert *x509.Certificate, now time.Time) int {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	remaining := cert.NotAfter.Sub(now)
+
+	switch {
+	case lifetime <= 0 || remaining <= 0:
+		return 0
+	case remaining >= lifetime:
+		return 100
+	default:
+		return int(math.Trunc(float64(remaining) / float64(lifetime) * 100))
+	}
+}
+
+// certificateRenewal is a helper function that converts the given expected
+// renewal of a certificate as of the given time into the payload format.
+func certificateRenewal(renewal shared.Renewal, now time.Time) CertificateRenewal {
+	if !renewal.Evaluated {
+		return CertificateRenewal{}
+	}
+
+	// Truncating a value just past the expected renewal point yields
+	// negative zero; record zero instead.
+	daysUntilExpected := math.Trunc(renewal.ExpectedOn.Sub(now).Hours()/24*100) / 100
+	if daysUntilExpected == 0 {
+		daysUntilExpected = 0
+	}
+
+	return CertificateRenewal{
+		Evaluated:         true,
+		Rule:              renewal.RuleName,
+		LifetimeFraction:  renewal.LifetimeFraction,
+		RenewBeforeHours:  renewal.Rule.RenewBefore.Hours(),
+		ExpectedOn:        renewal.ExpectedOn,
+		DaysUntilExpected: daysUntilExpected,
+		Overdue:           renewal.Overdue(now),
+	}
+}
+
+// validityCompliance is a helper function that evaluates the validity
+// period of the given certificate against the schedule of maximum validity
+// periods. Only leaf certificates are evaluated; the role of the certificate
+// is determined as it is for the certificate lints (see certs.IsLeafRole).
+func validityCompliance(cert *x509.Certificate, certChain []*x509.Certificate) ValidityCompliance {
+	if !certs.IsLeafRole(cert, certChain) {
+		return ValidityCompliance{}
+	}
+
+	compliance := ValidityCompliance{
+		Evaluated: true,
+		Compliant: true,
+	}
+
+	if limit, ok := certs.ValidityLimitAt(cert.NotBefore); ok {
+		compliance.MaxValidityDays = limit.MaxDays
+		compliance.MaxValidityEffectiveOn = limit.EffectiveOn
+		compliance.Compliant = !certs.ExceedsValidityLimit(certs.ValidityPeriod(cert), limit)
+	}
+
+	if next, ok := certs.NextExceededValidityLimit(cert); ok {
+		compliance.NonCompliantRenewalOn = next.EffectiveOn
+		compliance.NonCompliantRenewalMaxDays = next.MaxDays
+	}
+
+	return compliance
+}
+
+// certificateRevocation is a helper function that converts the given
+// revocation results into the revocation status of a certificate.
+func certificateRevocation(result revocation.Result) CertificateRevocation {
+	combined := result.Combined()
+
+	return CertificateRevocation{
+		Status:      combined.Status,
+		Reason:      combined.Reason,
+		RevokedAt:   combined.RevokedAt,
+		CRL:         revocationCheck(result.CRL),
+		OCSP:        revocationCheck(result.OCSP),
+		OCSPStapled: result.Stapled,
+	}
+}
+
+// revocationCheck is a helper function that converts the given revocation
+// check result into the payload format.
+func revocationCheck(check revocation.Check) RevocationCheck {
+	var errMsg string
+	if check.Err != nil {
+		errMsg = check.Err.Error()
+	}
+
+	return RevocationCheck{
+		Status:     check.Status,
+		Reason:     check.Reason,
+		RevokedAt:  check.RevokedAt,
+		ThisUpdate: check.ThisUpdate,
+		NextUpdate: check.NextUpdate,
+		Stale:      check.Stale,
+		Signer:     check.Signer,
+		Error:      errMsg,
+	}
+}
+
+// chainOrder is a helper function that reconstructs the canonical order of
+// the given certificate chain and returns the presented and reconstructed
+// orders.
+func chainOrder(certChain []*x509.Certificate) ChainOrder {
+	built := certs.BuildChain(certChain)
+
+	presented := make([]int, 0, len(certChain))
+	for idx := range certChain {
+		presented = append(presented, idx)
+	}
+
+	reordered := false
+	for i, idx := range built.Path {
+		if i != idx {
+			reordered = true
+		}
+	}
+
+	return ChainOrder{
+		Reordered:     reordered,
+		Complete:      built.Complete,
+		Presented:     indexedPathCertificates(presented, certChain),
+		Reconstructed: indexedPathCertificates(built.Path, certChain),
+		Unused:        indexedPathCertificates(built.Unused, certChain),
+	}
+}
+
+// indexedPathCertificates is a helper function that converts the
+// certificates at the given positions of the given certificate chain into a
+// list of path certificates.
+func indexedPathCertificates(indexes []int, certChain []*x509.Certificate) []PathCertificate {
+	pathCerts := make([]PathCertificate, 0, len(indexes))
+
+	for _, idx := range indexes {
+		pathCerts = append(pathCerts, newPathCertificate(certChain[idx], idx))
+	}
+
+	return pathCerts
+}
+
+// pathCertificates is a helper function that converts the given certificate
+// path into a list of path certificates, recording the position of each
+// certificate in the given certificate chain.
+func pathCertificates(path []*x509.Certificate, certChain []*x509.Certificate) []PathCertificate {
+	pathCerts := make([]PathCertificate, 0, len(path))
+
+	for _, cert := range path {
+		pathCerts = append(pathCerts, newPathCertificate(cert, shared.ChainIndex(cert, certChain)))
+	}
+
+	return pathCerts
+}
+
+// newPathCertificate is a helper function that converts the given
+// certificate into a path certificate using the given chain position.
+func newPathCertificate(cert *x509.Certificate, chainIndex int) PathCertificate {
+	return PathCertificate{
+		Subject:      cert.Subject.String(),
+		CommonName:   cert.Subject.CommonName,
+		SerialNumber: certs.FormatCertSerialNumber(cert.SerialNumber),
+		ExpiresOn:    cert.NotAfter,
+		ChainIndex:   chainIndex,
+		NotServed:    chainIndex == -1,
+	}
+}
+
+// chainCompletion is a helper function that completes the given certificate
+// chain using the given retrieved issuer certificates. The section is empty
+// if no issuer certificates were retrieved.
+func chainCompletion(certChain []*x509.Certificate, fetchedIssuers []input.FetchedCert) (ChainCompletion, error) {
+	if len(fetchedIssuers) == 0 {
+		return ChainCompletion{}, nil
+	}
+
+	pool := make([]*x509.Certificate, 0, len(certChain)+len(fetchedIssuers))
+	pool = append(pool, certChain...)
+
+	fetchedCerts := make([]FetchedCertificate, 0, len(fetchedIssuers))
+	for i, fetched := range fetchedIssuers {
+		pool = append(pool, fetched.Cert)
+
+		pemCert, err := shared.CertChainToPEM([]*x509.Certificate{fetched.Cert})
+		if err != nil {
+			return ChainCompletion{}, fmt.Errorf(
+				"error converting fetched issuer cert %d to PEM format: %w",
+				i,
+				err,
+			)
+		}
+
+		fetchedCerts = append(fetchedCerts, FetchedCertificate{
+			Subject:      fetched.Cert.Subject.String(),
+			CommonName:   fetched.Cert.Subject.CommonName,
+			Issuer:       fetched.Cert.Issuer.String(),
+			SerialNumber: certs.FormatCertSerialNumber(fetched.Cert.SerialNumber),
+			SubjectKeyID: certs.FormatKeyID(fetched.Cert.SubjectKeyId),
+			ExpiresOn:    fetched.Cert.NotAfter,
+			Source:       fetched.Source,
+			NotServed:    true,
+			PEM:          pemCert[0],
+		})
+	}
+
+	built := certs.BuildChain(pool)
+
+	// Retrieved certificates are not part of the presented chain.
+	path := make([]PathCertificate, 0, len(built.Path))
+	for _, idx := range built.Path {
+		chainIndex := idx
+		if idx >= len(certChain) {
+			chainIndex = -1
+		}
+
+		path = append(path, newPathCertificate(pool[idx], chainIndex))
+	}
+
+	return ChainCompletion{
+		Completed:      true,
+		Complete:       built.Complete,
+		Path:           path,
+		FetchedIssuers: fetchedCerts,
+	}, nil
+}
+
+// trustVerification is a helper function that converts the given trust
+// verification result for the given certificate chain into the trust
+// verification section of the payload.
+func trustVerification(trust shared.TrustResult, certChain []*x509.Certificate) TrustVerification {
+	var errMsg string
+	if trust.Err != nil {
+		errMsg = trust.Err.Error()
+	}
+
+	verifiedPaths := make([][]PathCertificate, 0, len(trust.Paths))
+	for _, path := range trust.Paths {
+		verifiedPaths = append(verifiedPaths, pathCertificates(path, certChain))
+	}
+
+	allPaths := make([]ChainPath, 0, len(trust.AllPaths))
+	for _, path := range trust.AllPaths {
+		// The certificate is always present.
+		daysRemaining, _ := certs.ExpiresInDaysPrecise(path.Certs[path.ExpirationIndex])
+
+		allPaths = append(allPaths, ChainPath{
+			Certificates:        pathCertificates(path.Certs, certChain),
+			Served:              path.Served,
+			CrossSigned:         path.CrossSignIndex != -1,
+			ExpiresOn:           path.ExpiresOn(),
+			DaysRemaining:       daysRemaining,
+			ExpirationPathIndex: path.ExpirationIndex,
+		})
+	}
+
+	return TrustVerification{
+		Performed:     trust.Performed(),
+		Verified:      trust.Verified(),
+		Status:        trust.Status,
+		Error:         errMsg,
+		TrustStores:   trust.Sources,
+		VerifiedPaths: verifiedPaths,
+		AllPaths:      allPaths,
+	}
+}
+
+// sansEntries evaluates given input options and either returns all Subject
+// Alternate Names for a given certificate or nil to indicate that a sysadmin
+// opted out of recording SANs entries.
+func sansEntries(cert *x509.Certificate, inputData input.Values) []string {
+	if inputData.OmitSANsEntries {
+		return nil
+	}
+
+	return cert.DNSNames
+}
+
+// hostnameValue is a helper function that evaluates the given hostname values
+// used to perform a certificate service check and returns either the default
+// server value or a custom DNS name value (e.g., virtual host value) if one
+// was specified.
+func hostnameValue(inputData input.Values) string {
+	// Default to using the server FQDN or IP Address used to make the
+	// connection as our hostname value.
+	hostnameValue := inputData.Server.HostValue
+
+	// Allow the user to explicitly specify which hostname should be used
+	// for comparison against the leaf certificate. This works for a
+	// certificate retrieved by a server as well as a certificate
+	// retrieved from a file.
+	if inputData.DNSName != "" {
+		hostnameValue = inputData.DNSName
+	}
+
+	return hostnameValue
+}
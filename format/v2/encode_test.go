@@ -0,0 +1,1427 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/issues"
+)
+
+// testChain is a leaf, intermediate and root certificate chain.
+type testChain struct {
+	leaf         *x509.Certificate
+	intermediate *x509.Certificate
+	root         *x509.Certificate
+}
+
+// certs returns the certificate chain in leaf to root order.
+func (tc testChain) certs() []*x509.Certificate {
+	return []*x509.Certificate{tc.leaf, tc.intermediate, tc.root}
+}
+
+// newTestChain creates a certificate chain for www.example.com with a 90
+// day leaf certificate expiring in the given number of days and an
+// intermediate certificate expiring in the given number of days.
+func newTestChain(t *testing.T, leafDays int, intermediateDays int) testChain {
+	t.Helper()
+
+	now := time.Now()
+
+	root, rootKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             now.AddDate(-5, 0, 0),
+		NotAfter:              now.AddDate(15, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+
+	intermediate, intermediateKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(0, 0, intermediateDays),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root, rootKey)
+
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0x04a1b2c3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.AddDate(0, 0, leafDays-90),
+		NotAfter:     now.AddDate(0, 0, leafDays),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	return testChain{leaf: leaf, intermediate: intermediate, root: root}
+}
+
+// encodeDecode encodes a payload for the given input data and decodes the
+// result, rejecting unknown fields.
+func encodeDecode(t *testing.T, inputData input.Values) ([]byte, format2.CertChainPayload) {
+	t.Helper()
+
+	payloadJSON, err := format2.Encode(inputData)
+	if err != nil {
+		t.Fatalf("failed to encode payload: %v", err)
+	}
+
+	var payload format2.CertChainPayload
+	if err := format2.Decode(&payload, bytes.NewReader(payloadJSON), false); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+
+	return payloadJSON, payload
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+
+	inputData := input.Values{
+		CertChain:                            chain.certs(),
+		Server:                               input.Server{HostValue: "www.example.com", IPAddress: "192.0.2.10"},
+		TCPPort:                              443,
+		ExpirationAgeInDaysWarningThreshold:  30,
+		ExpirationAgeInDaysCriticalThreshold: 15,
+		ServiceStateMode:                     input.ServiceStateEvaluated,
+	}
+
+	payloadJSON, payload := encodeDecode(t, inputData)
+
+	// Every field of the payload survives decoding.
+	reencoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal decoded payload: %v", err)
+	}
+
+	if !bytes.Equal(reencoded, payloadJSON) {
+		t.Errorf("decoded payload does not match encoded payload\ngot:  %s\nwant: %s", reencoded, payloadJSON)
+	}
+
+	if payload.FormatVersion != format2.FormatVersion {
+		t.Errorf("got format version %d, want %d", payload.FormatVersion, format2.FormatVersion)
+	}
+
+	wantTypes := []string{"leaf", "intermediate", "root"}
+	if len(payload.CertChainSubset) != len(wantTypes) {
+		t.Fatalf("got %d certificates, want %d", len(payload.CertChainSubset), len(wantTypes))
+	}
+
+	for idx, cert := range payload.CertChainSubset {
+		if cert.Type != wantTypes[idx] {
+			t.Errorf("cert %d: got type %q, want %q", idx, cert.Type, wantTypes[idx])
+		}
+
+		if !cert.Status.OK {
+			t.Errorf("cert %d: got status %+v, want OK", idx, cert.Status)
+		}
+	}
+
+	if got, want := payload.CertChainSubset[0].SerialNumber, "04:A1:B2:C3"; got != want {
+		t.Errorf("got leaf serial %q, want %q", got, want)
+	}
+
+	if payload.Issues.Confirmed() || len(payload.IssueDetails) != 0 {
+		t.Errorf("got issues %+v, %+v; want none", payload.Issues, payload.IssueDetails)
+	}
+
+	if payload.ServiceState != "OK" {
+		t.Errorf("got service state %q, want OK", payload.ServiceState)
+	}
+}
+
+func TestEncodeDetectsIssues(t *testing.T) {
+	chain := newTestChain(t, 10, 1000)
+
+	inputData := input.Values{
+		CertChain:                            chain.certs(),
+		Server:                               input.Server{HostValue: "mail.example.com"},
+		ExpirationAgeInDaysWarningThreshold:  30,
+		ExpirationAgeInDaysCriticalThreshold: 15,
+		ServiceStateMode:                     input.ServiceStateEvaluated,
+	}
+
+	_, payload := encodeDecode(t, inputData)
+
+	if !payload.Issues.HostnameMismatch || !payload.Issues.Confirmed() {
+		t.Errorf("got issues %+v, want hostname mismatch", payload.Issues)
+	}
+
+	if len(payload.IssueDetails) != 1 || payload.IssueDetails[0].Code != issues.CodeHostnameMismatch {
+		t.Errorf("got issue details %+v, want %s", payload.IssueDetails, issues.CodeHostnameMismatch)
+	}
+
+	leaf := payload.CertChainSubset[0]
+	if !leaf.Status.Expiring || leaf.Status.OK {
+		t.Errorf("got leaf status %+v, want expiring", leaf.Status)
+	}
+
+	if payload.ServiceState != "CRITICAL" {
+		t.Errorf("got service state %q, want CRITICAL", payload.ServiceState)
+	}
+
+	state, err := format2.EvaluateServiceState(inputData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state != payload.ServiceState {
+		t.Errorf("evaluated service state %q does not match payload service state %q", state, payload.ServiceState)
+	}
+}
+
+func TestEncodeInvalidIssueState(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+
+	inputData := input.Values{
+		CertChain:   chain.certs(),
+		IssueStates: map[string]string{input.IssueHostnameMismatch: "warning"},
+	}
+
+	if _, err := format2.Encode(inputData); err == nil {
+		t.Error("expected error for invalid issue service state")
+	}
+
+	if _, err := format2.EvaluateServiceState(inputData); err == nil {
+		t.Error("expected error for invalid issue service state")
+	}
+}
+
+func TestEncodeUnusableCRL(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+
+	inputData := input.Values{
+		CertChain: chain.certs(),
+		Server:    input.Server{HostValue: "www.example.com"},
+		CRLs:      [][]byte{[]byte("not a CRL")},
+	}
+
+	_, payload := encodeDecode(t, inputData)
+
+	for idx, cert := range payload.CertChainSubset {
+		crl := cert.Revocation.CRL
+
+		wantStatus := input.RevocationStatusUnknown
+		if idx == len(payload.CertChainSubset)-1 {
+			// Self-signed root certificates are not checked.
+			wantStatus = input.RevocationStatusNotChecked
+		}
+
+		if crl.Status != wantStatus {
+			t.Errorf("cert %d: got CRL status %q, want %q", idx, crl.Status, wantStatus)
+		}
+
+		if wantStatus == input.RevocationStatusUnknown && crl.Error == "" {
+			t.Errorf("cert %d: expected CRL error", idx)
+		}
+	}
+
+	if _, err := format2.EvaluateServiceState(inputData); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEncodeSuppressions(t *testing.T) {
+	chain := newTestChain(t, 10, 1000)
+
+	inputData := input.Values{
+		CertChain:                            chain.certs(),
+		Server:                               input.Server{HostValue: "mail.example.com"},
+		ExpirationAgeInDaysWarningThreshold:  30,
+		ExpirationAgeInDaysCriticalThreshold: 15,
+		ServiceStateMode:                     input.ServiceStateEvaluated,
+		Suppressions: []input.Suppression{
+			{
+				Issues:           []string{input.IssueHostnameMismatch},
+				IgnoreExpiration: true,
+				SerialNumbers:    []string{"4a1b2c3"},
+				Reason:           "replacement scheduled",
+			},
+		},
+	}
+
+	_, payload := encodeDecode(t, inputData)
+
+	leaf := payload.CertChainSubset[0]
+	if !leaf.Status.ExpirationIgnored || !leaf.Status.OK {
+		t.Errorf("got leaf status %+v, want expiration ignored", leaf.Status)
+	}
+
+	status := payload.ChainStatus
+	if !status.OK || status.Expiring || status.ExpiringCertsCount != 0 || status.ExpirationIgnoredCertsCount != 1 {
+		t.Errorf("got chain status %+v, want OK with one ignored expiration", status)
+	}
+
+	if payload.Issues.HostnameMismatch || payload.Issues.Confirmed() {
+		t.Errorf("got issues %+v, want none", payload.Issues)
+	}
+
+	if len(payload.IssueDetails) != 1 || !payload.IssueDetails[0].Ignored {
+		t.Errorf("got issue details %+v, want ignored %s", payload.IssueDetails, issues.CodeHostnameMismatch)
+	}
+
+	if payload.ServiceState != "OK" {
+		t.Errorf("got service state %q, want OK", payload.ServiceState)
+	}
+
+	state, err := format2.EvaluateServiceState(inputData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state != payload.ServiceState {
+		t.Errorf("evaluated service state %q does not match payload service state %q", state, payload.ServiceState)
+	}
+}
+
+func TestEncodeMisorderedChainRoles(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+
+	// The intermediate certificate is listed before the leaf certificate.
+	inputData := input.Values{
+		CertChain: []*x509.Certificate{chain.intermediate, chain.leaf, chain.root},
+		Server:    input.Server{HostValue: "www.example.com"},
+	}
+
+	_, payload := encodeDecode(t, inputData)
+
+	const leafIdx = 1
+
+	if !payload.Issues.MisorderedCerts {
+		t.Errorf("got issues %+v, want misordered certs", payload.Issues)
+	}
+
+	for idx, cert := range payload.CertChainSubset {
+		isLeaf := idx == leafIdx
+
+		if cert.ValidityCompliance.Evaluated != isLeaf {
+			t.Errorf("cert %d: got validity compliance evaluated %t, want %t", idx, cert.ValidityCompliance.Evaluated, isLeaf)
+		}
+
+		// Leaf lints are only reported for the leaf certificate.
+		for _, l := range cert.Lints {
+			if strings.HasPrefix(l.ID, "LEAF_") && !isLeaf {
+				t.Errorf("cert %d: got leaf lint %s for %s certificate", idx, l.ID, cert.Type)
+			}
+		}
+	}
+
+	if got := payload.CertChainSubset[leafIdx].Type; got != "leaf" {
+		t.Errorf("got leaf certificate type %q, want leaf", got)
+	}
+}
+
+// crossSignedChain is a certificate chain served with an intermediate
+// certificate issued by a new root and a copy of the new root cross-signed
+// by a legacy root; the trust store of a client may include either root.
+type crossSignedChain struct {
+	leaf         *x509.Certificate
+	intermediate *x509.Certificate
+	newRoot      *x509.Certificate
+	crossSigned  *x509.Certificate
+	legacyRoot   *x509.Certificate
+}
+
+// newCrossSignedChain creates a cross-signed certificate chain with a legacy
+// root expiring in the given number of days.
+func newCrossSignedChain(t *testing.T, legacyRootDays int) crossSignedChain {
+	t.Helper()
+
+	now := time.Now()
+
+	ca := func(serial int64, commonName string, notAfter time.Time) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: commonName},
+			NotBefore:             now.AddDate(-1, 0, 0),
+			NotAfter:              notAfter,
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+	}
+
+	legacyRoot, legacyRootKey := testutil.IssueCert(t, ca(1, "Test Legacy Root CA", now.AddDate(0, 0, legacyRootDays)), nil, nil)
+	newRoot, newRootKey := testutil.IssueCert(t, ca(2, "Test Root CA", now.AddDate(15, 0, 0)), nil, nil)
+	crossSigned := testutil.IssueCertForKey(
+		t, ca(3, "Test Root CA", now.AddDate(15, 0, 0)), legacyRoot, legacyRootKey, newRootKey.Public(),
+	)
+
+	intermediate, intermediateKey := testutil.IssueCert(t, ca(4, "Test Intermediate CA", now.AddDate(5, 0, 0)), newRoot, newRootKey)
+
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(5),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.AddDate(0, 0, -30),
+		NotAfter:     now.AddDate(0, 0, 60),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	return crossSignedChain{
+		leaf:         leaf,
+		intermediate: intermediate,
+		newRoot:      newRoot,
+		crossSigned:  crossSigned,
+		legacyRoot:   legacyRoot,
+	}
+}
+
+// caBundle returns the given certificates as a PEM encoded CA bundle.
+func caBundle(roots ...*x509.Certificate) []byte {
+	var bundle []byte
+	for _, root := range roots {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+	}
+
+	return bundle
+}
+
+func TestEncodeCrossSignedPaths(t *testing.T) {
+	tests := []struct {
+		name           string
+		legacyRootDays int
+		serveCrossSign bool
+
+		// wantServedCrossSigned indicates whether the served path depends
+		// on the cross-signed certificate.
+		wantServedCrossSigned bool
+		wantPaths             int
+		wantExpiring          bool
+
+		// rootThresholds is the optional set of expiration thresholds for
+		// root certificates (e.g., the legacy root used to cross-sign).
+		rootThresholds input.ExpirationThresholds
+	}{
+		{
+			name:                  "served cross-sign expiring",
+			legacyRootDays:        10,
+			serveCrossSign:        true,
+			wantServedCrossSigned: true,
+			wantPaths:             2,
+			wantExpiring:          true,
+		},
+		{
+			name:                  "served cross-sign not expiring",
+			legacyRootDays:        3650,
+			serveCrossSign:        true,
+			wantServedCrossSigned: true,
+			wantPaths:             2,
+		},
+		{
+			name:                  "served cross-sign expiring per lifetime percentage",
+			legacyRootDays:        200,
+			serveCrossSign:        true,
+			wantServedCrossSigned: true,
+			wantPaths:             2,
+			wantExpiring:          true,
+			rootThresholds: input.ExpirationThresholds{
+				Warning:  input.ExpirationThreshold{LifetimePercent: 50},
+				Critical: input.ExpirationThreshold{Days: 15},
+			},
+		},
+		{
+			name:           "cross-sign not served",
+			legacyRootDays: 10,
+			wantPaths:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newCrossSignedChain(t, tt.legacyRootDays)
+
+			certChain := []*x509.Certificate{chain.leaf, chain.intermediate}
+			if tt.serveCrossSign {
+				certChain = append(certChain, chain.crossSigned)
+			}
+
+			inputData := input.Values{
+				CertChain:                            certChain,
+				Server:                               input.Server{HostValue: "www.example.com"},
+				ExpirationAgeInDaysWarningThreshold:  30,
+				ExpirationAgeInDaysCriticalThreshold: 15,
+				TrustStore:                           input.TrustStore{CABundlePEM: caBundle(chain.newRoot, chain.legacyRoot)},
+				RootExpirationThresholds:             tt.rootThresholds,
+			}
+
+			_, payload := encodeDecode(t, inputData)
+
+			trust := payload.TrustVerification
+			if !trust.Verified {
+				t.Errorf("got trust verification %+v, want verified", trust)
+			}
+
+			if len(trust.AllPaths) != tt.wantPaths {
+				t.Fatalf("got %d paths, want %d: %+v", len(trust.AllPaths), tt.wantPaths, trust.AllPaths)
+			}
+
+			var served []format2.ChainPath
+			for _, path := range trust.AllPaths {
+				if path.Served {
+					served = append(served, path)
+				}
+
+				anchor := path.Certificates[len(path.Certificates)-1]
+				if anchor.ChainIndex != -1 || !anchor.NotServed {
+					t.Errorf("got trust anchor %+v, want certificate not served by the endpoint", anchor)
+				}
+
+				// The leaf certificate expires first unless the path depends on
+				// the legacy root.
+				wantExpiresOn := chain.leaf.NotAfter
+				if path.CrossSigned && chain.legacyRoot.NotAfter.Before(wantExpiresOn) {
+					wantExpiresOn = chain.legacyRoot.NotAfter
+				}
+
+				if !path.ExpiresOn.Equal(wantExpiresOn) {
+					t.Errorf("got path expiration %s, want %s", path.ExpiresOn, wantExpiresOn)
+				}
+			}
+
+			if len(served) != 1 || served[0].CrossSigned != tt.wantServedCrossSigned {
+				t.Errorf("got served paths %+v, want one path with cross-signed %t", served, tt.wantServedCrossSigned)
+			}
+
+			if payload.Issues.ExpiringCrossSign != tt.wantExpiring {
+				t.Errorf("got expiring cross-sign %t, want %t", payload.Issues.ExpiringCrossSign, tt.wantExpiring)
+			}
+
+			if !tt.wantExpiring {
+				return
+			}
+
+			const crossSignedIdx = 2
+
+			var found bool
+			for _, issue := range payload.CertChainSubset[crossSignedIdx].Issues {
+				if issue.Name != input.IssueExpiringCrossSign {
+					continue
+				}
+
+				found = true
+
+				wantEvidence := chain.legacyRoot.NotAfter.UTC().Format(time.RFC3339)
+				if !strings.Contains(issue.Evidence, wantEvidence) {
+					t.Errorf("got evidence %q, want expiration %s", issue.Evidence, wantEvidence)
+				}
+			}
+
+			if !found {
+				t.Errorf("got cert %d issues %+v, want %s", crossSignedIdx,
+					payload.CertChainSubset[crossSignedIdx].Issues, input.IssueExpiringCrossSign)
+			}
+		})
+	}
+}
+
+func TestEncodeIssueAttribution(t *testing.T) {
+	tests := []struct {
+		name             string
+		intermediateDays int
+		host             string
+		certChain        func(tc testChain) []*x509.Certificate
+		issue            string
+		certIndex        int
+		wantChainIndexes []int
+		wantEvidence     func(tc testChain) string
+
+		// wantIssueIndexes is the list of chain positions for all
+		// certificates involved in the issue.
+		wantIssueIndexes []int
+	}{
+		{
+			name:             "misordered certs",
+			intermediateDays: 1000,
+			certChain: func(tc testChain) []*x509.Certificate {
+				return []*x509.Certificate{tc.intermediate, tc.leaf, tc.root}
+			},
+			issue:            input.IssueMisorderedCerts,
+			certIndex:        0,
+			wantChainIndexes: []int{0, 1},
+			wantIssueIndexes: []int{0, 1, 2},
+			wantEvidence: func(testChain) string {
+				return `cert 0 issuer "CN=Test Root CA" does not refer to cert 1 subject "CN=www.example.com"`
+			},
+		},
+		{
+			name:             "hostname mismatch",
+			intermediateDays: 1000,
+			host:             "mail.example.com",
+			issue:            input.IssueHostnameMismatch,
+			certIndex:        0,
+			wantChainIndexes: []int{0},
+			wantIssueIndexes: []int{0},
+			wantEvidence: func(testChain) string {
+				return `host name "mail.example.com" not valid for cert 0 (SANs entries: www.example.com)`
+			},
+		},
+		{
+			name:             "missing intermediate certs",
+			intermediateDays: 1000,
+			certChain: func(tc testChain) []*x509.Certificate {
+				return []*x509.Certificate{tc.leaf}
+			},
+			issue:            input.IssueMissingIntermediateCerts,
+			certIndex:        0,
+			wantChainIndexes: []int{0},
+			wantIssueIndexes: []int{0},
+			wantEvidence: func(testChain) string {
+				return `no intermediate certificates present; cert 0 issued by "CN=Test Intermediate CA"`
+			},
+		},
+		{
+			name:             "duplicate certs",
+			intermediateDays: 1000,
+			certChain: func(tc testChain) []*x509.Certificate {
+				return []*x509.Certificate{tc.leaf, tc.intermediate, tc.intermediate, tc.root}
+			},
+			issue:            input.IssueDuplicateCerts,
+			certIndex:        2,
+			wantChainIndexes: []int{2, 1},
+			wantIssueIndexes: []int{1, 2},
+			wantEvidence: func(testChain) string {
+				return "certs 1, 2 share serial number 02"
+			},
+		},
+		{
+			name:             "expired intermediate",
+			intermediateDays: -1,
+			issue:            input.IssueExpiredCerts,
+			certIndex:        1,
+			wantChainIndexes: []int{1},
+			wantIssueIndexes: []int{1},
+			wantEvidence: func(tc testChain) string {
+				return "cert 1 expired on " + tc.intermediate.NotAfter.UTC().Format(time.RFC3339)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t, 60, tt.intermediateDays)
+
+			certChain := chain.certs()
+			if tt.certChain != nil {
+				certChain = tt.certChain(chain)
+			}
+
+			host := tt.host
+			if host == "" {
+				host = "www.example.com"
+			}
+
+			_, payload := encodeDecode(t, input.Values{
+				CertChain: certChain,
+				Server:    input.Server{HostValue: host},
+			})
+
+			var found bool
+			for _, issue := range payload.CertChainSubset[tt.certIndex].Issues {
+				if issue.Name != tt.issue {
+					continue
+				}
+
+				found = true
+
+				if !reflect.DeepEqual(issue.ChainIndexes, tt.wantChainIndexes) {
+					t.Errorf("got chain indexes %v, want %v", issue.ChainIndexes, tt.wantChainIndexes)
+				}
+
+				if want := tt.wantEvidence(chain); issue.Evidence != want {
+					t.Errorf("got evidence %q, want %q", issue.Evidence, want)
+				}
+
+				if def, _ := issues.Lookup(tt.issue); issue.Code != def.Code {
+					t.Errorf("got code %q, want %q", issue.Code, def.Code)
+				}
+			}
+
+			if !found {
+				t.Fatalf("%s not attributed to cert %d", tt.issue, tt.certIndex)
+			}
+
+			for _, detail := range payload.IssueDetails {
+				if detail.Name != tt.issue {
+					continue
+				}
+
+				if !reflect.DeepEqual(detail.ChainIndexes, tt.wantIssueIndexes) {
+					t.Errorf("got issue chain indexes %v, want %v", detail.ChainIndexes, tt.wantIssueIndexes)
+				}
+
+				return
+			}
+
+			t.Errorf("got issue details %+v, want %s", payload.IssueDetails, tt.issue)
+		})
+	}
+}
+
+func TestEncodeCertificateStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		leafDays         int
+		intermediateDays int
+		wantStatus       []format2.CertificateStatus
+		wantChainStatus  format2.CertificateChainStatus
+	}{
+		{
+			name:             "all valid",
+			leafDays:         60,
+			intermediateDays: 1000,
+			wantStatus: []format2.CertificateStatus{
+				{OK: true},
+				{OK: true},
+				{OK: true},
+			},
+			wantChainStatus: format2.CertificateChainStatus{OK: true},
+		},
+		{
+			name:             "expiring leaf",
+			leafDays:         10,
+			intermediateDays: 1000,
+			wantStatus: []format2.CertificateStatus{
+				{Expiring: true},
+				{OK: true},
+				{OK: true},
+			},
+			wantChainStatus: format2.CertificateChainStatus{Expiring: true, ExpiringCertsCount: 1},
+		},
+		{
+			name:             "expired intermediate",
+			leafDays:         60,
+			intermediateDays: -1,
+			wantStatus: []format2.CertificateStatus{
+				{OK: true},
+				{Expired: true},
+				{OK: true},
+			},
+			wantChainStatus: format2.CertificateChainStatus{Expired: true, ExpiredCertsCount: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t, tt.leafDays, tt.intermediateDays)
+
+			_, payload := encodeDecode(t, input.Values{
+				CertChain:                            chain.certs(),
+				Server:                               input.Server{HostValue: "www.example.com"},
+				ExpirationAgeInDaysWarningThreshold:  30,
+				ExpirationAgeInDaysCriticalThreshold: 15,
+			})
+
+			for idx, cert := range payload.CertChainSubset {
+				if cert.Status != tt.wantStatus[idx] {
+					t.Errorf("cert %d: got status %+v, want %+v", idx, cert.Status, tt.wantStatus[idx])
+				}
+			}
+
+			if payload.ChainStatus != tt.wantChainStatus {
+				t.Errorf("got chain status %+v, want %+v", payload.ChainStatus, tt.wantChainStatus)
+			}
+		})
+	}
+}
+
+func TestEncodeNotYetValidClockSkew(t *testing.T) {
+	now := time.Now()
+
+	root, rootKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             now.AddDate(-5, 0, 0),
+		NotAfter:              now.AddDate(15, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+
+	intermediate, intermediateKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(5, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root, rootKey)
+
+	// The leaf certificate becomes valid in five minutes.
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.Add(5 * time.Minute),
+		NotAfter:     now.AddDate(0, 0, 90),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	validFrom := leaf.NotBefore.UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name             string
+		tolerance        time.Duration
+		wantNotYetValid  bool
+		wantEvidence     string
+		wantServiceState string
+	}{
+		{
+			name:             "no tolerance",
+			wantNotYetValid:  true,
+			wantEvidence:     "cert 0 not valid before " + validFrom + " (clock skew tolerance 0s)",
+			wantServiceState: "CRITICAL",
+		},
+		{
+			name:             "tolerance too short",
+			tolerance:        time.Minute,
+			wantNotYetValid:  true,
+			wantEvidence:     "cert 0 not valid before " + validFrom + " (clock skew tolerance 1m0s)",
+			wantServiceState: "CRITICAL",
+		},
+		{
+			name:             "within tolerance",
+			tolerance:        10 * time.Minute,
+			wantServiceState: "OK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, payload := encodeDecode(t, input.Values{
+				CertChain:                            []*x509.Certificate{leaf, intermediate, root},
+				Server:                               input.Server{HostValue: "www.example.com"},
+				ExpirationAgeInDaysWarningThreshold:  30,
+				ExpirationAgeInDaysCriticalThreshold: 15,
+				ClockSkewTolerance:                   tt.tolerance,
+				ServiceStateMode:                     input.ServiceStateEvaluated,
+			})
+
+			leafCert := payload.CertChainSubset[0]
+
+			if leafCert.Status.NotYetValid != tt.wantNotYetValid || leafCert.Status.OK == tt.wantNotYetValid {
+				t.Errorf("got leaf status %+v, want not yet valid %t", leafCert.Status, tt.wantNotYetValid)
+			}
+
+			if payload.ChainStatus.NotYetValid != tt.wantNotYetValid {
+				t.Errorf("got chain status %+v, want not yet valid %t", payload.ChainStatus, tt.wantNotYetValid)
+			}
+
+			if payload.Issues.NotYetValidCerts != tt.wantNotYetValid {
+				t.Errorf("got issues %+v, want not yet valid certs %t", payload.Issues, tt.wantNotYetValid)
+			}
+
+			if payload.ServiceState != tt.wantServiceState {
+				t.Errorf("got service state %q, want %q", payload.ServiceState, tt.wantServiceState)
+			}
+
+			if !tt.wantNotYetValid {
+				if len(leafCert.Issues) != 0 {
+					t.Errorf("got leaf issues %+v, want none", leafCert.Issues)
+				}
+
+				return
+			}
+
+			if want := "[NOT YET VALID] valid from " + validFrom; leafCert.Summary != want {
+				t.Errorf("got leaf summary %q, want %q", leafCert.Summary, want)
+			}
+
+			if len(leafCert.Issues) != 1 || leafCert.Issues[0].Evidence != tt.wantEvidence {
+				t.Errorf("got leaf issues %+v, want evidence %q", leafCert.Issues, tt.wantEvidence)
+			}
+		})
+	}
+}
+
+func TestEncodeLifetimePercent(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		want      int
+	}{
+		{
+			name:      "partially elapsed",
+			notBefore: now.AddDate(0, 0, -30),
+			notAfter:  now.AddDate(0, 0, 60).Add(time.Hour),
+			want:      66,
+		},
+		{
+			name:      "validity period under a day",
+			notBefore: now.Add(-6 * time.Hour),
+			notAfter:  now.Add(6*time.Hour + time.Minute),
+			want:      50,
+		},
+		{
+			name:      "not yet valid",
+			notBefore: now.Add(time.Hour),
+			notAfter:  now.AddDate(0, 0, 90),
+			want:      100,
+		},
+		{
+			name:      "expired",
+			notBefore: now.AddDate(0, 0, -90),
+			notAfter:  now.AddDate(0, 0, -1),
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, _ := testutil.IssueCert(t, &x509.Certificate{
+				SerialNumber: big.NewInt(1),
+				Subject:      pkix.Name{CommonName: "www.example.com"},
+				DNSNames:     []string{"www.example.com"},
+				NotBefore:    tt.notBefore,
+				NotAfter:     tt.notAfter,
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}, nil, nil)
+
+			_, payload := encodeDecode(t, input.Values{
+				CertChain: []*x509.Certificate{cert},
+				Server:    input.Server{HostValue: "www.example.com"},
+			})
+
+			got := payload.CertChainSubset[0]
+
+			if got.LifetimePercent != tt.want {
+				t.Errorf("got lifetime remaining %d%%, want %d%%", got.LifetimePercent, tt.want)
+			}
+
+			if got.Status.NotYetValid {
+				return
+			}
+
+			if want := fmt.Sprintf("(%d%%)", tt.want); !strings.HasSuffix(got.Summary, want) {
+				t.Errorf("got summary %q, want suffix %q", got.Summary, want)
+			}
+		})
+	}
+}
+
+func TestEncodeEffectiveExpiration(t *testing.T) {
+	now := time.Now()
+
+	// A chain whose served root certificate expires before the leaf and
+	// intermediate certificates.
+	root, rootKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Expiring Root CA"},
+		NotBefore:             now.AddDate(-5, 0, 0),
+		NotAfter:              now.AddDate(0, 0, 20),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+	intermediate, intermediateKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(0, 0, 40),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root, rootKey)
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.AddDate(0, 0, -30),
+		NotAfter:     now.AddDate(0, 0, 60),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, intermediate, intermediateKey)
+
+	tests := []struct {
+		name               string
+		certChain          func() []*x509.Certificate
+		wantChainIndex     int
+		wantType           string
+		wantCommonName     string
+		wantValidationPath []int
+	}{
+		{
+			name: "leaf expires first",
+			certChain: func() []*x509.Certificate {
+				return newTestChain(t, 60, 1000).certs()
+			},
+			wantChainIndex:     0,
+			wantType:           "leaf",
+			wantCommonName:     "www.example.com",
+			wantValidationPath: []int{0, 1},
+		},
+		{
+			name: "intermediate expires first",
+			certChain: func() []*x509.Certificate {
+				return newTestChain(t, 60, 30).certs()
+			},
+			wantChainIndex:     1,
+			wantType:           "intermediate",
+			wantCommonName:     "Test Intermediate CA",
+			wantValidationPath: []int{0, 1},
+		},
+		{
+			name: "served root expiring first is not used",
+			certChain: func() []*x509.Certificate {
+				return []*x509.Certificate{leaf, intermediate, root}
+			},
+			wantChainIndex:     1,
+			wantType:           "intermediate",
+			wantCommonName:     "Test Intermediate CA",
+			wantValidationPath: []int{0, 1},
+		},
+		{
+			name: "misordered chain",
+			certChain: func() []*x509.Certificate {
+				return []*x509.Certificate{intermediate, leaf, root}
+			},
+			wantChainIndex:     0,
+			wantType:           "intermediate",
+			wantCommonName:     "Test Intermediate CA",
+			wantValidationPath: []int{1, 0},
+		},
+		{
+			name: "no chain",
+			certChain: func() []*x509.Certificate {
+				return nil
+			},
+			wantChainIndex: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certChain := tt.certChain()
+
+			_, payload := encodeDecode(t, input.Values{
+				CertChain: certChain,
+				Server:    input.Server{HostValue: "www.example.com"},
+			})
+
+			got := payload.EffectiveExpiration
+
+			if got.ChainIndex != tt.wantChainIndex {
+				t.Fatalf("got chain index %d, want %d", got.ChainIndex, tt.wantChainIndex)
+			}
+
+			if len(got.ValidationPath) != 0 || len(tt.wantValidationPath) != 0 {
+				if !reflect.DeepEqual(got.ValidationPath, tt.wantValidationPath) {
+					t.Errorf("got validation path %v, want %v", got.ValidationPath, tt.wantValidationPath)
+				}
+			}
+
+			if tt.wantChainIndex == -1 {
+				return
+			}
+
+			if got.Type != tt.wantType || got.CommonName != tt.wantCommonName {
+				t.Errorf("got %s certificate %q, want %s certificate %q", got.Type, got.CommonName, tt.wantType, tt.wantCommonName)
+			}
+
+			if want := certChain[tt.wantChainIndex].NotAfter; !got.ExpiresOn.Equal(want) {
+				t.Errorf("got expiration %s, want %s", got.ExpiresOn, want)
+			}
+
+			effective := format2.Certificates(payload.CertChainSubset).EffectiveExpiration()
+			if effective.SerialNumber != got.SerialNumber || !effective.ExpiresOn.Equal(got.ExpiresOn) {
+				t.Errorf("got effective expiration certificate %s, want %s", effective.SerialNumber, got.SerialNumber)
+			}
+		})
+	}
+}
+
+func TestEncodeTrustVerification(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+	expiredIntermediateChain := newTestChain(t, 60, -1)
+	otherRoot := newTestChain(t, 60, 1000).root
+
+	bundleFile := filepath.Join(t.TempDir(), "ca-bundle.pem")
+	if err := os.WriteFile(bundleFile, caBundle(otherRoot, chain.root), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		certChain       []*x509.Certificate
+		trustStore      input.TrustStore
+		wantStatus      string
+		wantTrustStores []string
+
+		// wantCertIndex is the chain position of the certificate the
+		// untrusted chain issue is attributed to or -1 if the chain is
+		// trusted or was not verified.
+		wantCertIndex int
+	}{
+		{
+			name:          "not performed",
+			certChain:     chain.certs(),
+			wantStatus:    input.TrustStatusNotPerformed,
+			wantCertIndex: -1,
+		},
+		{
+			name:            "verified using PEM CA bundle",
+			certChain:       chain.certs(),
+			trustStore:      input.TrustStore{CABundlePEM: caBundle(chain.root)},
+			wantStatus:      input.TrustStatusVerified,
+			wantTrustStores: []string{"pem"},
+			wantCertIndex:   -1,
+		},
+		{
+			name:            "verified using CA bundle file",
+			certChain:       []*x509.Certificate{chain.leaf, chain.intermediate},
+			trustStore:      input.TrustStore{CABundleFiles: []string{bundleFile}},
+			wantStatus:      input.TrustStatusVerified,
+			wantTrustStores: []string{bundleFile},
+			wantCertIndex:   -1,
+		},
+		{
+			name:            "untrusted root",
+			certChain:       chain.certs(),
+			trustStore:      input.TrustStore{CABundlePEM: caBundle(otherRoot)},
+			wantStatus:      input.TrustStatusUntrustedRoot,
+			wantTrustStores: []string{"pem"},
+			wantCertIndex:   2,
+		},
+		{
+			name:            "unknown authority",
+			certChain:       []*x509.Certificate{chain.leaf, chain.intermediate},
+			trustStore:      input.TrustStore{CABundlePEM: caBundle(otherRoot)},
+			wantStatus:      input.TrustStatusUnknownAuthority,
+			wantTrustStores: []string{"pem"},
+			wantCertIndex:   1,
+		},
+		{
+			name:            "expired intermediate",
+			certChain:       expiredIntermediateChain.certs(),
+			trustStore:      input.TrustStore{CABundlePEM: caBundle(expiredIntermediateChain.root)},
+			wantStatus:      input.TrustStatusInvalid,
+			wantTrustStores: []string{"pem"},
+			wantCertIndex:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, payload := encodeDecode(t, input.Values{
+				CertChain:  tt.certChain,
+				Server:     input.Server{HostValue: "www.example.com"},
+				TrustStore: tt.trustStore,
+			})
+
+			trust := payload.TrustVerification
+			wantVerified := tt.wantStatus == input.TrustStatusVerified
+			wantPerformed := tt.wantStatus != input.TrustStatusNotPerformed
+
+			if trust.Status != tt.wantStatus || trust.Verified != wantVerified || trust.Performed != wantPerformed {
+				t.Errorf("got trust verification %+v, want status %s", trust, tt.wantStatus)
+			}
+
+			if len(trust.TrustStores) != 0 || len(tt.wantTrustStores) != 0 {
+				if !reflect.DeepEqual(trust.TrustStores, tt.wantTrustStores) {
+					t.Errorf("got trust stores %v, want %v", trust.TrustStores, tt.wantTrustStores)
+				}
+			}
+
+			if wantVerified {
+				if len(trust.VerifiedPaths) != 1 {
+					t.Fatalf("got verified paths %+v, want one path", trust.VerifiedPaths)
+				}
+
+				path := trust.VerifiedPaths[0]
+				if anchor := path[len(path)-1]; anchor.CommonName != "Test Root CA" {
+					t.Errorf("got trust anchor %+v, want Test Root CA", anchor)
+				}
+			}
+
+			if payload.Issues.UntrustedChain != (tt.wantCertIndex != -1) {
+				t.Errorf("got untrusted chain %t, want %t", payload.Issues.UntrustedChain, tt.wantCertIndex != -1)
+			}
+
+			var found int
+			for idx, cert := range payload.CertChainSubset {
+				for _, issue := range cert.Issues {
+					if issue.Name != input.IssueUntrustedChain {
+						continue
+					}
+
+					found++
+
+					if idx != tt.wantCertIndex {
+						t.Errorf("got untrusted chain issue for cert %d, want cert %d", idx, tt.wantCertIndex)
+					}
+
+					wantPrefix := fmt.Sprintf("cert %d not verified to a trusted root (%s): ", idx, tt.wantStatus)
+					if !strings.HasPrefix(issue.Evidence, wantPrefix) {
+						t.Errorf("got evidence %q, want prefix %q", issue.Evidence, wantPrefix)
+					}
+				}
+			}
+
+			if wantFound := tt.wantCertIndex != -1; (found == 1) != wantFound || found > 1 {
+				t.Errorf("got %d untrusted chain issues, want found %t", found, wantFound)
+			}
+		})
+	}
+}
+
+func TestEncodeKeyStrength(t *testing.T) {
+	now := time.Now()
+
+	root, rootKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             now.AddDate(-5, 0, 0),
+		NotAfter:              now.AddDate(15, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, nil)
+
+	intermediate, intermediateKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(5, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, root, rootKey)
+
+	rsaKey := func(bits int) *rsa.PublicKey {
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &key.PublicKey
+	}
+
+	ecdsaKey := func(curve elliptic.Curve) *ecdsa.PublicKey {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &key.PublicKey
+	}
+
+	rsa2048 := rsaKey(2048)
+
+	tests := []struct {
+		name          string
+		pub           crypto.PublicKey
+		policy        input.KeyPolicy
+		wantAlgorithm string
+		wantSize      int
+		wantCurve     string
+		wantExponent  int
+
+		// wantReason is the reason the leaf key is considered weak or an
+		// empty string if the key satisfies the policy.
+		wantReason string
+	}{
+		{
+			name:          "ECDSA P-256",
+			pub:           ecdsaKey(elliptic.P256()),
+			wantAlgorithm: "ECDSA",
+			wantSize:      256,
+			wantCurve:     "P-256",
+		},
+		{
+			name:          "ECDSA curve not allowed",
+			pub:           ecdsaKey(elliptic.P384()),
+			policy:        input.KeyPolicy{AllowedCurves: []string{"P-256"}},
+			wantAlgorithm: "ECDSA",
+			wantSize:      384,
+			wantCurve:     "P-384",
+			wantReason:    "ECDSA curve P-384 is not one of the allowed curves (P-256)",
+		},
+		{
+			name:          "RSA 2048",
+			pub:           rsa2048,
+			wantAlgorithm: "RSA",
+			wantSize:      2048,
+			wantExponent:  65537,
+		},
+		{
+			name:          "RSA 1024",
+			pub:           rsaKey(1024),
+			wantAlgorithm: "RSA",
+			wantSize:      1024,
+			wantExponent:  65537,
+			wantReason:    "RSA key size of 1024 bits is below the minimum of 2048 bits",
+		},
+		{
+			name:          "RSA 2048 below policy minimum",
+			pub:           rsa2048,
+			policy:        input.KeyPolicy{MinRSAKeyBits: 3072},
+			wantAlgorithm: "RSA",
+			wantSize:      2048,
+			wantExponent:  65537,
+			wantReason:    "RSA key size of 2048 bits is below the minimum of 3072 bits",
+		},
+		{
+			name:          "RSA small public exponent",
+			pub:           &rsa.PublicKey{N: rsa2048.N, E: 3},
+			wantAlgorithm: "RSA",
+			wantSize:      2048,
+			wantExponent:  3,
+			wantReason:    "RSA public exponent 3 is below the minimum of 65537",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf := testutil.IssueCertForKey(t, &x509.Certificate{
+				SerialNumber: big.NewInt(3),
+				Subject:      pkix.Name{CommonName: "www.example.com"},
+				DNSNames:     []string{"www.example.com"},
+				NotBefore:    now.AddDate(0, 0, -30),
+				NotAfter:     now.AddDate(0, 0, 60),
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}, intermediate, intermediateKey, tt.pub)
+
+			_, payload := encodeDecode(t, input.Values{
+				CertChain: []*x509.Certificate{leaf, intermediate, root},
+				Server:    input.Server{HostValue: "www.example.com"},
+				KeyPolicy: tt.policy,
+			})
+
+			leafCert := payload.CertChainSubset[0]
+
+			if leafCert.KeyAlgorithm != tt.wantAlgorithm ||
+				leafCert.KeySize != tt.wantSize ||
+				leafCert.KeyCurve != tt.wantCurve ||
+				leafCert.RSAExponent != tt.wantExponent {
+				t.Errorf("got key %s %d bits (curve %q, exponent %d), want %s %d bits (curve %q, exponent %d)",
+					leafCert.KeyAlgorithm, leafCert.KeySize, leafCert.KeyCurve, leafCert.RSAExponent,
+					tt.wantAlgorithm, tt.wantSize, tt.wantCurve, tt.wantExponent)
+			}
+
+			if payload.Issues.WeakKey != (tt.wantReason != "") {
+				t.Errorf("got weak key %t, want %t", payload.Issues.WeakKey, tt.wantReason != "")
+			}
+
+			var evidence []string
+			for _, issue := range leafCert.Issues {
+				if issue.Name == input.IssueWeakKey {
+					evidence = append(evidence, issue.Evidence)
+				}
+			}
+
+			var wantEvidence []string
+			if tt.wantReason != "" {
+				wantEvidence = []string{"cert 0 has a weak public key: " + tt.wantReason}
+			}
+
+			if !reflect.DeepEqual(evidence, wantEvidence) {
+				t.Errorf("got weak key evidence %q, want %q", evidence, wantEvidence)
+			}
+		})
+	}
+}
+
+func TestEncodeDebianWeakKeyCheck(t *testing.T) {
+	chain := newTestChain(t, 60, 1000)
+
+	// An arbitrary entry in the openssl-blacklist format which is not
+	// present in the embedded blocklist.
+	blocklistFile := filepath.Join(t.TempDir(), "blacklist.RSA-2048")
+	if err := os.WriteFile(blocklistFile, []byte("ffffffffffffffffffff\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, embeddedOnly := encodeDecode(t, input.Values{
+		CertChain: chain.certs(),
+		Server:    input.Server{HostValue: "www.example.com"},
+	})
+
+	embeddedEntries := embeddedOnly.DebianWeakKeyCheck.BlocklistEntries
+
+	tests := []struct {
+		name        string
+		files       []string
+		wantStatus  string
+		wantEntries int
+	}{
+		{
+			name:        "embedded sample blocklist only",
+			wantStatus:  input.DebianWeakKeyCheckIncomplete,
+			wantEntries: embeddedEntries,
+		},
+		{
+			name:        "blocklist files provided",
+			files:       []string{blocklistFile},
+			wantStatus:  input.DebianWeakKeyCheckPerformed,
+			wantEntries: embeddedEntries + 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, payload := encodeDecode(t, input.Values{
+				CertChain: chain.certs(),
+				Server:    input.Server{HostValue: "www.example.com"},
+				KeyPolicy: input.KeyPolicy{DebianBlocklistFiles: tt.files},
+			})
+
+			check := payload.DebianWeakKeyCheck
+
+			if check.Status != tt.wantStatus || check.BlocklistEntries != tt.wantEntries {
+				t.Errorf(
+					"got status %s with %d entries, want status %s with %d entries",
+					check.Status,
+					check.BlocklistEntries,
+					tt.wantStatus,
+					tt.wantEntries,
+				)
+			}
+
+			if embeddedEntries == 0 {
+				t.Error("got no embedded blocklist entries")
+			}
+
+			if !reflect.DeepEqual(check.BlocklistFiles, tt.files) {
+				t.Errorf("got blocklist files %v, want %v", check.BlocklistFiles, tt.files)
+			}
+		})
+	}
+}
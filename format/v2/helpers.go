@@ -0,0 +1,215 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2
+
+import (
+	"github.com/atc0005/cert-payload/format/internal/shared"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/textutils"
+	"github.com/atc0005/cert-payload/issues"
+	"github.com/atc0005/cert-payload/lint"
+)
+
+// Confirmed is a helper function to indicate whether issues are present
+// with the evaluated certificate chain.
+func (cci CertificateChainIssues) Confirmed() bool {
+	switch {
+	case cci.MissingIntermediateCerts:
+		return true
+
+	case cci.MissingSANsEntries:
+		return true
+
+	case cci.DuplicateCerts:
+		return true
+
+	case cci.MisorderedCerts:
+		return true
+
+	case cci.ExpiredCerts:
+		return true
+
+	case cci.HostnameMismatch:
+		return true
+
+	case cci.SelfSignedLeafCert:
+		return true
+
+	case cci.WeakSignatureAlgorithm:
+		return true
+
+	case cci.NotYetValidCerts:
+		return true
+
+	case cci.LeafOutlivesIssuer:
+		return true
+
+	case cci.UntrustedChain:
+		return true
+
+	case cci.RevokedCerts:
+		return true
+
+	case cci.MissingOCSPStaple:
+		return true
+
+	case cci.ExpiringCrossSign:
+		return true
+
+	case cci.WeakKey:
+		return true
+
+	case cci.ROCAVulnerableKey:
+		return true
+
+	case cci.DebianWeakKey:
+		return true
+
+	case cci.RenewalOverdue:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Flags returns each certificate chain issue paired with a boolean value
+// indicating whether the issue was detected. Issues are returned in a fixed
+// order and are named using the JSON field names of the
+// CertificateChainIssues type. These names are intended to remain stable for
+// use as metric labels or item keys by reporting and monitoring tools.
+func (cci CertificateChainIssues) Flags() []IssueFlag {
+	return issues.Flags(cci)
+}
+
+// Detected returns the names of all detected certificate chain issues.
+func (cci CertificateChainIssues) Detected() []string {
+	return issues.Detected(cci)
+}
+
+// Count returns the number of detected certificate chain issues.
+func (cci CertificateChainIssues) Count() int {
+	return len(cci.Detected())
+}
+
+// IssuesFromNames converts the given certificate chain issue names (e.g.,
+// `expired_certs`) into a list of issues using the default severity from the
+// issue catalogue. Unrecognized issue names are skipped.
+//
+// This is intended for converting the boolean-only issue fields of earlier
+// payload format versions into the issue list provided by this format
+// version. For example:
+//
+//	details := format2.IssuesFromNames(format1Payload.Issues.Detected())
+func IssuesFromNames(names []string) []Issue {
+	return issueDetails(names, nil, nil)
+}
+
+// issueDetails is a helper function that converts the given certificate
+// chain issue names into a list of issues. The severity for an issue is
+// taken from the given mapping of issue name to service state, falling back
+// to the default severity from the issue catalogue. The certificates involved
+// in each issue are taken from the given findings. Issues for which all of
+// the given findings are ignored are marked as ignored.
+func issueDetails(names []string, issueStates map[string]string, findings []shared.Finding) []Issue {
+	defs := issues.FromNames(names)
+	details := make([]Issue, 0, len(defs))
+	ignored := shared.IgnoredIssues(findings)
+
+	for _, def := range defs {
+		severity := def.Severity
+		if state, ok := issueStates[def.Name]; ok {
+			severity = issues.SeverityFromServiceState(state)
+		}
+
+		details = append(details, Issue{
+			Code:         def.Code,
+			Name:         def.Name,
+			Severity:     severity,
+			Description:  def.Description,
+			Remediation:  def.Remediation,
+			ChainIndexes: shared.CertIndexesForIssue(findings, def.Name),
+			Ignored:      ignored[def.Name],
+		})
+	}
+
+	return details
+}
+
+// reportedIssues is a helper function that returns the names of the given
+// detected certificate chain issues along with the names of the issues for
+// which all of the given findings are ignored. Names are returned in
+// catalogue order.
+func reportedIssues(detected []string, findings []shared.Finding) []string {
+	ignored := shared.IgnoredIssues(findings)
+	names := make([]string, 0, len(detected)+len(ignored))
+
+	for _, def := range issues.Catalog() {
+		if ignored[def.Name] || textutils.InList(def.Name, detected, false) {
+			names = append(names, def.Name)
+		}
+	}
+
+	return names
+}
+
+// certificateIssues is a helper function that converts the findings
+// attributed to the certificate at the given chain position into a list of
+// certificate issues.
+func certificateIssues(findings []shared.Finding, certIndex int) []CertificateIssue {
+	certFindings := shared.FindingsForCert(findings, certIndex)
+	certIssues := make([]CertificateIssue, 0, len(certFindings))
+
+	for _, finding := range certFindings {
+		var code string
+		if def, ok := issues.Lookup(finding.Issue); ok {
+			code = def.Code
+		}
+
+		certIssues = append(certIssues, CertificateIssue{
+			Code:          code,
+			Name:          finding.Issue,
+			ChainIndexes:  finding.CertIndexes,
+			Evidence:      finding.Evidence,
+			Ignored:       finding.Ignored,
+			IgnoredReason: finding.Suppression.Reason,
+			IgnoredUntil:  finding.Suppression.Expires,
+		})
+	}
+
+	return certIssues
+}
+
+// certificateLints is a helper function that converts the given failed
+// lints for a certificate into a list of certificate lints.
+func certificateLints(results []lint.Result) []CertificateLint {
+	certLints := make([]CertificateLint, 0, len(results))
+
+	for _, result := range results {
+		certLint := CertificateLint{
+			ID:       result.ID,
+			Severity: result.Severity,
+			Details:  result.Details,
+		}
+
+		if def, ok := lint.Lookup(result.ID); ok {
+			certLint.Description = def.Description
+			certLint.Citation = def.Citation
+		}
+
+		certLints = append(certLints, certLint)
+	}
+
+	return certLints
+}
+
+// revoked is a helper method that indicates whether the revocation check
+// found the certificate to be revoked.
+func (rc RevocationCheck) revoked() bool {
+	return rc.Status == input.RevocationStatusRevoked
+}
@@ -0,0 +1,1022 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package format2
+
+import (
+	"time"
+
+	"github.com/atc0005/cert-payload/issues"
+)
+
+const (
+	// FormatVersion indicates the format version support provided by this
+	// package.
+	FormatVersion int = 2
+)
+
+// Server reflects the host value and resolved IP Address used to retrieve the
+// certificate chain.
+type Server struct {
+	// HostValue is the original hostname value. While usually a FQDN, this
+	// value could also be a fixed IP Address (e.g., if SNI support wasn't
+	// used to retrieve the certificate chain).
+	HostValue string `json:"host_value"`
+
+	// IPAddress is the resolved IP Address for the hostname value used to
+	// retrieve a certificate chain.
+	IPAddress string `json:"ip_address"`
+}
+
+// CertificateStatus is the overall status of a certificate. Unlike earlier
+// format versions, this status reflects only the certificate it is
+// associated with and not the certificate chain as a whole.
+//
+//   - no problems (ok)
+//   - expired
+//   - expiring (based on given threshold values)
+//   - not yet valid
+//   - revoked (based on provided revocation information)
+//
+// TODO: Any useful status values to borrow here?
+// They have `Active`, `Revoked` and then a `Pending*` variation for both.
+// https://developers.cloudflare.com/ssl/reference/certificate-statuses/#client-certificates
+type CertificateStatus struct {
+	OK       bool `json:"status_ok"`       // No observed issues; shouldn't this be calculated?
+	Expiring bool `json:"status_expiring"` // Based on given monitoring thresholds
+	Expired  bool `json:"status_expired"`  // Based on certificate NotAfter field
+
+	// NotYetValid indicates that the certificate NotBefore field is in the
+	// future (allowing for the configured clock skew tolerance).
+	NotYetValid bool `json:"status_not_yet_valid"`
+
+	// Revoked indicates that the certificate has been revoked per any
+	// evaluated source of revocation information.
+	Revoked bool `json:"status_revoked"`
+
+	// RevokedPerCRL indicates that the certificate has been revoked per a
+	// provided Certificate Revocation List.
+	RevokedPerCRL bool `json:"status_revoked_per_crl"`
+
+	// RevokedPerOCSP indicates that the certificate has been revoked per a
+	// stapled or provided OCSP response.
+	RevokedPerOCSP bool `json:"status_revoked_per_ocsp"`
+
+	// ExpirationIgnored indicates that the certificate is expiring or has
+	// expired and that the expiration is ignored per a suppression. An
+	// ignored expiration does not affect the OK status or service state.
+	ExpirationIgnored bool `json:"status_expiration_ignored"`
+}
+
+// CertificateChainStatus is the overall status of a certificate chain
+// determined from the status of each certificate in the chain.
+type CertificateChainStatus struct {
+	OK       bool `json:"status_ok"`       // No expired or expiring certificates
+	Expiring bool `json:"status_expiring"` // One or more expiring certificates
+	Expired  bool `json:"status_expired"`  // One or more expired certificates
+
+	// ExpiringCertsCount is the number of expiring certificates in the
+	// chain.
+	ExpiringCertsCount int `json:"expiring_certs_count"`
+
+	// ExpiredCertsCount is the number of expired certificates in the chain.
+	ExpiredCertsCount int `json:"expired_certs_count"`
+
+	// ExpirationIgnoredCertsCount is the number of expiring or expired
+	// certificates in the chain with an expiration ignored per a
+	// suppression. These certificates are not included in the expiring and
+	// expired certificate counts.
+	ExpirationIgnoredCertsCount int `json:"expiration_ignored_certs_count"`
+
+	// NotYetValid indicates that one or more certificates in the chain are
+	// not yet valid.
+	NotYetValid bool `json:"status_not_yet_valid"`
+
+	// NotYetValidCertsCount is the number of certificates in the chain which
+	// are not yet valid.
+	NotYetValidCertsCount int `json:"not_yet_valid_certs_count"`
+
+	// Revoked indicates that one or more certificates in the chain have
+	// been revoked.
+	Revoked bool `json:"status_revoked"`
+
+	// RevokedCertsCount is the number of revoked certificates in the chain.
+	RevokedCertsCount int `json:"revoked_certs_count"`
+}
+
+// CertificateRevocation is the revocation status of a certificate.
+type CertificateRevocation struct {
+	// Status is the overall revocation status of the certificate (e.g.,
+	// not_checked, good, revoked, unknown) determined from all evaluated
+	// sources of revocation information. A revoked status from any source
+	// takes precedence.
+	Status string `json:"status"`
+
+	// Reason is the revocation reason for a revoked certificate (e.g.,
+	// keyCompromise).
+	Reason string `json:"reason"`
+
+	// RevokedAt is a RFC3389 time value for when the certificate was
+	// revoked.
+	RevokedAt time.Time `json:"revoked_at"`
+
+	// CRL is the revocation status of the certificate per the provided
+	// Certificate Revocation Lists.
+	CRL RevocationCheck `json:"crl"`
+
+	// OCSP is the revocation status of the certificate per the stapled or
+	// provided OCSP responses. The signer value includes the responder
+	// identity from the response.
+	OCSP RevocationCheck `json:"ocsp"`
+
+	// OCSPStapled indicates whether the service stapled an OCSP response for
+	// the certificate to the TLS handshake.
+	OCSPStapled bool `json:"ocsp_stapled"`
+}
+
+// RevocationCheck is the revocation status of a certificate per a single
+// source of revocation information.
+type RevocationCheck struct {
+	// Status is the revocation status (e.g., not_checked, good, revoked,
+	// unknown).
+	Status string `json:"status"`
+
+	// Reason is the revocation reason for a revoked certificate (e.g.,
+	// keyCompromise).
+	Reason string `json:"reason"`
+
+	// RevokedAt is a RFC3389 time value for when the certificate was
+	// revoked.
+	RevokedAt time.Time `json:"revoked_at"`
+
+	// ThisUpdate is a RFC3389 time value for when the revocation
+	// information was issued.
+	ThisUpdate time.Time `json:"this_update"`
+
+	// NextUpdate is a RFC3389 time value for when newer revocation
+	// information is expected to be available.
+	NextUpdate time.Time `json:"next_update"`
+
+	// Stale indicates that the revocation information is past its
+	// NextUpdate value.
+	Stale bool `json:"stale"`
+
+	// Signer is the subject of the certificate which signed the revocation
+	// information.
+	Signer string `json:"signer"`
+
+	// Error is the error encountered while evaluating the revocation
+	// information, if any.
+	Error string `json:"error"`
+}
+
+// ChainExpiration is the effective expiration of a certificate chain; the
+// point at which the certificate chain as a whole stops working. This is the
+// earliest expiration among the certificates needed to validate the leaf
+// certificate.
+type ChainExpiration struct {
+	// ExpiresOn is a RFC3389 time value for when the certificate chain
+	// stops working.
+	ExpiresOn time.Time `json:"not_after"`
+
+	// DaysRemaining is the number of days remaining for the certificate
+	// chain in two digit decimal precision.
+	DaysRemaining float64 `json:"days_remaining"`
+
+	// ChainIndex is the chain position (zero-based) of the certificate which
+	// determines the effective expiration. This value is -1 if a
+	// determination could not be made (e.g., an empty certificate chain).
+	ChainIndex int `json:"chain_index"`
+
+	// CommonName is the short subject value of the certificate which
+	// determines the effective expiration. This is intended for display
+	// purposes.
+	CommonName string `json:"common_name"`
+
+	// SerialNumber is the serial number of the certificate which determines
+	// the effective expiration.
+	SerialNumber string `json:"serial_number"`
+
+	// Type indicates the type of the certificate which determines the
+	// effective expiration (leaf, intermediate or root).
+	Type string `json:"type"`
+
+	// ValidationPath is the list of chain positions (zero-based) for the
+	// certificates needed to validate the leaf certificate, starting with
+	// the leaf certificate.
+	ValidationPath []int `json:"validation_path"`
+}
+
+// TrustVerification is the result of verifying the certificate chain against
+// a trust store of root certificates.
+type TrustVerification struct {
+	// Performed indicates whether trust verification was requested and
+	// performed.
+	Performed bool `json:"performed"`
+
+	// Verified indicates whether the certificate chain was verified to a
+	// trusted root certificate.
+	Verified bool `json:"verified"`
+
+	// Status is the trust verification status (e.g., not_performed,
+	// verified, untrusted_root, unknown_authority, invalid).
+	Status string `json:"status"`
+
+	// Error is the error message from the verification process, if any.
+	Error string `json:"error"`
+
+	// TrustStores is the list of trust store sources used for verification.
+	// The value `system` indicates the system trust store and `pem` a PEM
+	// encoded CA bundle provided directly; other values are CA bundle file
+	// paths.
+	TrustStores []string `json:"trust_stores"`
+
+	// VerifiedPaths is the list of verified paths from the leaf certificate
+	// to a trusted root certificate.
+	VerifiedPaths [][]PathCertificate `json:"verified_paths"`
+
+	// AllPaths is the list of all paths from the leaf certificate to a trust
+	// anchor in the trust stores, irrespective of certificate validity
+	// periods. Cross-signed intermediate certificates result in multiple
+	// paths; the path a client uses depends on its trust store.
+	AllPaths []ChainPath `json:"all_paths"`
+}
+
+// DebianWeakKeyCheck is the coverage of the check for RSA keys generated by
+// the vulnerable Debian OpenSSL package (CVE-2008-0166).
+type DebianWeakKeyCheck struct {
+	// Status is the Debian weak key check status (e.g., incomplete,
+	// performed). The incomplete status indicates that only the embedded
+	// sample blocklist was used; a weak key which is not in the sample is
+	// not detected.
+	Status string `json:"status"`
+
+	// BlocklistEntries is the number of entries in the blocklist used for
+	// the check.
+	BlocklistEntries int `json:"blocklist_entries"`
+
+	// BlocklistFiles is the list of blocklist files used in addition to the
+	// embedded blocklist.
+	BlocklistFiles []string `json:"blocklist_files"`
+}
+
+// ChainPath is a path from the leaf certificate to a trust anchor.
+type ChainPath struct {
+	// Certificates is the list of certificates in the path, starting with
+	// the leaf certificate and ending with the trust anchor.
+	Certificates []PathCertificate `json:"certificates"`
+
+	// Served indicates whether the path starts with the served certificates
+	// needed to validate the leaf certificate.
+	Served bool `json:"served"`
+
+	// CrossSigned indicates whether the path includes a cross-signed
+	// intermediate certificate.
+	CrossSigned bool `json:"cross_signed"`
+
+	// ExpiresOn is a RFC3389 time value for the effective expiration of the
+	// path; the earliest expiration among the certificates in the path,
+	// including the trust anchor.
+	ExpiresOn time.Time `json:"not_after"`
+
+	// DaysRemaining is the number of days remaining for the path in two
+	// digit decimal precision.
+	DaysRemaining float64 `json:"days_remaining"`
+
+	// ExpirationPathIndex is the path position (zero-based) of the
+	// certificate which determines the effective expiration of the path.
+	ExpirationPathIndex int `json:"expiration_path_index"`
+}
+
+// ChainOrder is the presented order of the certificate chain along with the
+// canonical leaf to root order reconstructed from the presented
+// certificates.
+type ChainOrder struct {
+	// Reordered indicates whether the reconstructed order differs from the
+	// presented order. Unused certificates listed after the reconstructed
+	// chain are not considered.
+	Reordered bool `json:"reordered"`
+
+	// Complete indicates whether the reconstructed certificate chain ends
+	// with a self-signed (root) certificate.
+	Complete bool `json:"complete"`
+
+	// Presented is the certificate chain in the order presented by the
+	// service or bundle file.
+	Presented []PathCertificate `json:"presented"`
+
+	// Reconstructed is the certificate chain in the canonical leaf to root
+	// order reconstructed from the presented certificates.
+	Reconstructed []PathCertificate `json:"reconstructed"`
+
+	// Unused is the list of presented certificates which are not part of
+	// the reconstructed certificate chain (e.g., duplicate or unrelated
+	// certificates).
+	Unused []PathCertificate `json:"unused"`
+}
+
+// PathCertificate is a certificate in a certificate path.
+type PathCertificate struct {
+	// Subject is the full subject value for the certificate.
+	Subject string `json:"subject"`
+
+	// CommonName is the short subject value of the certificate.
+	CommonName string `json:"common_name"`
+
+	// SerialNumber is the serial number for the certificate in hex format
+	// with a colon inserted after each two digits.
+	SerialNumber string `json:"serial_number"`
+
+	// ExpiresOn is a RFC3389 time value for when the certificate expires.
+	ExpiresOn time.Time `json:"not_after"`
+
+	// ChainIndex is the chain position (zero-based) of the certificate in
+	// the presented certificate chain or -1 if the certificate is not part
+	// of the presented chain (e.g., provided by the trust store).
+	ChainIndex int `json:"chain_index"`
+
+	// NotServed indicates that the certificate was not served by the
+	// endpoint as part of the presented certificate chain (e.g., provided by
+	// the trust store or retrieved from an AIA CA Issuers URL).
+	NotServed bool `json:"not_served_by_endpoint"`
+}
+
+// ChainCompletion is the certificate chain completed using issuer
+// certificates which were not served by the endpoint and were instead
+// retrieved from elsewhere (e.g., AIA CA Issuers URLs).
+type ChainCompletion struct {
+	// Completed indicates whether issuer certificates missing from the
+	// presented certificate chain were retrieved.
+	Completed bool `json:"completed"`
+
+	// Complete indicates whether the completed certificate chain ends with a
+	// self-signed (root) certificate.
+	Complete bool `json:"complete"`
+
+	// Path is the completed certificate chain in the canonical leaf to root
+	// order. Retrieved certificates are marked as not served by the
+	// endpoint.
+	Path []PathCertificate `json:"path"`
+
+	// FetchedIssuers is the list of retrieved issuer certificates which were
+	// not served by the endpoint. These are the certificates which should be
+	// installed alongside the leaf certificate.
+	FetchedIssuers []FetchedCertificate `json:"fetched_issuers"`
+}
+
+// FetchedCertificate is an issuer certificate which was not served by the
+// endpoint and was instead retrieved from elsewhere.
+type FetchedCertificate struct {
+	// Subject is the full subject value for the certificate.
+	Subject string `json:"subject"`
+
+	// CommonName is the short subject value of the certificate.
+	CommonName string `json:"common_name"`
+
+	// Issuer is the full issuer value for the certificate.
+	Issuer string `json:"issuer"`
+
+	// SerialNumber is the serial number for the certificate in hex format
+	// with a colon inserted after each two digits.
+	SerialNumber string `json:"serial_number"`
+
+	// SubjectKeyID is the Subject Key Identifier of the certificate in hex
+	// format with a colon inserted after each two digits.
+	SubjectKeyID string `json:"subject_key_id"`
+
+	// ExpiresOn is a RFC3389 time value for when the certificate expires.
+	ExpiresOn time.Time `json:"not_after"`
+
+	// Source is the location the certificate was retrieved from (e.g., the
+	// AIA CA Issuers URL).
+	Source string `json:"source"`
+
+	// NotServed indicates that the certificate was not served by the
+	// endpoint. This is always true for retrieved certificates.
+	NotServed bool `json:"not_served_by_endpoint"`
+
+	// PEM is the PEM encoded certificate.
+	PEM string `json:"pem"`
+}
+
+// Certificate is a subset of the metadata for an evaluated certificate.
+type Certificate struct {
+	// Subject is the full subject value for a certificate. This is intended
+	// for (non-cryptographic) comparison purposes.
+	Subject string `json:"subject"`
+
+	// CommonName is the short subject value of a certificate. This is
+	// intended for display purposes.
+	CommonName string `json:"common_name"`
+
+	// SANsEntries is the full list of Subject Alternate Names for a
+	// certificate.
+	SANsEntries []string `json:"sans_entries"`
+
+	// SANsEntriesCount is the number of Subject Alternate Names for a
+	// certificate.
+	//
+	// This field allows the payload creator to omit SANs entries to conserve
+	// plugin output size and still indicate the number of SANs entries
+	// present for a certificate for use in display or for metrics purposes.
+	SANsEntriesCount int `json:"sans_entries_count"`
+
+	// Issuer is the full CommonName of the signing certificate. This is
+	// intended for (non-cryptographic) comparison purposes.
+	Issuer string `json:"issuer"`
+
+	// IssuerShort is the short CommonName of the signing certificate. This is
+	// intended for display purposes.
+	IssuerShort string `json:"issuer_short"`
+
+	// IssuerIndex is the chain position (zero-based) of the certificate
+	// which issued this certificate or -1 if the issuer is not present in
+	// the certificate chain. Issuers are identified using the raw issuer and
+	// subject names, the Authority and Subject Key Identifiers (when present)
+	// and signature verification. A self-signed certificate is not considered
+	// to be its own issuer.
+	IssuerIndex int `json:"issuer_index"`
+
+	// SubjectKeyID is the Subject Key Identifier for a certificate in hex
+	// format with a colon inserted after each two digits. This value is
+	// empty if the extension is not present.
+	SubjectKeyID string `json:"subject_key_id"`
+
+	// AuthorityKeyID is the Authority Key Identifier for a certificate in
+	// hex format with a colon inserted after each two digits. This value
+	// matches the SubjectKeyID of the issuing certificate and is empty if
+	// the extension is not present.
+	AuthorityKeyID string `json:"authority_key_id"`
+
+	// SerialNumber is the serial number for a certificate in hex format with
+	// a colon inserted after each two digits.
+	//
+	// For example, `77:BD:0D:6C:DB:36:F9:1A:EA:21:0F:C4:F0:58:D3:0D`.
+	SerialNumber string `json:"serial_number"`
+
+	// IssuedOn is a RFC3389 time value for when a certificate is first
+	// valid or usable.
+	IssuedOn time.Time `json:"not_before"`
+
+	// ExpiresOn is a RFC3389 time value for when the certificate expires.
+	ExpiresOn time.Time `json:"not_after"`
+
+	// DaysRemaining is the number of days remaining for a certificate in two
+	// digit decimal precision.
+	DaysRemaining float64 `json:"days_remaining"`
+
+	// DaysRemainingTruncated is the number of days remaining for a
+	// certificate as a whole number rounded down.
+	//
+	// For example, if five and a half days remain then this value would be
+	// `5`.
+	DaysRemainingTruncated int `json:"days_remaining_truncated"`
+
+	// DaysUntilValid is the number of days until a certificate which is not
+	// yet valid becomes valid in two digit decimal precision. This value is
+	// zero for certificates which are already valid.
+	DaysUntilValid float64 `json:"days_until_valid"`
+
+	// ExpiringWarningOn is a RFC3389 time value for when the certificate
+	// reaches the WARNING expiration threshold for its role (see the
+	// effective expiration thresholds of the payload).
+	ExpiringWarningOn time.Time `json:"expiring_warning_on"`
+
+	// ExpiringCriticalOn is a RFC3389 time value for when the certificate
+	// reaches the CRITICAL expiration threshold for its role (see the
+	// effective expiration thresholds of the payload).
+	ExpiringCriticalOn time.Time `json:"expiring_critical_on"`
+
+	// LifetimePercent is percentage of life remaining for a certificate.
+	//
+	// For example, if 43% life is remaining for a cert (a rounded value) this
+	// field would be set to `43`. A certificate which is not yet valid
+	// reports `100`.
+	LifetimePercent int `json:"lifetime_remaining_percent"`
+
+	// ValidityPeriodDescription is the human readable value such as "90 days"
+	// or "1 year".
+	ValidityPeriodDescription string `json:"validity_period_description"`
+
+	// ValidityPeriodDays is the number of total days a certificate is valid
+	// for using `Not Before` & `Not After` as the starting & ending range.
+	ValidityPeriodDays int `json:"validity_period_days"`
+
+	// ValidityCompliance is the evaluation of the validity period of a leaf
+	// certificate against the CA/Browser Forum schedule of maximum validity
+	// periods.
+	ValidityCompliance ValidityCompliance `json:"validity_compliance"`
+
+	// human readable summary such as, `[OK] 1199d 2h remaining (43%)`
+	Summary string `json:"summary"`
+
+	// Status is the overall status of the certificate.
+	Status CertificateStatus `json:"status"`
+
+	// ExpirationIgnoredReason is the reason recorded for the suppression
+	// applied to an ignored certificate expiration.
+	ExpirationIgnoredReason string `json:"expiration_ignored_reason"`
+
+	// ExpirationIgnoredUntil is the point in time at which the suppression
+	// applied to an ignored certificate expiration no longer applies. This
+	// value is not set if the suppression does not expire.
+	ExpirationIgnoredUntil time.Time `json:"expiration_ignored_until"`
+
+	// SignatureAlgorithm indicates what certificate signature algorithm was
+	// used by a certification authority (CA)'s private key to sign a checksum
+	// calculated by a signature hash algorithm (i.e., what algorithm was used
+	// to sign the certificate). The verifying party must use the same
+	// algorithm to decrypt and verify the checksum using the CA's public key.
+	//
+	// A cryptographically weak hashing algorithm (e.g. MD2, MD4, MD5, SHA1)
+	// used to sign a certificate is considered to be a vulnerability.
+	SignatureAlgorithm string `json:"signature_algorithm"`
+
+	// KeyAlgorithm is the algorithm of the subject public key (e.g., RSA,
+	// ECDSA, Ed25519, DSA).
+	KeyAlgorithm string `json:"key_algorithm"`
+
+	// KeySize is the size of the subject public key in bits (e.g., the RSA
+	// modulus size or the ECDSA curve size).
+	KeySize int `json:"key_size"`
+
+	// KeyCurve is the name of the elliptic curve for an ECDSA subject public
+	// key (e.g., P-256).
+	KeyCurve string `json:"key_curve"`
+
+	// RSAExponent is the public exponent of a RSA subject public key.
+	RSAExponent int `json:"rsa_exponent"`
+
+	// Type indicates the type of certificate (leaf, intermediate or root).
+	Type string `json:"type"`
+
+	// ValidationPath indicates whether the certificate is needed to validate
+	// the leaf certificate and so is considered when determining the
+	// effective expiration of the certificate chain. Root certificates are
+	// not included as clients use the copy of a root certificate from their
+	// trust store.
+	ValidationPath bool `json:"validation_path"`
+
+	// MustStaple indicates that the certificate carries the TLS Feature
+	// extension requiring the service to staple an OCSP response
+	// (must-staple).
+	MustStaple bool `json:"must_staple"`
+
+	// Revocation is the revocation status of the certificate.
+	Revocation CertificateRevocation `json:"revocation"`
+
+	// Renewal is the expected renewal of a leaf certificate as determined by
+	// the renewal policy.
+	Renewal CertificateRenewal `json:"renewal"`
+
+	// Issues is the list of certificate chain issues attributed to this
+	// certificate along with the evidence for each.
+	Issues []CertificateIssue `json:"issues"`
+
+	// Lints is the list of failed conformance checks for this certificate
+	// using the lint profile recorded for the payload. Lint results do not
+	// affect the service state.
+	Lints []CertificateLint `json:"lints"`
+}
+
+// CertificateRenewal is the expected renewal of a leaf certificate as
+// determined by the renewal policy.
+type CertificateRenewal struct {
+	// Evaluated indicates whether a renewal rule applied to the
+	// certificate. Only leaf certificates are evaluated and only if a
+	// renewal policy was specified.
+	Evaluated bool `json:"evaluated"`
+
+	// Rule is the issuer value of the renewal rule applied to the
+	// certificate or `default` if the default rule was used.
+	Rule string `json:"rule"`
+
+	// LifetimeFraction is the fraction of the validity period after which
+	// the certificate is expected to be renewed or zero if the rule uses a
+	// fixed amount of time before expiration.
+	LifetimeFraction float64 `json:"lifetime_fraction"`
+
+	// RenewBeforeHours is the fixed amount of time in hours before
+	// expiration at which the certificate is expected to be renewed or zero
+	// if the rule uses a fraction of the validity period.
+	RenewBeforeHours float64 `json:"renew_before_hours"`
+
+	// ExpectedOn is a RFC3389 time value for when the certificate is
+	// expected to be renewed.
+	ExpectedOn time.Time `json:"expected_on"`
+
+	// DaysUntilExpected is the number of days until the certificate is
+	// expected to be renewed in two digit decimal precision. This value is
+	// negative once the expected renewal point has passed.
+	DaysUntilExpected float64 `json:"days_until_expected"`
+
+	// Overdue indicates that the certificate has passed the expected
+	// renewal point (allowing for the grace period of the rule).
+	Overdue bool `json:"overdue"`
+}
+
+// ValidityCompliance is the evaluation of the validity period of a leaf
+// certificate against the CA/Browser Forum Baseline Requirements schedule of
+// maximum validity periods (including the SC-081 reductions). These limits
+// apply to publicly trusted certificates; see the `public_ca` lint profile
+// for the related lints.
+type ValidityCompliance struct {
+	// Evaluated indicates whether the certificate was evaluated. Only leaf
+	// certificates are evaluated.
+	Evaluated bool `json:"evaluated"`
+
+	// MaxValidityDays is the maximum validity period in days in force when
+	// the certificate was issued or zero if no maximum applied.
+	MaxValidityDays int `json:"max_validity_days"`
+
+	// MaxValidityEffectiveOn is the date from which the maximum validity
+	// period in force when the certificate was issued applies.
+	MaxValidityEffectiveOn time.Time `json:"max_validity_effective_on"`
+
+	// Compliant indicates whether the validity period of the certificate
+	// does not exceed the maximum in force when it was issued.
+	Compliant bool `json:"compliant"`
+
+	// NonCompliantRenewalOn is the date of the first scheduled reduction
+	// after the certificate was issued which its validity period exceeds.
+	// Renewals issued from this date using the same validity period are not
+	// compliant. This value is the zero value if the validity period
+	// complies with all scheduled reductions.
+	NonCompliantRenewalOn time.Time `json:"non_compliant_renewal_on"`
+
+	// NonCompliantRenewalMaxDays is the maximum validity period in days
+	// from NonCompliantRenewalOn or zero if not applicable.
+	NonCompliantRenewalMaxDays int `json:"non_compliant_renewal_max_days"`
+}
+
+// Threshold is the point before expiration at which a certificate enters a
+// service state. The certificate enters the state once any non-zero value is
+// reached.
+type Threshold struct {
+	// Days is the number of days remaining before expiration.
+	Days int `json:"days"`
+
+	// Hours is the number of hours remaining before expiration.
+	Hours int `json:"hours"`
+
+	// LifetimePercent is the percentage of the validity period remaining
+	// before expiration.
+	LifetimePercent float64 `json:"lifetime_remaining_percent"`
+}
+
+// ThresholdSet is the pair of expiration thresholds applied to certificates
+// of a specific role.
+type ThresholdSet struct {
+	// Warning is the threshold at which a certificate is considered to be
+	// expiring and in a WARNING state.
+	Warning Threshold `json:"warning"`
+
+	// Critical is the threshold at which a certificate is considered to be
+	// expiring and in a CRITICAL state.
+	Critical Threshold `json:"critical"`
+}
+
+// ExpirationThresholds is the set of expiration thresholds applied to each
+// certificate role.
+type ExpirationThresholds struct {
+	// Leaf is the set of thresholds applied to leaf certificates.
+	Leaf ThresholdSet `json:"leaf"`
+
+	// Intermediate is the set of thresholds applied to intermediate
+	// certificates.
+	Intermediate ThresholdSet `json:"intermediate"`
+
+	// Root is the set of thresholds applied to root certificates.
+	Root ThresholdSet `json:"root"`
+}
+
+// CertificateIssue is a certificate chain issue attributed to a specific
+// certificate in the chain.
+type CertificateIssue struct {
+	// Code is the stable identifier for the issue (e.g.,
+	// `CHAIN_MISORDERED_CERTS`).
+	Code string `json:"code"`
+
+	// Name is the name of the matching boolean certificate chain issue field
+	// (e.g., `misordered_certs`).
+	Name string `json:"name"`
+
+	// ChainIndexes is the list of chain positions (zero-based) for all
+	// certificates involved in the issue. The first entry is the position of
+	// this certificate.
+	ChainIndexes []int `json:"chain_indexes"`
+
+	// Evidence is a human readable explanation of why the issue was
+	// attributed to this certificate (e.g., "cert 1 issuer does not match
+	// cert 2 subject").
+	Evidence string `json:"evidence"`
+
+	// Ignored indicates that the issue matches an active suppression for
+	// this certificate and does not affect the confirmed certificate chain
+	// issues or the service state.
+	Ignored bool `json:"ignored"`
+
+	// IgnoredReason is the reason recorded for the suppression applied to
+	// an ignored issue.
+	IgnoredReason string `json:"ignored_reason"`
+
+	// IgnoredUntil is the point in time at which the suppression applied to
+	// an ignored issue no longer applies. This value is not set if the
+	// suppression does not expire.
+	IgnoredUntil time.Time `json:"ignored_until"`
+}
+
+// CertificateLint is a failed conformance check (lint) for a specific
+// certificate in the chain.
+type CertificateLint struct {
+	// ID is the stable identifier for the lint (e.g.,
+	// `CERT_SERIAL_TOO_LONG`).
+	ID string `json:"id"`
+
+	// Severity is the severity of the failed lint for the lint profile used
+	// (e.g., ERROR, WARNING, NOTICE).
+	Severity string `json:"severity"`
+
+	// Description is a short description of the problem detected by the
+	// lint.
+	Description string `json:"description"`
+
+	// Citation is the requirement checked by the lint (e.g., `RFC 5280,
+	// 4.1.2.2`).
+	Citation string `json:"citation"`
+
+	// Details is a human readable explanation of why the lint failed for
+	// this certificate.
+	Details string `json:"details"`
+}
+
+// Certificates is a collection of Certificate values from a single
+// certificate chain.
+type Certificates []Certificate
+
+// CertificateChainIssues is an aggregated collection of problems detected for
+// the certificate chain.
+type CertificateChainIssues struct {
+	// MissingIntermediateCerts indicates that intermediate certificates are
+	// missing from the certificate chain.
+	MissingIntermediateCerts bool `json:"missing_intermediate_certs"`
+
+	// MissingSANsEntries indicates that SANs entries are missing from a leaf
+	// certificate within the certificates chain.
+	MissingSANsEntries bool `json:"missing_sans_entries"`
+
+	// DuplicateCerts indicates that there are one or more duplicate copies of
+	// a certificate in the certificate chain.
+	DuplicateCerts bool `json:"duplicate_certs"`
+
+	// MisorderedCerts indicates that certificates in the chain are out of the
+	// expected order.
+	//
+	// E.g., instead of leaf, intermediate(s), root (technically not best
+	// practice) the chain has something like leaf, root, intermediate(s) or
+	// intermediates and then leaf.
+	MisorderedCerts bool `json:"misordered_certs"`
+
+	// ExpiredCerts indicates that there are one or more expired certificates
+	// in the certificate chain.
+	ExpiredCerts bool `json:"expired_certs"`
+
+	// HostnameMismatch indicates that the name or IP Address used to
+	// establish a connection to a certificate-enabled service does not match
+	// the list of valid host names honored by the leaf certificate.
+	//
+	// Historically the Common Name (CN) field was searched in addition to the
+	// Subject Alternate Names (SANs) field for a match, but this practice is
+	// deprecated and many clients (e.g., web browsers) no longer support
+	// this.
+	HostnameMismatch bool `json:"hostname_mismatch"`
+
+	// SelfSignedLeafCert indicates that the leaf certificate is self-signed.
+	// This is fairly common for development/test environments but is not best
+	// practice for certificates used outside of temporary / lab environments.
+	SelfSignedLeafCert bool `json:"self_signed_leaf_cert"`
+
+	// WeakSignatureAlgorithm indicates that the certificate chain has been
+	// signed using a cryptographically weak hashing algorithm (e.g. MD2, MD4,
+	// MD5, or SHA1). These signature algorithms are known to be vulnerable to
+	// collision attacks. An attacker can exploit this to generate another
+	// certificate with the same digital signature, allowing an attacker to
+	// masquerade as the affected service.
+	//
+	// NOTE: This does not apply to trusted root certificates; TLS clients
+	// trust them by their identity instead of the signature of their hash;
+	// client code setting this field would need to exclude root certificates
+	// from the determination whether the chain is vulnerable to weak
+	// signature algorithms.
+	//
+	//   - https://security.googleblog.com/2014/09/gradually-sunsetting-sha-1.html
+	//   - https://security.googleblog.com/2015/12/an-update-on-sha-1-certificates-in.html
+	//   - https://superuser.com/questions/1122069/why-are-root-cas-with-sha1-signatures-not-a-risk
+	//   - https://developer.mozilla.org/en-US/docs/Web/Security/Weak_Signature_Algorithm
+	//   - https://www.tenable.com/plugins/nessus/35291
+	//   - https://docs.ostorlab.co/kb/WEAK_HASHING_ALGO/index.html
+	WeakSignatureAlgorithm bool `json:"weak_signature_algorithm"`
+
+	// NotYetValidCerts indicates that there are one or more certificates in
+	// the certificate chain which are not yet valid (i.e., the NotBefore
+	// value is in the future). This is commonly caused by pre-dated issuance
+	// or an incorrect system clock.
+	NotYetValidCerts bool `json:"not_yet_valid_certs"`
+
+	// LeafOutlivesIssuer indicates that a leaf certificate expires after the
+	// certificate which issued it. The certificate chain stops working when
+	// the issuing certificate expires regardless of the leaf certificate
+	// expiration.
+	LeafOutlivesIssuer bool `json:"leaf_outlives_issuer"`
+
+	// UntrustedChain indicates that trust verification was requested and
+	// the certificate chain could not be verified to a trusted root
+	// certificate. See the trust verification section of the payload for
+	// details.
+	UntrustedChain bool `json:"untrusted_chain"`
+
+	// RevokedCerts indicates that there are one or more revoked
+	// certificates in the certificate chain per the provided revocation
+	// information.
+	RevokedCerts bool `json:"revoked_certs"`
+
+	// MissingOCSPStaple indicates that the leaf certificate requires OCSP
+	// stapling (must-staple) but the service did not staple an OCSP
+	// response.
+	MissingOCSPStaple bool `json:"missing_ocsp_staple"`
+
+	// ExpiringCrossSign indicates that the served certificate chain reaches
+	// a trust anchor only through a cross-signed intermediate certificate
+	// and that the cross-sign is expiring or has expired. Trust verification
+	// must be requested for this issue to be evaluated.
+	ExpiringCrossSign bool `json:"expiring_cross_sign"`
+
+	// WeakKey indicates that a certificate in the chain has a subject public
+	// key which does not satisfy the key strength policy (e.g., RSA below
+	// 2048 bits, DSA, an unusual curve or a small RSA exponent). Unlike the
+	// WeakSignatureAlgorithm issue, root certificates are evaluated.
+	WeakKey bool `json:"weak_key"`
+
+	// ROCAVulnerableKey indicates that a certificate in the chain has a RSA
+	// key generated by the Infineon RSA Library which is vulnerable to
+	// factorization (ROCA, CVE-2017-15361).
+	ROCAVulnerableKey bool `json:"roca_vulnerable_key"`
+
+	// DebianWeakKey indicates that a certificate in the chain has a RSA key
+	// generated by the vulnerable Debian OpenSSL package (CVE-2008-0166).
+	DebianWeakKey bool `json:"debian_weak_key"`
+
+	// RenewalOverdue indicates that a leaf certificate in the chain has
+	// passed the point at which it was expected to be renewed by the
+	// renewal policy.
+	RenewalOverdue bool `json:"renewal_overdue"`
+
+	// SelfSignedIntermediateCerts indicates that an intermediate certificate
+	// in the chain is self-signed.
+	//
+	// NOTE: This is unlikely to occur in practice, so we're likely not going
+	// to keep this field.
+	//
+	// SelfSignedIntermediateCerts bool `json:"self_signed_intermediate_certs"`
+}
+
+// Issue is a detected certificate chain problem along with the details
+// needed to rank the problem and resolve it.
+type Issue struct {
+	// Code is the stable identifier for the issue (e.g.,
+	// `CHAIN_MISSING_INTERMEDIATE`).
+	Code string `json:"code"`
+
+	// Name is the name of the matching boolean certificate chain issue field
+	// (e.g., `missing_intermediate_certs`).
+	Name string `json:"name"`
+
+	// Severity is the severity of the issue (e.g., CRITICAL, WARNING, INFO).
+	Severity string `json:"severity"`
+
+	// Description is a short description of the issue.
+	Description string `json:"description"`
+
+	// Remediation is guidance for resolving the issue.
+	Remediation string `json:"remediation"`
+
+	// ChainIndexes is the list of chain positions (zero-based) for all
+	// certificates involved in the issue.
+	ChainIndexes []int `json:"chain_indexes"`
+
+	// Ignored indicates that all findings for the issue match an active
+	// suppression. An ignored issue is not reported by the boolean
+	// certificate chain issue fields and does not affect the service state.
+	Ignored bool `json:"ignored"`
+}
+
+// IssueFlag pairs the name of a certificate chain issue with a boolean value
+// indicating whether the issue was detected.
+type IssueFlag = issues.Flag
+
+// CertChainPayload is the "parent" data structure which represents the
+// information to be encoded as a payload and later decoded for use in
+// reporting (and other) tools.
+//
+// This data structure is (future design) intended to be generated by this
+// library and not directly by client code. Instead, client code is meant to
+// pass in data using the `InputData` (name subject to change) struct.
+type CertChainPayload struct {
+	// FormatVersion is the format version of the generated certificate
+	// metadata payload.
+	FormatVersion int `json:"format_version"`
+
+	// Errors is intended to represent a potential collection of errors
+	// encountered while retrieving a certificate chain from a service. Due to
+	// limitations in the JSON encoding/decoding process (exported fields are
+	// required and interfaces do not provide those), we cannot provide this
+	// collection as a collection of native Go errors.
+	//
+	// See also:
+	//
+	//   - https://stackoverflow.com/a/44990051/903870
+	//
+	Errors []string `json:"errors"`
+
+	// CertChainOriginal is the original certificate chain entries encoded in
+	// PEM format.
+	//
+	// Due to size constraints this field may not be populated if the user did
+	// not explicitly opt into bundling the full certificate chain.
+	CertChainOriginal []string `json:"cert_chain_original"`
+
+	// CertChainSubset is a customized subset of the original certificate
+	// chain metadata. This field should always be populated.
+	CertChainSubset []Certificate `json:"cert_chain_subset"`
+
+	// Server reflects the host value and resolved IP Address (which could be
+	// the same value) used to retrieve the certificate chain.
+	Server Server `json:"server"`
+
+	// A fully-qualified domain name or IP Address in the Subject Alternate
+	// Names (SANs) list for the leaf certificate.
+	//
+	// Depending on how the check_cert plugin was called this value may not be
+	// set (e.g., the `server` flag is sufficient if specifying a valid FQDN
+	// associated with the leaf certificate).
+	DNSName string `json:"dns_name"`
+
+	// TCPPort is the TCP port of the remote certificate-enabled service. This
+	// is usually 443 (HTTPS) or 636 (LDAPS).
+	TCPPort int `json:"tcp_port"`
+
+	// ChainStatus is the overall status of the certificate chain as
+	// determined from the status of each certificate in the chain.
+	ChainStatus CertificateChainStatus `json:"cert_chain_status"`
+
+	// EffectiveExpiration is the point at which the certificate chain as a
+	// whole stops working and the certificate which determines it.
+	EffectiveExpiration ChainExpiration `json:"cert_chain_effective_expiration"`
+
+	// ChainOrder is the presented order of the certificate chain along with
+	// the reconstructed canonical leaf to root order.
+	ChainOrder ChainOrder `json:"cert_chain_order"`
+
+	// ChainCompletion is the certificate chain completed using retrieved
+	// issuer certificates which were not served by the endpoint.
+	ChainCompletion ChainCompletion `json:"cert_chain_completion"`
+
+	// Issues is an aggregated collection of problems detected for the
+	// certificate chain. Issues for which all findings are ignored per a
+	// suppression are not reported here.
+	Issues CertificateChainIssues `json:"cert_chain_issues"`
+
+	// IssueDetails is the list of problems detected for the certificate
+	// chain. Each entry provides a stable issue code, severity, description
+	// and remediation guidance for a detected issue. Issues for which all
+	// findings are ignored per a suppression are listed and marked as
+	// ignored.
+	IssueDetails []Issue `json:"cert_chain_issue_details"`
+
+	// TrustVerification is the result of verifying the certificate chain
+	// against a trust store of root certificates.
+	TrustVerification TrustVerification `json:"cert_chain_trust_verification"`
+
+	// DebianWeakKeyCheck is the coverage of the check for RSA keys generated
+	// by the vulnerable Debian OpenSSL package. The debian_weak_key issue is
+	// not reliable unless the check status is performed.
+	DebianWeakKeyCheck DebianWeakKeyCheck `json:"cert_chain_debian_weak_key_check"`
+
+	// ExpirationThresholds is the effective set of expiration thresholds
+	// applied to each certificate role when evaluating whether a
+	// certificate is expiring.
+	ExpirationThresholds ExpirationThresholds `json:"cert_expiration_thresholds"`
+
+	// LintProfile is the lint profile (e.g., `public_ca`) used to check each
+	// certificate in the chain for conformance issues.
+	LintProfile string `json:"lint_profile"`
+
+	// ServiceState is the monitoring system's evaluated state for the service
+	// check performed against a given certificate chain (e.g., OK, CRITICAL,
+	// WARNING, UNKNOWN).
+	ServiceState string `json:"service_state"`
+}
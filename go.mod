@@ -7,4 +7,6 @@
 
 module github.com/atc0005/cert-payload
 
-go 1.19
+go 1.21
+
+require golang.org/x/crypto v0.33.0
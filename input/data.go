@@ -7,7 +7,385 @@
 
 package input
 
-import "crypto/x509"
+import (
+	"crypto/x509"
+	"time"
+)
+
+// Certificate chain issue names. These values match the JSON field names used
+// for the certificate chain issues section of a certificate metadata payload
+// and are used as keys when specifying the service state for an issue.
+const (
+	IssueMissingIntermediateCerts string = "missing_intermediate_certs"
+	IssueMissingSANsEntries       string = "missing_sans_entries"
+	IssueDuplicateCerts           string = "duplicate_certs"
+	IssueMisorderedCerts          string = "misordered_certs"
+	IssueExpiredCerts             string = "expired_certs"
+	IssueHostnameMismatch         string = "hostname_mismatch"
+	IssueSelfSignedLeafCert       string = "self_signed_leaf_cert"
+	IssueWeakSignatureAlgorithm   string = "weak_signature_algorithm"
+	IssueNotYetValidCerts         string = "not_yet_valid_certs"
+	IssueLeafOutlivesIssuer       string = "leaf_outlives_issuer"
+	IssueUntrustedChain           string = "untrusted_chain"
+	IssueRevokedCerts             string = "revoked_certs"
+	IssueMissingOCSPStaple        string = "missing_ocsp_staple"
+	IssueExpiringCrossSign        string = "expiring_cross_sign"
+	IssueWeakKey                  string = "weak_key"
+	IssueROCAVulnerableKey        string = "roca_vulnerable_key"
+	IssueDebianWeakKey            string = "debian_weak_key"
+	IssueRenewalOverdue           string = "renewal_overdue"
+)
+
+// Revocation status values. These values are used for the revocation
+// details recorded for each certificate in a certificate metadata payload.
+const (
+	// RevocationStatusNotChecked indicates that revocation information was
+	// not available for a certificate.
+	RevocationStatusNotChecked string = "not_checked"
+
+	// RevocationStatusGood indicates that the certificate is not revoked.
+	RevocationStatusGood string = "good"
+
+	// RevocationStatusRevoked indicates that the certificate is revoked.
+	RevocationStatusRevoked string = "revoked"
+
+	// RevocationStatusUnknown indicates that revocation information was
+	// available for a certificate but a determination could not be made
+	// (e.g., the revocation information could not be verified or is stale).
+	RevocationStatusUnknown string = "unknown"
+)
+
+// Trust verification status values. These values are used for the trust
+// verification section of a certificate metadata payload.
+const (
+	// TrustStatusNotPerformed indicates that trust verification was not
+	// requested.
+	TrustStatusNotPerformed string = "not_performed"
+
+	// TrustStatusVerified indicates that the certificate chain was verified
+	// to a trusted root certificate.
+	TrustStatusVerified string = "verified"
+
+	// TrustStatusUntrustedRoot indicates that the certificate chain ends
+	// with a root certificate which is not present in the trust store.
+	TrustStatusUntrustedRoot string = "untrusted_root"
+
+	// TrustStatusUnknownAuthority indicates that the issuer of a certificate
+	// in the chain could not be found in the chain or the trust store.
+	TrustStatusUnknownAuthority string = "unknown_authority"
+
+	// TrustStatusInvalid indicates that a path to a trusted root certificate
+	// was found, but a certificate in the path is invalid (e.g., expired or
+	// not valid for use as a CA).
+	TrustStatusInvalid string = "invalid"
+)
+
+// Debian weak key check status values. These values are used for the Debian
+// weak key check section of a certificate metadata payload.
+const (
+	// DebianWeakKeyCheckIncomplete indicates that only the embedded Debian
+	// weak key blocklist was used. The embedded blocklist is a small sample
+	// of the vulnerable keys; a weak key which is not in the sample is not
+	// detected.
+	DebianWeakKeyCheckIncomplete string = "incomplete"
+
+	// DebianWeakKeyCheckPerformed indicates that the caller provided Debian
+	// weak key blocklist files were used in addition to the embedded
+	// blocklist.
+	DebianWeakKeyCheckPerformed string = "performed"
+)
+
+// Certificate lint profiles. A lint profile selects the conformance checks
+// performed for each certificate in the chain along with the severity of
+// each check.
+const (
+	// LintProfileInternalPKI applies the RFC 5280 conformance checks with
+	// relaxed severities suitable for certificates issued by an internal
+	// PKI. This is the default profile.
+	LintProfileInternalPKI string = "internal_pki"
+
+	// LintProfilePublicCA applies the RFC 5280 conformance checks along
+	// with the CA/Browser Forum Baseline Requirements checks using the
+	// strict severities expected of publicly trusted certificates.
+	LintProfilePublicCA string = "public_ca"
+
+	// LintProfileDisabled disables certificate linting.
+	LintProfileDisabled string = "disabled"
+)
+
+// ServiceStateMode indicates how the ServiceState value for a certificate
+// metadata payload is determined.
+type ServiceStateMode int
+
+const (
+	// ServiceStateCallerProvided indicates that the ServiceState value
+	// provided by the caller is used as-is. This is the default behavior.
+	ServiceStateCallerProvided ServiceStateMode = iota
+
+	// ServiceStateEvaluated indicates that the ServiceState value is
+	// evaluated by this library from the certificate chain, the expiration
+	// thresholds and detected certificate chain issues. Any caller provided
+	// ServiceState value is replaced; if it differs from the evaluated state
+	// the mismatch is recorded in the payload errors collection.
+	ServiceStateEvaluated
+
+	// ServiceStateVerified indicates that the ServiceState value provided by
+	// the caller is retained but is compared against the state evaluated by
+	// this library. A mismatch is recorded in the payload errors collection.
+	ServiceStateVerified
+)
+
+// TrustStore is the collection of sources for the trusted root certificates
+// used to verify a certificate chain. Trust verification is performed only
+// if at least one source is specified. Sources are combined into a single
+// pool of trusted root certificates.
+type TrustStore struct {
+	// SystemRoots indicates that the root certificates trusted by the
+	// operating system should be used.
+	SystemRoots bool
+
+	// CABundleFiles is the list of paths to PEM encoded CA bundle files
+	// containing trusted root certificates. This allows verifying a
+	// certificate chain offline or against an internal PKI.
+	CABundleFiles []string
+
+	// CABundlePEM is a PEM encoded CA bundle containing trusted root
+	// certificates.
+	CABundlePEM []byte
+}
+
+// Enabled indicates whether trust verification has been requested.
+func (ts TrustStore) Enabled() bool {
+	return ts.SystemRoots || len(ts.CABundleFiles) > 0 || len(ts.CABundlePEM) > 0
+}
+
+// Default public key policy thresholds used when a KeyPolicy value is not
+// specified.
+const (
+	// DefaultMinRSAKeyBits is the default minimum RSA key size in bits.
+	DefaultMinRSAKeyBits int = 2048
+
+	// DefaultMinRSAExponent is the default minimum RSA public exponent.
+	DefaultMinRSAExponent int = 65537
+)
+
+// DefaultAllowedCurves returns the default list of elliptic curves
+// considered acceptable for ECDSA public keys.
+func DefaultAllowedCurves() []string {
+	return []string{"P-256", "P-384", "P-521"}
+}
+
+// KeyPolicy is the policy used to evaluate the strength of the subject
+// public key of each certificate in the chain. Zero values use the default
+// thresholds.
+type KeyPolicy struct {
+	// MinRSAKeyBits is the minimum acceptable RSA key size in bits. If not
+	// specified, DefaultMinRSAKeyBits is used.
+	MinRSAKeyBits int
+
+	// MinRSAExponent is the minimum acceptable RSA public exponent. If not
+	// specified, DefaultMinRSAExponent is used.
+	MinRSAExponent int
+
+	// AllowedCurves is the list of elliptic curves (e.g., P-256) considered
+	// acceptable for ECDSA public keys. If not specified,
+	// DefaultAllowedCurves is used.
+	AllowedCurves []string
+
+	// AllowDSA indicates that DSA public keys are considered acceptable.
+	// DSA keys are considered weak by default.
+	AllowDSA bool
+
+	// DebianBlocklistFiles is an optional list of paths to Debian weak key
+	// blocklist files (e.g., the blacklist.RSA-2048 file provided by the
+	// openssl-blacklist package) used in addition to the embedded blocklist
+	// when checking for keys generated by the vulnerable Debian OpenSSL
+	// package (CVE-2008-0166). The embedded blocklist is only a sample of
+	// the vulnerable keys; the check is reported as incomplete unless these
+	// files are provided.
+	DebianBlocklistFiles []string
+}
+
+// WithDefaults returns a copy of the policy with default thresholds applied
+// for any values which are not specified.
+func (kp KeyPolicy) WithDefaults() KeyPolicy {
+	if kp.MinRSAKeyBits <= 0 {
+		kp.MinRSAKeyBits = DefaultMinRSAKeyBits
+	}
+
+	if kp.MinRSAExponent <= 0 {
+		kp.MinRSAExponent = DefaultMinRSAExponent
+	}
+
+	if len(kp.AllowedCurves) == 0 {
+		kp.AllowedCurves = DefaultAllowedCurves()
+	}
+
+	return kp
+}
+
+// ExpirationThreshold is the point before expiration at which a certificate
+// enters a service state (e.g., WARNING). Each specified value is evaluated
+// and the certificate enters the state once any of them is reached. Zero
+// values are not evaluated.
+type ExpirationThreshold struct {
+	// Days is the number of days remaining before expiration.
+	Days int
+
+	// Hours is the number of hours remaining before expiration. This is
+	// intended for short-lived certificates.
+	Hours int
+
+	// LifetimePercent is the percentage of the validity period remaining
+	// before expiration (e.g., 33 for a 90 day certificate enters the state
+	// with roughly 30 days remaining).
+	LifetimePercent float64
+}
+
+// ExpirationThresholds is the set of expiration thresholds applied to
+// certificates of a specific role (leaf, intermediate or root).
+type ExpirationThresholds struct {
+	// Warning is the threshold at which a certificate is considered to be
+	// expiring and in a WARNING state.
+	Warning ExpirationThreshold
+
+	// Critical is the threshold at which a certificate is considered to be
+	// expiring and in a CRITICAL state.
+	Critical ExpirationThreshold
+}
+
+// Enabled indicates whether any threshold value has been specified.
+func (et ExpirationThresholds) Enabled() bool {
+	return et != ExpirationThresholds{}
+}
+
+// DefaultRenewalLifetimeFraction is the default fraction of the validity
+// period of a leaf certificate after which the certificate is expected to be
+// renewed. This matches the renewal point commonly used by ACME clients
+// (e.g., 30 days before expiration for a 90 day certificate).
+const DefaultRenewalLifetimeFraction float64 = 2.0 / 3.0
+
+// RenewalRule describes when leaf certificates are expected to be renewed.
+type RenewalRule struct {
+	// Issuer is the issuer of the leaf certificates the rule applies to.
+	// The value is compared case-insensitively against the full issuer
+	// name, the issuer Common Name and each issuer Organization value
+	// (e.g., "Let's Encrypt"). This value is ignored for the default rule.
+	Issuer string
+
+	// LifetimeFraction is the fraction of the validity period after which a
+	// certificate is expected to be renewed (e.g., 2.0/3.0). If neither
+	// this value nor RenewBefore is specified,
+	// DefaultRenewalLifetimeFraction is used.
+	LifetimeFraction float64
+
+	// RenewBefore is the fixed amount of time before expiration at which a
+	// certificate is expected to be renewed (e.g., 30 days for a
+	// certificate renewed manually). If specified, this value is used
+	// instead of LifetimeFraction.
+	RenewBefore time.Duration
+
+	// GracePeriod is the amount of time after the expected renewal point
+	// before renewal is considered to be overdue. This allows for renewal
+	// jobs which run on a schedule (e.g., weekly).
+	GracePeriod time.Duration
+}
+
+// RenewalPolicy is the policy used to determine when each leaf certificate
+// in the chain is expected to be renewed. Renewal is evaluated only if the
+// policy is enabled.
+type RenewalPolicy struct {
+	// Rules is the list of per-issuer renewal rules. The first rule matching
+	// the issuer of a leaf certificate is used.
+	Rules []RenewalRule
+
+	// Default is the renewal rule used for leaf certificates which do not
+	// match an entry in Rules.
+	Default RenewalRule
+
+	// UseDefault indicates that the Default rule is applied to leaf
+	// certificates which do not match an entry in Rules. If false, these
+	// certificates are not evaluated.
+	UseDefault bool
+}
+
+// Enabled indicates whether renewal evaluation has been requested.
+func (rp RenewalPolicy) Enabled() bool {
+	return len(rp.Rules) > 0 || rp.UseDefault
+}
+
+// Certificate roles. These values are used to limit a suppression to
+// certificates in a specific position of the certificate chain.
+const (
+	CertRoleLeaf         string = "leaf"
+	CertRoleIntermediate string = "intermediate"
+	CertRoleRoot         string = "root"
+)
+
+// Suppression describes known and accepted certificate chain findings (e.g.,
+// an expiring intermediate certificate which is scheduled for replacement).
+// Suppressed findings remain visible in a certificate metadata payload but
+// are marked as ignored and do not affect the confirmed certificate chain
+// issues or the evaluated service state.
+//
+// A suppression applies to a finding only if all specified criteria match.
+type Suppression struct {
+	// Issues is the list of certificate chain issue names (e.g.,
+	// IssueHostnameMismatch) to ignore.
+	Issues []string
+
+	// IgnoreExpiration indicates that the expiration (expiring or expired)
+	// of matching certificates is ignored. This includes any expired
+	// certificates issue findings attributed to matching certificates.
+	IgnoreExpiration bool
+
+	// Roles limits the suppression to certificates with the given roles
+	// (e.g., CertRoleIntermediate). If not specified, certificates of any
+	// role match.
+	Roles []string
+
+	// Hosts limits the suppression to certificate chains evaluated for the
+	// given host names or IP addresses. Values are compared
+	// case-insensitively against the server host value, server IP address
+	// and DNS name. If not specified, any host matches.
+	Hosts []string
+
+	// SerialNumbers limits the suppression to certificates with the given
+	// serial numbers. Values are compared case-insensitively and colon
+	// delimiters are optional (e.g., "04:A1:B2" or "04a1b2"). If not
+	// specified, certificates with any serial number match.
+	//
+	// A finding attributed to multiple certificates (e.g., misordered
+	// certificates) is only suppressed if all of the certificates match the
+	// given roles and serial numbers.
+	SerialNumbers []string
+
+	// Expires is the optional point in time at which the suppression no
+	// longer applies. If not specified, the suppression does not expire.
+	Expires time.Time
+
+	// Reason is the explanation for the suppression (e.g., a change ticket
+	// reference) recorded for each ignored finding.
+	Reason string
+}
+
+// Active indicates whether the suppression applies at the given point in
+// time.
+func (s Suppression) Active(now time.Time) bool {
+	return s.Expires.IsZero() || now.Before(s.Expires)
+}
+
+// FetchedCert is an issuer certificate which was not served with the
+// certificate chain and was instead retrieved from elsewhere (e.g., the AIA
+// CA Issuers URL of the certificate it issued).
+type FetchedCert struct {
+	// Cert is the retrieved certificate.
+	Cert *x509.Certificate
+
+	// Source is the location the certificate was retrieved from (e.g., the
+	// AIA CA Issuers URL).
+	Source string
+}
 
 // Server reflects the host value and resolved IP Address (which could be
 // the same value) used to retrieve the certificate chain.
@@ -55,6 +433,31 @@ type Values struct {
 	// to be expiring and in a CRITICAL state.
 	ExpirationAgeInDaysCriticalThreshold int
 
+	// LeafExpirationThresholds is the optional set of expiration thresholds
+	// applied to leaf certificates. If not specified, the
+	// ExpirationAgeInDaysWarningThreshold and
+	// ExpirationAgeInDaysCriticalThreshold values are used.
+	LeafExpirationThresholds ExpirationThresholds
+
+	// IntermediateExpirationThresholds is the optional set of expiration
+	// thresholds applied to intermediate certificates. If not specified, the
+	// ExpirationAgeInDaysWarningThreshold and
+	// ExpirationAgeInDaysCriticalThreshold values are used.
+	IntermediateExpirationThresholds ExpirationThresholds
+
+	// RootExpirationThresholds is the optional set of expiration thresholds
+	// applied to root certificates. If not specified, the
+	// ExpirationAgeInDaysWarningThreshold and
+	// ExpirationAgeInDaysCriticalThreshold values are used.
+	RootExpirationThresholds ExpirationThresholds
+
+	// ClockSkewTolerance is the amount of time that a certificate NotBefore
+	// value may be in the future before the certificate is considered not
+	// yet valid. This allows for minor differences between the clock of the
+	// issuing system and the system evaluating the certificate chain. The
+	// default is no tolerance.
+	ClockSkewTolerance time.Duration
+
 	// Server is the host value (FQDN or IP Address) and resolved IP Address
 	// which was used to retrieve the certificate chain.
 	Server Server
@@ -76,4 +479,86 @@ type Values struct {
 	// check performed against a given certificate chain (e.g., OK, CRITICAL,
 	// WARNING, UNKNOWN).
 	ServiceState string
+
+	// ServiceStateMode indicates whether the ServiceState value is used
+	// as-is, replaced by the state evaluated by this library or compared
+	// against the evaluated state.
+	ServiceStateMode ServiceStateMode
+
+	// IssueStates is an optional mapping of certificate chain issue name
+	// (e.g., IssueExpiredCerts) to the service state (e.g., "WARNING") used
+	// when evaluating the service state for a certificate chain with that
+	// issue. Issues not listed use a default service state. Mapping an issue
+	// to "OK" excludes the issue from service state evaluation.
+	//
+	// Each service state must be one of "OK", "WARNING", "CRITICAL" or
+	// "UNKNOWN" (case-sensitive); encoding or evaluating the service state
+	// fails otherwise.
+	IssueStates map[string]string
+
+	// TrustStore is the optional collection of trusted root certificate
+	// sources used to verify the certificate chain. The intermediate
+	// certificates used for verification are taken from the certificate
+	// chain.
+	TrustStore TrustStore
+
+	// CRLs is an optional collection of DER or PEM encoded Certificate
+	// Revocation Lists used to evaluate the revocation status of each
+	// certificate in the chain. A PEM encoded entry may contain multiple
+	// CRLs. A CRL which cannot be parsed does not prevent evaluation; an
+	// unknown revocation status is recorded instead.
+	CRLs [][]byte
+
+	// CRLFiles is an optional list of paths to DER or PEM encoded
+	// Certificate Revocation List files used to evaluate the revocation
+	// status of each certificate in the chain. A file which cannot be read
+	// or parsed does not prevent evaluation; an unknown revocation status is
+	// recorded instead.
+	CRLFiles []string
+
+	// OCSPStaple is the optional DER encoded OCSP response stapled to the
+	// TLS handshake by the service for the leaf certificate (i.e., the
+	// first certificate in the chain).
+	//
+	// If the chain was retrieved from a service (i.e., Server.HostValue is
+	// set) and the leaf certificate requires OCSP stapling (must-staple), a
+	// missing staple is reported as a certificate chain issue.
+	OCSPStaple []byte
+
+	// OCSPResponses is an optional collection of DER encoded OCSP responses
+	// (e.g., fetched by the caller) used to evaluate the revocation status
+	// of each certificate in the chain. Responses are matched to
+	// certificates using the certificate identifiers in each response.
+	OCSPResponses [][]byte
+
+	// FetchedIssuers is an optional collection of issuer certificates which
+	// were not served with the certificate chain and were retrieved by the
+	// caller (e.g., by following the AIA CA Issuers URLs of the chain) in
+	// order to complete the chain. These certificates are reported
+	// separately from the certificate chain as not served by the endpoint
+	// and do not change the evaluation of the certificate chain.
+	FetchedIssuers []FetchedCert
+
+	// KeyPolicy is the policy used to evaluate the strength of the subject
+	// public key of each certificate in the chain. Certificates with a
+	// public key which does not satisfy the policy are reported as a weak
+	// key certificate chain issue.
+	KeyPolicy KeyPolicy
+
+	// LintProfile is the lint profile (e.g., LintProfilePublicCA) used to
+	// check each certificate in the chain for conformance issues. If not
+	// specified, LintProfileInternalPKI is used.
+	LintProfile string
+
+	// RenewalPolicy is the optional policy used to determine when each leaf
+	// certificate in the chain is expected to be renewed. Leaf certificates
+	// which have passed the expected renewal point are reported as a
+	// renewal overdue certificate chain issue.
+	RenewalPolicy RenewalPolicy
+
+	// Suppressions is the optional list of suppressions for known and
+	// accepted certificate chain findings (e.g., ignore expiring
+	// intermediate certificates for a specific host until a given date).
+	// The first active suppression matching a finding is applied.
+	Suppressions []Suppression
 }
@@ -0,0 +1,142 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs
+
+import (
+	"crypto/x509"
+	"sort"
+)
+
+// BuiltChain is a certificate chain reconstructed from an unordered pool of
+// certificates.
+type BuiltChain struct {
+	// Path is the list of pool positions (zero-based) for the reconstructed
+	// certificate chain, starting with the leaf certificate and ending with
+	// the root certificate if present.
+	Path []int
+
+	// Unused is the list of pool positions (zero-based) for certificates
+	// which are not part of the reconstructed certificate chain (e.g.,
+	// duplicate or unrelated certificates).
+	Unused []int
+
+	// Complete indicates whether the reconstructed certificate chain ends
+	// with a self-signed (root) certificate.
+	Complete bool
+}
+
+// BuildChain reconstructs the canonical leaf to root certificate chain from
+// the given unordered pool of certificates. Issuers are linked to the
+// certificates they issued using the raw issuer and subject names, the
+// Authority and Subject Key Identifiers (see IssuerLinked) and signature
+// verification. Certificates in the pool which are not part of the
+// reconstructed chain are listed as unused.
+//
+// If the pool contains more than one leaf certificate, the first leaf
+// certificate which did not issue another certificate in the pool is used.
+// If the pool does not contain a leaf certificate (e.g., an intermediates
+// bundle) the first certificate which did not issue another certificate in
+// the pool is used as the starting point.
+func BuildChain(pool []*x509.Certificate) BuiltChain {
+	var built BuiltChain
+
+	startIdx := bottomIndex(pool)
+	if startIdx == -1 {
+		return built
+	}
+
+	visited := make(map[int]bool, len(pool))
+
+	for idx := startIdx; idx != -1; {
+		visited[idx] = true
+		built.Path = append(built.Path, idx)
+
+		if isSelfSigned(pool[idx]) {
+			built.Complete = true
+			break
+		}
+
+		next := -1
+		for _, candidate := range issuerCandidates(pool[idx], pool) {
+			if !visited[candidate] {
+				next = candidate
+				break
+			}
+		}
+
+		idx = next
+	}
+
+	for idx := range pool {
+		if !visited[idx] {
+			built.Unused = append(built.Unused, idx)
+		}
+	}
+
+	return built
+}
+
+// bottomIndex is a helper function that returns the pool position of the
+// certificate used as the starting point when reconstructing a certificate
+// chain or -1 if the pool is empty.
+func bottomIndex(pool []*x509.Certificate) int {
+	if len(pool) == 0 {
+		return -1
+	}
+
+	issuers := make(map[int]bool, len(pool))
+	for _, cert := range pool {
+		for _, idx := range issuerCandidates(cert, pool) {
+			issuers[idx] = true
+		}
+	}
+
+	for idx, cert := range pool {
+		if issuers[idx] {
+			continue
+		}
+
+		switch ChainPosition(cert, pool) {
+		case CertChainPositionLeaf, CertChainPositionLeafSelfSigned:
+			return idx
+		}
+	}
+
+	for idx := range pool {
+		if !issuers[idx] {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+// issuerCandidates is a helper function that returns the pool positions of
+// all certificates which issued the given certificate, listing candidates
+// with the latest expiration first (e.g., the current version of a
+// cross-signed intermediate). A certificate is not considered to be its own
+// issuer.
+func issuerCandidates(cert *x509.Certificate, pool []*x509.Certificate) []int {
+	var candidates []int
+
+	for idx, candidate := range pool {
+		if candidate == cert || candidate.Equal(cert) {
+			continue
+		}
+
+		if verifySignature(cert, candidate) == nil {
+			candidates = append(candidates, idx)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return pool[candidates[i]].NotAfter.After(pool[candidates[j]].NotAfter)
+	})
+
+	return candidates
+}
@@ -41,6 +41,12 @@ const (
 	CertChainPositionIntermediate   string = "intermediate"
 	CertChainPositionRoot           string = "root"
 	CertChainPositionUnknown        string = "UNKNOWN cert chain position; please submit a bug report"
+
+	// CertChainPositionIntermediateCrossSigned is an intermediate
+	// certificate for a CA whose subject and public key are also certified by
+	// another issuer or by the CA itself (e.g., a root certificate
+	// cross-signed by an older root to extend compatibility).
+	CertChainPositionIntermediateCrossSigned string = "intermediate; cross-signed"
 )
 
 // Nagios plugin/service check state "labels". These values are used (where
@@ -57,9 +63,9 @@ const (
 // chain position to help determine the purpose of each v1 and v2 certificate.
 // This is because those certificate versions lack the more descriptive
 // "intention" fields (i.e., "extensions") of v3 certificates.
-func chainPositionV1V2Cert(cert *x509.Certificate, certChain []*x509.Certificate) string {
+func chainPositionV1V2Cert(cert *x509.Certificate, certChain []*x509.Certificate, selfSigned bool) string {
 	switch {
-	case isSelfSigned(cert):
+	case selfSigned:
 		if cert == certChain[0] {
 			return CertChainPositionLeafSelfSigned
 		}
@@ -78,9 +84,9 @@ func chainPositionV1V2Cert(cert *x509.Certificate, certChain []*x509.Certificate
 // chainPosV3CertKeyUsage evaluates the KeyUsage field for a certificate to
 // determine the chain position for a certificate; the KeyUsage field
 // identifies the set of actions that are valid for a given key.
-func chainPosV3CertKeyUsage(cert *x509.Certificate) string {
+func chainPosV3CertKeyUsage(cert *x509.Certificate, selfSigned bool) string {
 	switch {
-	case isSelfSigned(cert):
+	case selfSigned:
 		switch cert.KeyUsage {
 		case cert.KeyUsage | x509.KeyUsageCertSign | x509.KeyUsageCRLSign:
 			return CertChainPositionRoot
@@ -104,9 +110,7 @@ func chainPosV3CertKeyUsage(cert *x509.Certificate) string {
 
 // chainPositionV3Cert identifies the certificate chain position for a given
 // v3 cert.
-func chainPositionV3Cert(cert *x509.Certificate) string {
-	selfSigned := isSelfSigned(cert)
-
+func chainPositionV3Cert(cert *x509.Certificate, selfSigned bool) string {
 	// The CA boolean indicates whether the certified public key may be used
 	// to verify certificate signatures.
 	switch {
@@ -127,7 +131,7 @@ func chainPositionV3Cert(cert *x509.Certificate) string {
 		return CertChainPositionLeaf
 	}
 
-	return chainPosV3CertKeyUsage(cert)
+	return chainPosV3CertKeyUsage(cert, selfSigned)
 }
 
 // verifySignatureMD5WithRSA is a helper function that attempts to validate a
@@ -298,13 +302,21 @@ func verifySignatureECDSAWithSHA1(issuedCert *x509.Certificate, issuerCert *x509
 // chains are managed by sysadmins and already under their control the outcome
 // of this logic grants no more access than was already present.
 func verifySignature(issuedCert *x509.Certificate, issuerCert *x509.Certificate) error {
-	if issuedCert.Issuer.String() != issuerCert.Subject.String() {
+	if !IssuerLinked(issuedCert, issuerCert) {
 		return fmt.Errorf(
-			"issuer and subject X.509 distinguished name mismatch: %w",
+			"issuer name and key identifier mismatch: %w",
 			ErrSignatureVerificationFailed,
 		)
 	}
 
+	return checkSignature(issuedCert, issuerCert)
+}
+
+// checkSignature is a helper function used to verify the signature on
+// issuedCert using the public key of issuerCert without evaluating the
+// issuer and subject linkage of the certificates. See verifySignature for
+// details.
+func checkSignature(issuedCert *x509.Certificate, issuerCert *x509.Certificate) error {
 	// Regarding the specific order of issuer/issued certs in signature
 	// verification process:
 	//
@@ -363,7 +375,7 @@ func verifySignature(issuedCert *x509.Certificate, issuerCert *x509.Certificate)
 // with its own public key. Any errors encountered during signature validation
 // are assumed to be an indication that a certificate is not self-signed.
 func isSelfSigned(cert *x509.Certificate) bool {
-	if cert.Issuer.String() != cert.Subject.String() {
+	if !IssuerLinked(cert, cert) {
 		return false
 	}
 
@@ -382,13 +394,57 @@ func isSelfSigned(cert *x509.Certificate) bool {
 	}
 }
 
+// isSelfSignedLegacy behaves like isSelfSigned except that the issuer and
+// subject of the certificate are linked by comparing their readable
+// distinguished names. This matches the behavior of earlier releases relied
+// on by the stable payload format versions (see LegacyChainPosition).
+func isSelfSignedLegacy(cert *x509.Certificate) bool {
+	if cert.Issuer.String() != cert.Subject.String() {
+		return false
+	}
+
+	return checkSignature(cert, cert) == nil
+}
+
 // ChainPosition receives a cert and the cert chain that it belongs to and
 // returns a string indicating what position or "role" it occupies in the
 // certificate chain.
 //
+// Intermediate certificates which are cross-signed (see IsCrossSigned) are
+// reported as such. Other certificates in the given chain (e.g., trust
+// anchors appended after the presented certificates) are used to make this
+// determination.
+//
 // https://en.wikipedia.org/wiki/X.509
 // https://tools.ietf.org/html/rfc5280
 func ChainPosition(cert *x509.Certificate, certChain []*x509.Certificate) string {
+	chainPos := chainPosition(cert, certChain, isSelfSigned)
+
+	if chainPos == CertChainPositionIntermediate && IsCrossSigned(cert, certChain) {
+		return CertChainPositionIntermediateCrossSigned
+	}
+
+	return chainPos
+}
+
+// LegacyChainPosition behaves like ChainPosition except that self-signed
+// certificates are identified by comparing the readable issuer and subject
+// distinguished names (instead of using IssuerLinked) and cross-signed
+// intermediate certificates are reported as plain intermediate certificates.
+// This is intended for use by stable payload format versions whose output
+// predates these changes.
+func LegacyChainPosition(cert *x509.Certificate, certChain []*x509.Certificate) string {
+	return chainPosition(cert, certChain, isSelfSignedLegacy)
+}
+
+// chainPosition is a helper function used to determine the position of a
+// given certificate in the given certificate chain using the given function
+// to determine whether the certificate is self-signed.
+func chainPosition(
+	cert *x509.Certificate,
+	certChain []*x509.Certificate,
+	selfSigned func(*x509.Certificate) bool,
+) string {
 	// We require a valid certificate chain. Fail if not provided.
 	if certChain == nil {
 		return CertChainPositionUnknown
@@ -396,16 +452,24 @@ func ChainPosition(cert *x509.Certificate, certChain []*x509.Certificate) string
 
 	switch cert.Version {
 	case 1, 2:
-		return chainPositionV1V2Cert(cert, certChain)
+		return chainPositionV1V2Cert(cert, certChain, selfSigned(cert))
 
 	case 3:
-		return chainPositionV3Cert(cert)
+		return chainPositionV3Cert(cert, selfSigned(cert))
 	}
 
 	// no known match, so position unknown
 	return CertChainPositionUnknown
 }
 
+// IsIntermediatePosition indicates whether the given chain position value
+// (see ChainPosition) is for an intermediate certificate, cross-signed or
+// otherwise.
+func IsIntermediatePosition(chainPos string) bool {
+	return chainPos == CertChainPositionIntermediate ||
+		chainPos == CertChainPositionIntermediateCrossSigned
+}
+
 // MaxLifespanInDays returns the maximum lifespan in days for a given
 // certificate from the date it was issued until the time it is scheduled to
 // expire. This value is intentionally truncated (e.g., 1.5 days becomes 1
@@ -455,7 +519,7 @@ func NumIntermediateCerts(certChain []*x509.Certificate) int {
 	var num int
 	for _, cert := range certChain {
 		chainPos := ChainPosition(cert, certChain)
-		if chainPos == CertChainPositionIntermediate {
+		if IsIntermediatePosition(chainPos) {
 			num++
 		}
 	}
@@ -597,14 +661,29 @@ func FormattedExpiration(expireTime time.Time) string {
 // indicating the overall status at a glance. If requested, an expiring or
 // expired certificate is marked as ignored.
 func ExpirationStatus(cert *x509.Certificate, ageCritical time.Time, ageWarning time.Time, ignoreExpiration bool) string {
-	var expiresText string
-	certExpiration := cert.NotAfter
-
 	var lifeRemainingText string
 	if remaining, err := LifeRemainingPercentageTruncated(cert); err == nil {
 		lifeRemainingText = fmt.Sprintf(" (%d%%)", remaining)
 	}
 
+	return expirationStatus(cert, ageCritical, ageWarning, ignoreExpiration, lifeRemainingText)
+}
+
+// ExpirationStatusWithLifetime behaves like ExpirationStatus except that the
+// given percentage of remaining lifetime is reported instead of the value
+// calculated by LifeRemainingPercentageTruncated.
+func ExpirationStatusWithLifetime(cert *x509.Certificate, ageCritical time.Time, ageWarning time.Time, ignoreExpiration bool, lifeRemaining int) string {
+	lifeRemainingText := fmt.Sprintf(" (%d%%)", lifeRemaining)
+
+	return expirationStatus(cert, ageCritical, ageWarning, ignoreExpiration, lifeRemainingText)
+}
+
+// expirationStatus is a helper function used to generate the expiration
+// status text for ExpirationStatus and ExpirationStatusWithLifetime.
+func expirationStatus(cert *x509.Certificate, ageCritical time.Time, ageWarning time.Time, ignoreExpiration bool, lifeRemainingText string) string {
+	var expiresText string
+	certExpiration := cert.NotAfter
+
 	switch {
 	case certExpiration.Before(time.Now()) && ignoreExpiration:
 		expiresText = fmt.Sprintf(
@@ -740,6 +819,66 @@ func IsExpiredCert(cert *x509.Certificate) bool {
 	return cert.NotAfter.Before(time.Now())
 }
 
+// IsNotYetValidCert receives a x509 certificate, a clock skew tolerance and
+// the time of evaluation and returns a boolean value indicating whether the
+// cert is not yet valid (i.e., the NotBefore value is still in the future).
+// The tolerance allows for minor differences between the clock of the system
+// which issued the certificate and the system evaluating it.
+func IsNotYetValidCert(cert *x509.Certificate, skewTolerance time.Duration, now time.Time) bool {
+	return cert.NotBefore.After(now.Add(skewTolerance))
+}
+
+// ValidInDaysPrecise evaluates the given certificate and returns the number
+// of days until the certificate becomes valid as a floating point number
+// rounded down to two decimal places. If the certificate is already valid
+// zero is returned.
+//
+// An error is returned if the pointer to the given certificate is nil.
+func ValidInDaysPrecise(cert *x509.Certificate) (float64, error) {
+	if cert == nil {
+		return 0, fmt.Errorf(
+			"func ValidInDaysPrecise: unable to determine validity: %w",
+			ErrMissingValue,
+		)
+	}
+
+	timeUntilValid := time.Until(cert.NotBefore).Hours()
+	if timeUntilValid <= 0 {
+		return 0, nil
+	}
+
+	daysUntilValid := timeUntilValid / 24
+	daysUntilValid = math.Floor(daysUntilValid*100) / 100
+
+	return daysUntilValid, nil
+}
+
+// NotYetValidStatus receives a certificate which is not yet valid and
+// returns a human-readable string indicating when the certificate becomes
+// valid.
+func NotYetValidStatus(cert *x509.Certificate) string {
+	return fmt.Sprintf(
+		"[NOT YET VALID] valid from %s",
+		cert.NotBefore.UTC().Format(time.RFC3339),
+	)
+}
+
+// IsExpiringCert receives a x509 certificate, CRITICAL age threshold and
+// WARNING age threshold values and returns a boolean value indicating whether
+// the (not yet expired) cert is about to expire.
+func IsExpiringCert(cert *x509.Certificate, ageCritical time.Time, ageWarning time.Time) bool {
+	switch {
+	case IsExpiredCert(cert):
+		return false
+	case cert.NotAfter.Before(ageCritical):
+		return true
+	case cert.NotAfter.Before(ageWarning):
+		return true
+	default:
+		return false
+	}
+}
+
 // ExpiresInDays evaluates the given certificate and returns the number of
 // days until the certificate expires. If already expired, a negative number
 // is returned indicating how many days the certificate is past expiration.
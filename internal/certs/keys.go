@@ -0,0 +1,84 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs
+
+import (
+	"crypto/dsa" //nolint:staticcheck // used to report legacy DSA keys
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+)
+
+// Public key algorithm names for display and comparison purposes.
+const (
+	KeyAlgorithmRSA     string = "RSA"
+	KeyAlgorithmECDSA   string = "ECDSA"
+	KeyAlgorithmEd25519 string = "Ed25519"
+	KeyAlgorithmDSA     string = "DSA"
+	KeyAlgorithmUnknown string = "unknown"
+)
+
+// PublicKeyInfo describes the subject public key of a certificate.
+type PublicKeyInfo struct {
+	// Algorithm is the public key algorithm (e.g., RSA, ECDSA).
+	Algorithm string
+
+	// Bits is the size of the public key in bits (e.g., the RSA modulus
+	// size or the ECDSA curve size).
+	Bits int
+
+	// Curve is the name of the elliptic curve for an ECDSA public key
+	// (e.g., P-256).
+	Curve string
+
+	// RSAExponent is the public exponent for a RSA public key.
+	RSAExponent int
+}
+
+// KeyInfo returns details for the subject public key of the given
+// certificate. The algorithm is reported as unknown for a nil certificate or
+// a public key algorithm which is not supported.
+func KeyInfo(cert *x509.Certificate) PublicKeyInfo {
+	if cert == nil {
+		return PublicKeyInfo{Algorithm: KeyAlgorithmUnknown}
+	}
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return PublicKeyInfo{
+			Algorithm:   KeyAlgorithmRSA,
+			Bits:        pub.N.BitLen(),
+			RSAExponent: pub.E,
+		}
+
+	case *ecdsa.PublicKey:
+		params := pub.Curve.Params()
+
+		return PublicKeyInfo{
+			Algorithm: KeyAlgorithmECDSA,
+			Bits:      params.BitSize,
+			Curve:     params.Name,
+		}
+
+	case ed25519.PublicKey:
+		return PublicKeyInfo{
+			Algorithm: KeyAlgorithmEd25519,
+			Bits:      256,
+		}
+
+	case *dsa.PublicKey:
+		return PublicKeyInfo{
+			Algorithm: KeyAlgorithmDSA,
+			Bits:      pub.P.BitLen(),
+		}
+
+	default:
+		return PublicKeyInfo{Algorithm: KeyAlgorithmUnknown}
+	}
+}
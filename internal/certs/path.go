@@ -0,0 +1,192 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/atc0005/cert-payload/internal/textutils"
+)
+
+// IssuerIndex returns the position in the given certificate chain of the
+// certificate which issued the given certificate or -1 if the issuer is not
+// present in the chain. A self-signed certificate is not considered to be
+// its own issuer. If more than one issuer is present (e.g., a cross-signed
+// intermediate) the issuer preferred when reconstructing the chain is
+// returned.
+func IssuerIndex(cert *x509.Certificate, certChain []*x509.Certificate) int {
+	candidates := issuerCandidates(cert, certChain)
+	if len(candidates) == 0 {
+		return -1
+	}
+
+	return candidates[0]
+}
+
+// IssuerIndexes returns the positions in the given certificate chain of all
+// certificates which issued the given certificate, listing the issuer
+// preferred when reconstructing the chain first (e.g., for a cross-signed
+// intermediate). A self-signed certificate is not considered to be its own
+// issuer.
+func IssuerIndexes(cert *x509.Certificate, certChain []*x509.Certificate) []int {
+	return issuerCandidates(cert, certChain)
+}
+
+// FirstLeafIndex returns the position of the first leaf certificate in the
+// given certificate chain. If a leaf certificate is not present (e.g., an
+// intermediates bundle) the first position is returned. If the chain is
+// empty -1 is returned.
+func FirstLeafIndex(certChain []*x509.Certificate) int {
+	for idx, cert := range certChain {
+		switch ChainPosition(cert, certChain) {
+		case CertChainPositionLeaf, CertChainPositionLeafSelfSigned:
+			return idx
+		}
+	}
+
+	if len(certChain) == 0 {
+		return -1
+	}
+
+	return 0
+}
+
+// ValidationPath returns the positions of the certificates in the given
+// chain needed to validate the leaf certificate, starting with the leaf
+// certificate of the reconstructed chain (see BuildChain). Self-signed
+// (root) certificates are not included as clients validate against the copy
+// of a root certificate in their trust store instead of the copy provided
+// with the chain.
+func ValidationPath(certChain []*x509.Certificate) []int {
+	path := BuildChain(certChain).Path
+
+	if len(path) > 1 && isSelfSigned(certChain[path[len(path)-1]]) {
+		path = path[:len(path)-1]
+	}
+
+	return path
+}
+
+// EffectiveExpirationIndex returns the position of the certificate which
+// determines when the given certificate chain as a whole stops working.
+// This is the certificate with the earliest NotAfter value among the
+// certificates needed to validate the leaf certificate. If the chain is
+// empty -1 is returned.
+func EffectiveExpirationIndex(certChain []*x509.Certificate) int {
+	earliest := -1
+
+	for _, idx := range ValidationPath(certChain) {
+		if earliest == -1 || certChain[idx].NotAfter.Before(certChain[earliest].NotAfter) {
+			earliest = idx
+		}
+	}
+
+	return earliest
+}
+
+// IsSelfSigned asserts that the given certificate is self-signed by
+// validating its signature using its own public key.
+func IsSelfSigned(cert *x509.Certificate) bool {
+	return isSelfSigned(cert)
+}
+
+// IssuerLinked asserts that the issuer of issuedCert refers to issuerCert.
+// This does not verify the signature of issuedCert.
+//
+// The DER encoded issuer name of issuedCert must match the subject name of
+// issuerCert byte for byte. If both the Authority Key Identifier of
+// issuedCert and the Subject Key Identifier of issuerCert are present they
+// must also match; this distinguishes between issuers which share a subject
+// name but use a different key (e.g., a renewed CA certificate).
+func IssuerLinked(issuedCert *x509.Certificate, issuerCert *x509.Certificate) bool {
+	if !bytes.Equal(issuedCert.RawIssuer, issuerCert.RawSubject) {
+		return false
+	}
+
+	if len(issuedCert.AuthorityKeyId) > 0 && len(issuerCert.SubjectKeyId) > 0 {
+		return bytes.Equal(issuedCert.AuthorityKeyId, issuerCert.SubjectKeyId)
+	}
+
+	return true
+}
+
+// FormatKeyID formats the given key identifier (e.g., Subject Key
+// Identifier) in hex format with a colon inserted after each two digits. An
+// empty string is returned if the key identifier is not present.
+func FormatKeyID(keyID []byte) string {
+	if len(keyID) == 0 {
+		return ""
+	}
+
+	return textutils.InsertDelimiter(fmt.Sprintf("%X", keyID), ":", 2)
+}
+
+// IsCrossSigned asserts that the given certificate is a cross-signed CA
+// certificate; another certificate in the given pool certifies the same
+// subject and public key but was issued by a different issuer (e.g., a root
+// certificate and the copy of it cross-signed by an older root to support
+// clients which do not yet trust the newer root). Self-signed certificates
+// are not considered cross-signed.
+func IsCrossSigned(cert *x509.Certificate, pool []*x509.Certificate) bool {
+	if isSelfSigned(cert) {
+		return false
+	}
+
+	for _, other := range pool {
+		if other == nil || other == cert || other.Equal(cert) {
+			continue
+		}
+
+		if !bytes.Equal(other.RawSubject, cert.RawSubject) ||
+			!bytes.Equal(other.RawSubjectPublicKeyInfo, cert.RawSubjectPublicKeyInfo) {
+			continue
+		}
+
+		if !bytes.Equal(other.RawIssuer, cert.RawIssuer) ||
+			!bytes.Equal(other.AuthorityKeyId, cert.AuthorityKeyId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLeafRole indicates whether the given certificate acts as a leaf
+// (end-entity) certificate within the given certificate chain regardless of
+// its position in the chain.
+//
+// A certificate which issued another certificate in the chain is a CA
+// certificate (e.g., an intermediate certificate missing the basic
+// constraints extension). Otherwise the certificate is a leaf certificate if
+// ChainPosition reports it as such or if it is reported as an intermediate
+// certificate but cannot sign certificates (e.g., a leaf certificate
+// incorrectly asserting the basic constraints cA value).
+func IsLeafRole(cert *x509.Certificate, certChain []*x509.Certificate) bool {
+	for _, other := range certChain {
+		if other == nil || other == cert || other.Equal(cert) {
+			continue
+		}
+
+		if verifySignature(other, cert) == nil {
+			return false
+		}
+	}
+
+	switch chainPos := ChainPosition(cert, certChain); {
+	case chainPos == CertChainPositionLeaf, chainPos == CertChainPositionLeafSelfSigned:
+		return true
+
+	case IsIntermediatePosition(chainPos):
+		return cert.KeyUsage&x509.KeyUsageCertSign == 0
+
+	default:
+		return false
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs_test
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+func TestIssuerLinked(t *testing.T) {
+	issuerName := []byte("issuer name")
+	otherName := []byte("other name")
+	issuerKeyID := []byte{0x01, 0x02}
+	otherKeyID := []byte{0x03, 0x04}
+
+	tests := []struct {
+		name       string
+		issued     x509.Certificate
+		issuer     x509.Certificate
+		wantLinked bool
+	}{
+		{
+			name:       "names match without key identifiers",
+			issued:     x509.Certificate{RawIssuer: issuerName},
+			issuer:     x509.Certificate{RawSubject: issuerName},
+			wantLinked: true,
+		},
+		{
+			name:       "names and key identifiers match",
+			issued:     x509.Certificate{RawIssuer: issuerName, AuthorityKeyId: issuerKeyID},
+			issuer:     x509.Certificate{RawSubject: issuerName, SubjectKeyId: issuerKeyID},
+			wantLinked: true,
+		},
+		{
+			name:       "names match with only authority key identifier",
+			issued:     x509.Certificate{RawIssuer: issuerName, AuthorityKeyId: issuerKeyID},
+			issuer:     x509.Certificate{RawSubject: issuerName},
+			wantLinked: true,
+		},
+		{
+			name:   "names match with different key identifiers",
+			issued: x509.Certificate{RawIssuer: issuerName, AuthorityKeyId: issuerKeyID},
+			issuer: x509.Certificate{RawSubject: issuerName, SubjectKeyId: otherKeyID},
+		},
+		{
+			name:   "key identifiers match with different names",
+			issued: x509.Certificate{RawIssuer: issuerName, AuthorityKeyId: issuerKeyID},
+			issuer: x509.Certificate{RawSubject: otherName, SubjectKeyId: issuerKeyID},
+		},
+		{
+			name:   "names differ without key identifiers",
+			issued: x509.Certificate{RawIssuer: issuerName},
+			issuer: x509.Certificate{RawSubject: otherName},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certs.IssuerLinked(&tt.issued, &tt.issuer); got != tt.wantLinked {
+				t.Errorf("got linked %t, want %t", got, tt.wantLinked)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// ValidityLimit is a maximum validity period for subscriber (leaf)
+// certificates issued on or after a specific date per the CA/Browser Forum
+// Baseline Requirements (section 6.3.2).
+type ValidityLimit struct {
+	// EffectiveOn is the date from which certificates issued must comply
+	// with this limit.
+	EffectiveOn time.Time
+
+	// MaxDays is the maximum validity period in days. A day is measured as
+	// 86,400 seconds.
+	MaxDays int
+
+	// Reference is the requirement or ballot which introduced the limit.
+	Reference string
+}
+
+// ValidityLimits returns the schedule of maximum validity periods for
+// subscriber certificates in effective date order. Certificates issued
+// before the first entry are not subject to a limit.
+//
+// See also:
+//
+//   - https://cabforum.org/working-groups/server/baseline-requirements/requirements/
+//   - https://github.com/cabforum/servercert/pull/553
+func ValidityLimits() []ValidityLimit {
+	return []ValidityLimit{
+		{
+			EffectiveOn: time.Date(2012, time.July, 1, 0, 0, 0, 0, time.UTC),
+			MaxDays:     1825,
+			Reference:   "Baseline Requirements 1.0 (60 months)",
+		},
+		{
+			EffectiveOn: time.Date(2015, time.April, 1, 0, 0, 0, 0, time.UTC),
+			MaxDays:     1185,
+			Reference:   "Baseline Requirements 1.0 (39 months)",
+		},
+		{
+			EffectiveOn: time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC),
+			MaxDays:     825,
+			Reference:   "Ballot 193",
+		},
+		{
+			EffectiveOn: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC),
+			MaxDays:     398,
+			Reference:   "Ballot SC31",
+		},
+		{
+			EffectiveOn: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+			MaxDays:     200,
+			Reference:   "Ballot SC-081",
+		},
+		{
+			EffectiveOn: time.Date(2027, time.March, 15, 0, 0, 0, 0, time.UTC),
+			MaxDays:     100,
+			Reference:   "Ballot SC-081",
+		},
+		{
+			EffectiveOn: time.Date(2029, time.March, 15, 0, 0, 0, 0, time.UTC),
+			MaxDays:     47,
+			Reference:   "Ballot SC-081",
+		},
+	}
+}
+
+// ValidityLimitAt returns the maximum validity period in force for a
+// certificate issued at the given time. False is returned if no limit
+// applies.
+func ValidityLimitAt(issuedOn time.Time) (ValidityLimit, bool) {
+	var inForce ValidityLimit
+	var found bool
+
+	for _, limit := range ValidityLimits() {
+		if issuedOn.Before(limit.EffectiveOn) {
+			break
+		}
+
+		inForce = limit
+		found = true
+	}
+
+	return inForce, found
+}
+
+// ValidityPeriod returns the validity period of the given certificate as
+// defined by RFC 5280 (section 4.1.2.5); the period includes both the
+// NotBefore and NotAfter values and so is one second longer than the
+// difference between them.
+func ValidityPeriod(cert *x509.Certificate) time.Duration {
+	return cert.NotAfter.Sub(cert.NotBefore) + time.Second
+}
+
+// ExceedsValidityLimit indicates whether the given validity period is longer
+// than allowed by the given limit. Any amount of time greater than a whole
+// day counts as an additional day.
+func ExceedsValidityLimit(validityPeriod time.Duration, limit ValidityLimit) bool {
+	return validityPeriod > time.Duration(limit.MaxDays)*24*time.Hour
+}
+
+// NextExceededValidityLimit returns the first scheduled limit taking effect
+// after the given certificate was issued which its validity period exceeds.
+// This indicates when a renewal using the same validity period stops being
+// compliant. False is returned if the validity period complies with all
+// later limits.
+func NextExceededValidityLimit(cert *x509.Certificate) (ValidityLimit, bool) {
+	validityPeriod := ValidityPeriod(cert)
+
+	for _, limit := range ValidityLimits() {
+		if !limit.EffectiveOn.After(cert.NotBefore) {
+			continue
+		}
+
+		if ExceedsValidityLimit(validityPeriod, limit) {
+			return limit, true
+		}
+	}
+
+	return ValidityLimit{}, false
+}
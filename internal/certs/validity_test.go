@@ -0,0 +1,156 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package certs_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// date returns midnight UTC on the given date.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestValidityLimitAt(t *testing.T) {
+	tests := []struct {
+		name        string
+		issuedOn    time.Time
+		wantFound   bool
+		wantMaxDays int
+	}{
+		{
+			name:     "before first limit",
+			issuedOn: date(2012, time.July, 1).Add(-time.Second),
+		},
+		{name: "60 months", issuedOn: date(2012, time.July, 1), wantFound: true, wantMaxDays: 1825},
+		{name: "before 39 months", issuedOn: date(2015, time.April, 1).Add(-time.Second), wantFound: true, wantMaxDays: 1825},
+		{name: "39 months", issuedOn: date(2015, time.April, 1), wantFound: true, wantMaxDays: 1185},
+		{name: "before 825 days", issuedOn: date(2018, time.March, 1).Add(-time.Second), wantFound: true, wantMaxDays: 1185},
+		{name: "825 days", issuedOn: date(2018, time.March, 1), wantFound: true, wantMaxDays: 825},
+		{name: "before 398 days", issuedOn: date(2020, time.September, 1).Add(-time.Second), wantFound: true, wantMaxDays: 825},
+		{name: "398 days", issuedOn: date(2020, time.September, 1), wantFound: true, wantMaxDays: 398},
+		{name: "before 200 days", issuedOn: date(2026, time.March, 15).Add(-time.Second), wantFound: true, wantMaxDays: 398},
+		{name: "200 days", issuedOn: date(2026, time.March, 15), wantFound: true, wantMaxDays: 200},
+		{name: "before 100 days", issuedOn: date(2027, time.March, 15).Add(-time.Second), wantFound: true, wantMaxDays: 200},
+		{name: "100 days", issuedOn: date(2027, time.March, 15), wantFound: true, wantMaxDays: 100},
+		{name: "100 days a year later", issuedOn: date(2028, time.March, 15), wantFound: true, wantMaxDays: 100},
+		{name: "before 47 days", issuedOn: date(2029, time.March, 15).Add(-time.Second), wantFound: true, wantMaxDays: 100},
+		{name: "47 days", issuedOn: date(2029, time.March, 15), wantFound: true, wantMaxDays: 47},
+		{name: "after last limit", issuedOn: date(2035, time.January, 1), wantFound: true, wantMaxDays: 47},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, found := certs.ValidityLimitAt(tt.issuedOn)
+
+			if found != tt.wantFound {
+				t.Fatalf("got found %t, want %t", found, tt.wantFound)
+			}
+
+			if limit.MaxDays != tt.wantMaxDays {
+				t.Errorf("got %d days, want %d days", limit.MaxDays, tt.wantMaxDays)
+			}
+
+			if found && limit.EffectiveOn.After(tt.issuedOn) {
+				t.Errorf("limit effective on %v is after issue date %v", limit.EffectiveOn, tt.issuedOn)
+			}
+		})
+	}
+}
+
+func TestExceedsValidityLimit(t *testing.T) {
+	limit := certs.ValidityLimit{MaxDays: 47}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		validity time.Duration
+		want     bool
+	}{
+		{name: "shorter", validity: 46 * day, want: false},
+		{name: "exactly the limit", validity: 47 * day, want: false},
+		{name: "one second longer", validity: 47*day + time.Second, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certs.ExceedsValidityLimit(tt.validity, limit); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextExceededValidityLimit(t *testing.T) {
+	// newCert returns a certificate issued on the given date with a
+	// validity period of the given number of days (RFC 5280 inclusive).
+	newCert := func(issuedOn time.Time, days int) *x509.Certificate {
+		return &x509.Certificate{
+			NotBefore: issuedOn,
+			NotAfter:  issuedOn.AddDate(0, 0, days).Add(-time.Second),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		cert          *x509.Certificate
+		wantFound     bool
+		wantEffective time.Time
+	}{
+		{
+			name:          "398 days before 200 day limit",
+			cert:          newCert(date(2025, time.June, 1), 398),
+			wantFound:     true,
+			wantEffective: date(2026, time.March, 15),
+		},
+		{
+			name:          "200 days before 100 day limit",
+			cert:          newCert(date(2026, time.March, 15), 200),
+			wantFound:     true,
+			wantEffective: date(2027, time.March, 15),
+		},
+		{
+			name:          "100 days before 47 day limit",
+			cert:          newCert(date(2028, time.March, 15), 100),
+			wantFound:     true,
+			wantEffective: date(2029, time.March, 15),
+		},
+		{
+			name:          "100 days on day before 47 day limit",
+			cert:          newCert(date(2029, time.March, 14), 100),
+			wantFound:     true,
+			wantEffective: date(2029, time.March, 15),
+		},
+		{
+			name: "100 days after 47 day limit",
+			cert: newCert(date(2029, time.March, 15), 100),
+		},
+		{
+			name: "47 days",
+			cert: newCert(date(2026, time.April, 1), 47),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, found := certs.NextExceededValidityLimit(tt.cert)
+
+			if found != tt.wantFound {
+				t.Fatalf("got found %t, want %t", found, tt.wantFound)
+			}
+
+			if found && !limit.EffectiveOn.Equal(tt.wantEffective) {
+				t.Errorf("got limit effective on %v, want %v", limit.EffectiveOn, tt.wantEffective)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// ErrInvalidCRL indicates that given CRL data could not be parsed.
+var ErrInvalidCRL = errors.New("invalid CRL")
+
+// pemCRLBlockType is the PEM block type used for CRLs.
+const pemCRLBlockType string = "X509 CRL"
+
+// ParseCRLs parses the given DER or PEM encoded CRL data. PEM encoded data
+// may contain multiple CRLs.
+func ParseCRLs(data []byte) ([]*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block == nil {
+		crl, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCRL, err)
+		}
+
+		return []*x509.RevocationList{crl}, nil
+	}
+
+	var crls []*x509.RevocationList
+
+	for rest := data; ; {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != pemCRLBlockType {
+			continue
+		}
+
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCRL, err)
+		}
+
+		crls = append(crls, crl)
+	}
+
+	if len(crls) == 0 {
+		return nil, fmt.Errorf("no %s PEM blocks found: %w", pemCRLBlockType, ErrInvalidCRL)
+	}
+
+	return crls, nil
+}
+
+// LoadCRLs parses the CRLs from the given files and data. A file which
+// cannot be read or a CRL which cannot be parsed does not prevent loading
+// the remaining CRLs; an error is returned for each such file or CRL along
+// with the CRLs which were loaded.
+func LoadCRLs(files []string, data [][]byte) ([]*x509.RevocationList, []error) {
+	crls := make([]*x509.RevocationList, 0, len(files)+len(data))
+
+	var errs []error
+
+	for _, filename := range files {
+		fileData, err := os.ReadFile(filename) // #nosec G304 -- caller specified CRL
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading CRL file %s: %w", filename, err))
+			continue
+		}
+
+		parsed, err := ParseCRLs(fileData)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error parsing CRL file %s: %w", filename, err))
+			continue
+		}
+
+		crls = append(crls, parsed...)
+	}
+
+	for i, crlData := range data {
+		parsed, err := ParseCRLs(crlData)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error parsing CRL %d: %w", i, err))
+			continue
+		}
+
+		crls = append(crls, parsed...)
+	}
+
+	return crls, errs
+}
+
+// CheckCRL evaluates the revocation status of the given certificate using
+// the given CRLs as of the given time. Only CRLs issued by the issuer of the
+// certificate are considered; each CRL signature is verified using the
+// issuing certificate from the given certificate chain. If more than one
+// verified CRL is available the most recently issued CRL is used.
+//
+// Indirect CRLs, delta CRLs and CRL partitioning via the Issuing
+// Distribution Point extension are not supported. Self-signed certificates
+// are not checked.
+func CheckCRL(
+	cert *x509.Certificate,
+	certChain []*x509.Certificate,
+	crls []*x509.RevocationList,
+	now time.Time,
+) Check {
+	if certs.IsSelfSigned(cert) {
+		return NotChecked()
+	}
+
+	var candidates []*x509.RevocationList
+	for _, crl := range crls {
+		if bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+			candidates = append(candidates, crl)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return NotChecked()
+	}
+
+	issuerIdx := certs.IssuerIndex(cert, certChain)
+	if issuerIdx == -1 {
+		return Check{
+			Status: input.RevocationStatusUnknown,
+			Err:    fmt.Errorf("unable to verify CRL: %w", ErrIssuerNotPresent),
+		}
+	}
+
+	issuer := certChain[issuerIdx]
+
+	var crl *x509.RevocationList
+	var verifyErr error
+	for _, candidate := range candidates {
+		if err := candidate.CheckSignatureFrom(issuer); err != nil {
+			verifyErr = err
+			continue
+		}
+
+		if crl == nil || candidate.ThisUpdate.After(crl.ThisUpdate) {
+			crl = candidate
+		}
+	}
+
+	if crl == nil {
+		return Check{
+			Status: input.RevocationStatusUnknown,
+			Signer: issuer.Subject.String(),
+			Err:    fmt.Errorf("unable to verify CRL signature: %w", verifyErr),
+		}
+	}
+
+	check := Check{
+		Status:     input.RevocationStatusGood,
+		ThisUpdate: crl.ThisUpdate,
+		NextUpdate: crl.NextUpdate,
+		Stale:      !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate),
+		Signer:     issuer.Subject.String(),
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber != nil && entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			check.Status = input.RevocationStatusRevoked
+			check.Reason = ReasonText(entry.ReasonCode)
+			check.RevokedAt = entry.RevocationTime
+
+			return check
+		}
+	}
+
+	// A certificate not listed on a stale CRL may have been revoked since.
+	if check.Stale {
+		check.Status = input.RevocationStatusUnknown
+		check.Err = fmt.Errorf(
+			"CRL next update %s: %w",
+			crl.NextUpdate.UTC().Format(time.RFC3339),
+			ErrStaleRevocationInfo,
+		)
+	}
+
+	return check
+}
@@ -0,0 +1,313 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package revocation_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/revocation"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// testPKI is a minimal certificate chain used to evaluate revocation
+// information.
+type testPKI struct {
+	leaf  *x509.Certificate
+	ca    *x509.Certificate
+	caKey *ecdsa.PrivateKey
+	now   time.Time
+}
+
+// chain returns the certificate chain in leaf first order.
+func (p testPKI) chain() []*x509.Certificate {
+	return []*x509.Certificate{p.leaf, p.ca}
+}
+
+// newTestPKI returns a CA certificate and a leaf certificate issued by it.
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	ca, caKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Revocation CA"},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	leaf, _ := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0x1234),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, ca, caKey)
+
+	return testPKI{
+		leaf:  leaf,
+		ca:    ca,
+		caKey: caKey,
+		now:   now,
+	}
+}
+
+// createCRL returns a CRL issued by the given issuer listing the given
+// entries with the given validity period.
+func createCRL(
+	t *testing.T,
+	issuer *x509.Certificate,
+	issuerKey *ecdsa.PrivateKey,
+	thisUpdate time.Time,
+	nextUpdate time.Time,
+	entries []x509.RevocationListEntry,
+) []byte {
+	t.Helper()
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(thisUpdate.Unix()),
+		ThisUpdate:                thisUpdate,
+		NextUpdate:                nextUpdate,
+		RevokedCertificateEntries: entries,
+	}, issuer, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// parseCRL parses the given DER encoded CRL.
+func parseCRL(t *testing.T, der []byte) *x509.RevocationList {
+	t.Helper()
+
+	crls, err := revocation.ParseCRLs(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(crls) != 1 {
+		t.Fatalf("got %d CRLs, want 1", len(crls))
+	}
+
+	return crls[0]
+}
+
+func TestCheckCRL(t *testing.T) {
+	pki := newTestPKI(t)
+
+	// An unrelated CA with the same subject as the test CA; CRLs signed by
+	// this CA are matched to the leaf certificate by issuer name but fail
+	// signature verification.
+	imposter, imposterKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pki.ca.Subject,
+		NotBefore:             pki.now.Add(-24 * time.Hour),
+		NotAfter:              pki.now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	other, otherKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		NotBefore:             pki.now.Add(-24 * time.Hour),
+		NotAfter:              pki.now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	revokedAt := pki.now.Add(-2 * time.Hour)
+	revokedEntry := []x509.RevocationListEntry{
+		{
+			SerialNumber:   pki.leaf.SerialNumber,
+			RevocationTime: revokedAt,
+			ReasonCode:     1,
+		},
+	}
+	otherEntry := []x509.RevocationListEntry{
+		{
+			SerialNumber:   big.NewInt(0x9999),
+			RevocationTime: revokedAt,
+		},
+	}
+
+	current := func(entries []x509.RevocationListEntry) []byte {
+		return createCRL(t, pki.ca, pki.caKey, pki.now.Add(-time.Hour), pki.now.Add(24*time.Hour), entries)
+	}
+
+	tests := []struct {
+		name       string
+		cert       *x509.Certificate
+		crls       [][]byte
+		wantStatus string
+		wantStale  bool
+		wantReason string
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{
+			name:       "matching CRL without serial",
+			cert:       pki.leaf,
+			crls:       [][]byte{current(otherEntry)},
+			wantStatus: input.RevocationStatusGood,
+		},
+		{
+			name:       "matching CRL with serial",
+			cert:       pki.leaf,
+			crls:       [][]byte{current(revokedEntry)},
+			wantStatus: input.RevocationStatusRevoked,
+			wantReason: revocation.ReasonText(1),
+		},
+		{
+			name: "stale CRL without serial",
+			cert: pki.leaf,
+			crls: [][]byte{
+				createCRL(t, pki.ca, pki.caKey, pki.now.Add(-48*time.Hour), pki.now.Add(-24*time.Hour), otherEntry),
+			},
+			wantStatus: input.RevocationStatusUnknown,
+			wantStale:  true,
+			wantErr:    revocation.ErrStaleRevocationInfo,
+		},
+		{
+			name: "stale CRL with serial",
+			cert: pki.leaf,
+			crls: [][]byte{
+				createCRL(t, pki.ca, pki.caKey, pki.now.Add(-48*time.Hour), pki.now.Add(-24*time.Hour), revokedEntry),
+			},
+			wantStatus: input.RevocationStatusRevoked,
+			wantStale:  true,
+			wantReason: revocation.ReasonText(1),
+		},
+		{
+			name: "newest verified CRL is used",
+			cert: pki.leaf,
+			crls: [][]byte{
+				createCRL(t, pki.ca, pki.caKey, pki.now.Add(-48*time.Hour), pki.now.Add(-24*time.Hour), otherEntry),
+				current(revokedEntry),
+			},
+			wantStatus: input.RevocationStatusRevoked,
+			wantReason: revocation.ReasonText(1),
+		},
+		{
+			name: "bad signature",
+			cert: pki.leaf,
+			crls: [][]byte{
+				createCRL(t, imposter, imposterKey, pki.now.Add(-time.Hour), pki.now.Add(24*time.Hour), revokedEntry),
+			},
+			wantStatus: input.RevocationStatusUnknown,
+			wantAnyErr: true,
+		},
+		{
+			name:       "no CRL from issuer",
+			cert:       pki.leaf,
+			crls:       [][]byte{createCRL(t, other, otherKey, pki.now.Add(-time.Hour), pki.now.Add(24*time.Hour), revokedEntry)},
+			wantStatus: input.RevocationStatusNotChecked,
+		},
+		{
+			name:       "self-signed certificate",
+			cert:       pki.ca,
+			crls:       [][]byte{current(nil)},
+			wantStatus: input.RevocationStatusNotChecked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var crls []*x509.RevocationList
+			for _, der := range tt.crls {
+				crls = append(crls, parseCRL(t, der))
+			}
+
+			check := revocation.CheckCRL(tt.cert, pki.chain(), crls, pki.now)
+
+			if check.Status != tt.wantStatus {
+				t.Errorf("got status %q, want %q (err: %v)", check.Status, tt.wantStatus, check.Err)
+			}
+
+			if check.Stale != tt.wantStale {
+				t.Errorf("got stale %t, want %t", check.Stale, tt.wantStale)
+			}
+
+			if check.Reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", check.Reason, tt.wantReason)
+			}
+
+			if tt.wantStatus == input.RevocationStatusRevoked && !check.RevokedAt.Equal(revokedAt) {
+				t.Errorf("got revocation time %v, want %v", check.RevokedAt, revokedAt)
+			}
+
+			switch {
+			case tt.wantErr != nil && !errors.Is(check.Err, tt.wantErr):
+				t.Errorf("got error %v, want %v", check.Err, tt.wantErr)
+			case tt.wantErr == nil && tt.wantAnyErr != (check.Err != nil):
+				t.Errorf("got error %v, want error: %t", check.Err, tt.wantAnyErr)
+			}
+		})
+	}
+}
+
+func TestLoadCRLs(t *testing.T) {
+	pki := newTestPKI(t)
+
+	der := createCRL(t, pki.ca, pki.caKey, pki.now.Add(-time.Hour), pki.now.Add(24*time.Hour), nil)
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+
+	dir := t.TempDir()
+	goodFile := filepath.Join(dir, "good.crl")
+	if err := os.WriteFile(goodFile, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	badFile := filepath.Join(dir, "bad.crl")
+	if err := os.WriteFile(badFile, []byte("not a CRL"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	crls, errs := revocation.LoadCRLs(
+		[]string{goodFile, badFile, filepath.Join(dir, "missing.crl")},
+		[][]byte{der, []byte("not a CRL")},
+	)
+
+	if len(crls) != 2 {
+		t.Errorf("got %d CRLs, want 2", len(crls))
+	}
+
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), errs)
+	}
+
+	if !errors.Is(errs[0], revocation.ErrInvalidCRL) {
+		t.Errorf("got error %v, want %v", errs[0], revocation.ErrInvalidCRL)
+	}
+
+	if !errors.Is(errs[1], os.ErrNotExist) {
+		t.Errorf("got error %v, want %v", errs[1], os.ErrNotExist)
+	}
+
+	if !errors.Is(errs[2], revocation.ErrInvalidCRL) {
+		t.Errorf("got error %v, want %v", errs[2], revocation.ErrInvalidCRL)
+	}
+}
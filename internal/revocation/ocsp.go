@@ -0,0 +1,390 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+var (
+	// ErrInvalidOCSPResponse indicates that a given OCSP response could not
+	// be parsed.
+	ErrInvalidOCSPResponse = errors.New("invalid OCSP response")
+
+	// ErrOCSPResponseNotSuccessful indicates that an OCSP responder returned
+	// an error status instead of certificate status information.
+	ErrOCSPResponseNotSuccessful = errors.New("OCSP response not successful")
+
+	// ErrOCSPSignatureNotVerified indicates that the signature of an OCSP
+	// response could not be verified using the issuer of the certificate or
+	// an authorized responder certificate.
+	ErrOCSPSignatureNotVerified = errors.New("OCSP response signature not verified")
+)
+
+// errMultipleResponses is the error returned by the ocsp package when a
+// response containing status information for more than one certificate is
+// parsed without specifying the certificate of interest.
+var errMultipleResponses = ocsp.ParseError("OCSP response contains bad number of responses")
+
+// oidTLSFeature is the object identifier of the TLS Feature extension (RFC
+// 7633).
+var oidTLSFeature = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// tlsFeatureStatusRequest is the TLS Feature extension value for the
+// status_request TLS extension (i.e., OCSP must-staple).
+const tlsFeatureStatusRequest int = 5
+
+// OCSPResponse is a parsed OCSP response. Parsing and signature
+// verification are performed by the golang.org/x/crypto/ocsp package.
+type OCSPResponse struct {
+	raw []byte
+
+	// single is the parsed response if the response provides status
+	// information for a single certificate; responses with status
+	// information for multiple certificates are parsed for a specific
+	// certificate when evaluated.
+	single *ocsp.Response
+}
+
+// ParseOCSPResponse parses the given DER encoded OCSP response. An error is
+// returned if the response cannot be parsed or if the responder returned an
+// error status.
+func ParseOCSPResponse(der []byte) (*OCSPResponse, error) {
+	single, err := ocsp.ParseResponse(der, nil)
+
+	var respErr ocsp.ResponseError
+
+	switch {
+	case errors.As(err, &respErr):
+		return nil, fmt.Errorf("responder returned %s: %w", respErr.Status, ErrOCSPResponseNotSuccessful)
+
+	case errors.Is(err, errMultipleResponses):
+		return &OCSPResponse{raw: der}, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOCSPResponse, err)
+	}
+
+	return &OCSPResponse{raw: der, single: single}, nil
+}
+
+// ParseOCSPResponses parses the given DER encoded OCSP responses. An error
+// is returned if a response cannot be parsed.
+func ParseOCSPResponses(data [][]byte) ([]*OCSPResponse, error) {
+	responses := make([]*OCSPResponse, 0, len(data))
+
+	for i, der := range data {
+		resp, err := ParseOCSPResponse(der)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing OCSP response %d: %w", i, err)
+		}
+
+		responses = append(responses, resp)
+	}
+
+	return responses, nil
+}
+
+// ResponderID returns a human readable version of the responder identity
+// for the OCSP response; either the responder name or the SHA-1 hash of the
+// responder public key. An empty string is returned for responses with
+// status information for multiple certificates.
+func (r *OCSPResponse) ResponderID() string {
+	if r.single == nil {
+		return ""
+	}
+
+	return responderID(r.single)
+}
+
+// NextUpdate returns the NextUpdate value of the certificate status
+// information in the OCSP response or the zero value if not specified or if
+// the response provides status information for multiple certificates.
+func (r *OCSPResponse) NextUpdate() time.Time {
+	if r.single == nil {
+		return time.Time{}
+	}
+
+	return r.single.NextUpdate
+}
+
+// CheckOCSP evaluates the revocation status of the given certificate using
+// the given OCSP responses as of the given time. Only responses containing
+// status information for the certificate and its issuer are considered;
+// each response signature is verified using the issuer of the certificate
+// from the given certificate chain or an authorized responder certificate
+// issued by it which is valid at the given time. If more than one verified
+// response is available the most recent response is used.
+func CheckOCSP(
+	cert *x509.Certificate,
+	certChain []*x509.Certificate,
+	responses []*OCSPResponse,
+	now time.Time,
+) Check {
+	if certs.IsSelfSigned(cert) || len(responses) == 0 {
+		return NotChecked()
+	}
+
+	issuerIdx := certs.IssuerIndex(cert, certChain)
+
+	var (
+		best      *ocsp.Response
+		signer    *x509.Certificate
+		verifyErr error
+	)
+
+	for _, resp := range responses {
+		// The response signature is verified below to account for
+		// delegated responder certificates.
+		single, err := ocsp.ParseResponseForCert(resp.raw, cert, nil)
+		if err != nil {
+			continue
+		}
+
+		if issuerIdx == -1 {
+			verifyErr = fmt.Errorf("unable to verify OCSP response: %w", ErrIssuerNotPresent)
+			continue
+		}
+
+		issuer := certChain[issuerIdx]
+		if !certIDMatchesIssuer(single, cert, issuer) {
+			continue
+		}
+
+		respSigner, err := verifySigner(single, issuer, now)
+		if err != nil {
+			verifyErr = err
+			continue
+		}
+
+		if best == nil || single.ThisUpdate.After(best.ThisUpdate) {
+			best, signer = single, respSigner
+		}
+	}
+
+	switch {
+	case best == nil && verifyErr == nil:
+		return NotChecked()
+
+	case best == nil:
+		return Check{Status: input.RevocationStatusUnknown, Err: verifyErr}
+	}
+
+	check := Check{
+		ThisUpdate: best.ThisUpdate,
+		NextUpdate: best.NextUpdate,
+		Stale:      !best.NextUpdate.IsZero() && now.After(best.NextUpdate),
+		Signer:     fmt.Sprintf("%s (responder ID: %s)", signer.Subject.String(), responderID(best)),
+	}
+
+	switch best.Status {
+	case ocsp.Good:
+		check.Status = input.RevocationStatusGood
+
+	case ocsp.Revoked:
+		check.Status = input.RevocationStatusRevoked
+		check.Reason = ReasonText(best.RevocationReason)
+		check.RevokedAt = best.RevokedAt
+
+		return check
+
+	default:
+		check.Status = input.RevocationStatusUnknown
+
+		return check
+	}
+
+	// A certificate reported as good by a stale response may have been
+	// revoked since.
+	if check.Stale {
+		check.Status = input.RevocationStatusUnknown
+		check.Err = fmt.Errorf(
+			"OCSP next update %s: %w",
+			best.NextUpdate.UTC().Format(time.RFC3339),
+			ErrStaleRevocationInfo,
+		)
+	}
+
+	return check
+}
+
+// verifySigner is a helper function that verifies the signature of the
+// given OCSP response using the given issuer or an authorized responder
+// certificate issued by it and returns the certificate which signed the
+// response. Responder certificates which are not valid at the given time
+// are rejected.
+func verifySigner(resp *ocsp.Response, issuer *x509.Certificate, now time.Time) (*x509.Certificate, error) {
+	responder := resp.Certificate
+
+	// The ocsp package verified the response signature using the included
+	// certificate, if any.
+	if responder == nil || bytes.Equal(responder.Raw, issuer.Raw) {
+		if err := resp.CheckSignatureFrom(issuer); err != nil {
+			return nil, fmt.Errorf(
+				"not signed by %q or an authorized responder: %w",
+				issuer.Subject.String(),
+				ErrOCSPSignatureNotVerified,
+			)
+		}
+
+		return issuer, nil
+	}
+
+	// Delegated responder certificates must be issued by the certificate
+	// issuer and be authorized for OCSP signing (RFC 6960, section 4.2.2.2).
+	switch {
+	case responder.CheckSignatureFrom(issuer) != nil:
+		return nil, fmt.Errorf(
+			"responder certificate %q not issued by %q: %w",
+			responder.Subject.String(),
+			issuer.Subject.String(),
+			ErrOCSPSignatureNotVerified,
+		)
+
+	case !hasOCSPSigningEKU(responder):
+		return nil, fmt.Errorf(
+			"responder certificate %q not authorized for OCSP signing: %w",
+			responder.Subject.String(),
+			ErrOCSPSignatureNotVerified,
+		)
+
+	case now.Before(responder.NotBefore) || now.After(responder.NotAfter):
+		return nil, fmt.Errorf(
+			"responder certificate %q not valid at %s: %w",
+			responder.Subject.String(),
+			now.UTC().Format(time.RFC3339),
+			ErrOCSPSignatureNotVerified,
+		)
+	}
+
+	return responder, nil
+}
+
+// responseCertIDs is the subset of the ResponseData ASN.1 structure (RFC
+// 6960, section 4.2.1) needed to match the CertID of each certificate
+// status. The ocsp package only exposes the CertID serial number.
+type responseCertIDs struct {
+	Version     int `asn1:"optional,default:0,explicit,tag:0"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []struct {
+		CertID struct {
+			HashAlgorithm pkix.AlgorithmIdentifier
+			NameHash      []byte
+			IssuerKeyHash []byte
+			SerialNumber  *big.Int
+		}
+	}
+}
+
+// certIDMatchesIssuer is a helper function that asserts that the issuer
+// name and key hashes of the CertID for the status information of the given
+// certificate in the given OCSP response match the given issuer.
+func certIDMatchesIssuer(resp *ocsp.Response, cert *x509.Certificate, issuer *x509.Certificate) bool {
+	var data responseCertIDs
+	if _, err := asn1.Unmarshal(resp.TBSResponseData, &data); err != nil {
+		return false
+	}
+
+	// Let the ocsp package compute the expected hashes.
+	reqDER, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: resp.IssuerHash})
+	if err != nil {
+		return false
+	}
+
+	want, err := ocsp.ParseRequest(reqDER)
+	if err != nil {
+		return false
+	}
+
+	// The ocsp package uses the first status for the serial number.
+	for _, single := range data.Responses {
+		if single.CertID.SerialNumber == nil || single.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+
+		return bytes.Equal(single.CertID.NameHash, want.IssuerNameHash) &&
+			bytes.Equal(single.CertID.IssuerKeyHash, want.IssuerKeyHash)
+	}
+
+	return false
+}
+
+// responderID is a helper function that returns a human readable version of
+// the responder identity for the given OCSP response.
+func responderID(resp *ocsp.Response) string {
+	switch {
+	case resp.RawResponderName != nil:
+		var rdn pkix.RDNSequence
+		if _, err := asn1.Unmarshal(resp.RawResponderName, &rdn); err == nil {
+			var name pkix.Name
+			name.FillFromRDNSequence(&rdn)
+
+			return name.String()
+		}
+
+	case resp.ResponderKeyHash != nil:
+		return "key hash " + certs.FormatKeyID(resp.ResponderKeyHash)
+	}
+
+	return "unrecognized responder ID"
+}
+
+// hasOCSPSigningEKU is a helper function that asserts that the given
+// certificate is authorized for OCSP signing.
+func hasOCSPSigningEKU(cert *x509.Certificate) bool {
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MustStaple asserts that the given certificate carries the TLS Feature
+// extension requesting the status_request TLS extension (OCSP must-staple).
+func MustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidTLSFeature) {
+			continue
+		}
+
+		var features []int
+		if _, err := asn1.Unmarshal(ext.Value, &features); err != nil {
+			return false
+		}
+
+		for _, feature := range features {
+			if feature == tlsFeatureStatusRequest {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CreateOCSPRequest creates a DER encoded OCSP request for the given
+// certificate issued by the given issuer. A SHA-1 based certificate
+// identifier is used as this is supported by all OCSP responders.
+func CreateOCSPRequest(cert *x509.Certificate, issuer *x509.Certificate) ([]byte, error) {
+	return ocsp.CreateRequest(cert, issuer, nil)
+}
@@ -0,0 +1,372 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package revocation_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/revocation"
+	"github.com/atc0005/cert-payload/internal/testutil"
+)
+
+// ocspParams are the values used to build a test OCSP response.
+type ocspParams struct {
+	// status is the certificate status; one of ocsp.Good, ocsp.Revoked or
+	// ocsp.Unknown.
+	status int
+
+	// cert is the certificate the response provides status information
+	// for.
+	cert *x509.Certificate
+
+	// issuer is the issuer of cert used for the CertID value.
+	issuer *x509.Certificate
+
+	// issuerHash is the hash used for the CertID value; SHA-1 if not
+	// specified.
+	issuerHash crypto.Hash
+
+	// signer and signerKey sign the response.
+	signer    *x509.Certificate
+	signerKey *ecdsa.PrivateKey
+
+	// responderCert is included in the response if specified.
+	responderCert *x509.Certificate
+
+	thisUpdate time.Time
+	nextUpdate time.Time
+	revokedAt  time.Time
+}
+
+// createOCSPResponse returns a DER encoded OCSP response built from the
+// given values using the OCSP responder implementation of the ocsp package.
+func createOCSPResponse(t *testing.T, p ocspParams) []byte {
+	t.Helper()
+
+	der, err := ocsp.CreateResponse(p.issuer, p.signer, ocsp.Response{
+		Status:           p.status,
+		SerialNumber:     p.cert.SerialNumber,
+		ThisUpdate:       p.thisUpdate,
+		NextUpdate:       p.nextUpdate,
+		RevokedAt:        p.revokedAt,
+		RevocationReason: ocsp.KeyCompromise,
+		IssuerHash:       p.issuerHash,
+		Certificate:      p.responderCert,
+	}, p.signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// issueResponder returns a delegated OCSP responder certificate issued by
+// the CA of the given test PKI with the given validity period.
+func issueResponder(
+	t *testing.T,
+	pki testPKI,
+	notBefore time.Time,
+	notAfter time.Time,
+	ocspSigning bool,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(notBefore.UnixNano()),
+		Subject:      pkix.Name{CommonName: "Test OCSP Responder"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	if ocspSigning {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+	}
+
+	return testutil.IssueCert(t, tmpl, pki.ca, pki.caKey)
+}
+
+func TestParseOCSPResponse(t *testing.T) {
+	pki := newTestPKI(t)
+
+	valid := createOCSPResponse(t, ocspParams{
+		status:     ocsp.Good,
+		cert:       pki.leaf,
+		issuer:     pki.ca,
+		signer:     pki.ca,
+		signerKey:  pki.caKey,
+		thisUpdate: pki.now.Add(-time.Hour),
+		nextUpdate: pki.now.Add(24 * time.Hour),
+	})
+
+	tests := []struct {
+		name    string
+		der     []byte
+		wantErr error
+	}{
+		{
+			name: "valid response",
+			der:  valid,
+		},
+		{
+			name:    "malformed response",
+			der:     []byte("not an OCSP response"),
+			wantErr: revocation.ErrInvalidOCSPResponse,
+		},
+		{
+			name:    "trailing data",
+			der:     append(append([]byte{}, valid...), 0x00),
+			wantErr: revocation.ErrInvalidOCSPResponse,
+		},
+		{
+			name:    "unsuccessful response status",
+			der:     ocsp.TryLaterErrorResponse,
+			wantErr: revocation.ErrOCSPResponseNotSuccessful,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := revocation.ParseOCSPResponse(tt.der)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := resp.ResponderID(), pki.ca.Subject.String(); got != want {
+				t.Errorf("got responder ID %q, want %q", got, want)
+			}
+
+			if got, want := resp.NextUpdate(), pki.now.Add(24*time.Hour); !got.Equal(want) {
+				t.Errorf("got next update %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckOCSP(t *testing.T) {
+	pki := newTestPKI(t)
+
+	// An unrelated CA with the same subject as the test CA; responses signed
+	// by this CA match the leaf certificate by issuer name only.
+	imposter, imposterKey := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pki.ca.Subject,
+		NotBefore:             pki.now.Add(-24 * time.Hour),
+		NotAfter:              pki.now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	responder, responderKey := issueResponder(t, pki, pki.now.Add(-24*time.Hour), pki.now.Add(24*time.Hour), true)
+	noEKU, noEKUKey := issueResponder(t, pki, pki.now.Add(-24*time.Hour), pki.now.Add(24*time.Hour), false)
+	expired, expiredKey := issueResponder(t, pki, pki.now.Add(-48*time.Hour), pki.now.Add(-24*time.Hour), true)
+	notYetValid, notYetValidKey := issueResponder(t, pki, pki.now.Add(24*time.Hour), pki.now.Add(48*time.Hour), true)
+	shortLived, shortLivedKey := issueResponder(t, pki, pki.now.Add(-24*time.Hour), pki.now.Add(time.Hour), true)
+
+	revokedAt := pki.now.Add(-2 * time.Hour)
+
+	// params returns the values for a current response for the leaf
+	// certificate with the given status signed by the given certificate.
+	params := func(status int, signer *x509.Certificate, signerKey *ecdsa.PrivateKey) ocspParams {
+		return ocspParams{
+			status:     status,
+			cert:       pki.leaf,
+			issuer:     pki.ca,
+			signer:     signer,
+			signerKey:  signerKey,
+			thisUpdate: pki.now.Add(-time.Hour),
+			nextUpdate: pki.now.Add(24 * time.Hour),
+			revokedAt:  revokedAt,
+		}
+	}
+
+	delegated := func(signer *x509.Certificate, signerKey *ecdsa.PrivateKey) ocspParams {
+		p := params(ocsp.Good, signer, signerKey)
+		p.responderCert = signer
+
+		return p
+	}
+
+	stale := params(ocsp.Good, pki.ca, pki.caKey)
+	stale.thisUpdate = pki.now.Add(-48 * time.Hour)
+	stale.nextUpdate = pki.now.Add(-24 * time.Hour)
+
+	wrongIssuer := params(ocsp.Good, pki.ca, pki.caKey)
+	wrongIssuer.issuer = imposter
+
+	sha256CertID := params(ocsp.Good, pki.ca, pki.caKey)
+	sha256CertID.issuerHash = crypto.SHA256
+
+	tests := []struct {
+		name       string
+		cert       *x509.Certificate
+		params     ocspParams
+		checkAt    time.Time
+		wantStatus string
+		wantReason string
+		wantSigner *x509.Certificate
+		wantErr    error
+	}{
+		{
+			name:       "good",
+			cert:       pki.leaf,
+			params:     params(ocsp.Good, pki.ca, pki.caKey),
+			wantStatus: input.RevocationStatusGood,
+			wantSigner: pki.ca,
+		},
+		{
+			name:       "revoked",
+			cert:       pki.leaf,
+			params:     params(ocsp.Revoked, pki.ca, pki.caKey),
+			wantStatus: input.RevocationStatusRevoked,
+			wantReason: revocation.ReasonText(1),
+			wantSigner: pki.ca,
+		},
+		{
+			name:       "unknown",
+			cert:       pki.leaf,
+			params:     params(ocsp.Unknown, pki.ca, pki.caKey),
+			wantStatus: input.RevocationStatusUnknown,
+			wantSigner: pki.ca,
+		},
+		{
+			name:       "stale",
+			cert:       pki.leaf,
+			params:     stale,
+			wantStatus: input.RevocationStatusUnknown,
+			wantSigner: pki.ca,
+			wantErr:    revocation.ErrStaleRevocationInfo,
+		},
+		{
+			name:       "signed by wrong issuer",
+			cert:       pki.leaf,
+			params:     params(ocsp.Good, imposter, imposterKey),
+			wantStatus: input.RevocationStatusUnknown,
+			wantErr:    revocation.ErrOCSPSignatureNotVerified,
+		},
+		{
+			name:       "CertID for wrong issuer",
+			cert:       pki.leaf,
+			params:     wrongIssuer,
+			wantStatus: input.RevocationStatusNotChecked,
+		},
+		{
+			name:       "delegated responder",
+			cert:       pki.leaf,
+			params:     delegated(responder, responderKey),
+			wantStatus: input.RevocationStatusGood,
+			wantSigner: responder,
+		},
+		{
+			name:       "delegated responder without OCSP signing",
+			cert:       pki.leaf,
+			params:     delegated(noEKU, noEKUKey),
+			wantStatus: input.RevocationStatusUnknown,
+			wantErr:    revocation.ErrOCSPSignatureNotVerified,
+		},
+		{
+			name:       "expired delegated responder",
+			cert:       pki.leaf,
+			params:     delegated(expired, expiredKey),
+			wantStatus: input.RevocationStatusUnknown,
+			wantErr:    revocation.ErrOCSPSignatureNotVerified,
+		},
+		{
+			name:       "not yet valid delegated responder",
+			cert:       pki.leaf,
+			params:     delegated(notYetValid, notYetValidKey),
+			wantStatus: input.RevocationStatusUnknown,
+			wantErr:    revocation.ErrOCSPSignatureNotVerified,
+		},
+		{
+			// The responder certificate was valid when the response was
+			// produced but has expired since.
+			name:       "delegated responder expired at verification time",
+			cert:       pki.leaf,
+			params:     delegated(shortLived, shortLivedKey),
+			checkAt:    pki.now.Add(2 * time.Hour),
+			wantStatus: input.RevocationStatusUnknown,
+			wantErr:    revocation.ErrOCSPSignatureNotVerified,
+		},
+		{
+			name:       "SHA-256 CertID",
+			cert:       pki.leaf,
+			params:     sha256CertID,
+			wantStatus: input.RevocationStatusGood,
+			wantSigner: pki.ca,
+		},
+		{
+			name:       "self-signed certificate",
+			cert:       pki.ca,
+			params:     params(ocsp.Good, pki.ca, pki.caKey),
+			wantStatus: input.RevocationStatusNotChecked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := revocation.ParseOCSPResponse(createOCSPResponse(t, tt.params))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			checkAt := pki.now
+			if !tt.checkAt.IsZero() {
+				checkAt = tt.checkAt
+			}
+
+			check := revocation.CheckOCSP(tt.cert, pki.chain(), []*revocation.OCSPResponse{resp}, checkAt)
+
+			if check.Status != tt.wantStatus {
+				t.Errorf("got status %q, want %q (err: %v)", check.Status, tt.wantStatus, check.Err)
+			}
+
+			if check.Reason != tt.wantReason {
+				t.Errorf("got reason %q, want %q", check.Reason, tt.wantReason)
+			}
+
+			if tt.wantStatus == input.RevocationStatusRevoked && !check.RevokedAt.Equal(revokedAt) {
+				t.Errorf("got revocation time %v, want %v", check.RevokedAt, revokedAt)
+			}
+
+			if tt.wantSigner != nil {
+				want := tt.wantSigner.Subject.String() + " (responder ID: " + tt.params.signer.Subject.String() + ")"
+				if check.Signer != want {
+					t.Errorf("got signer %q, want %q", check.Signer, want)
+				}
+			}
+
+			switch {
+			case tt.wantErr != nil && !errors.Is(check.Err, tt.wantErr):
+				t.Errorf("got error %v, want %v", check.Err, tt.wantErr)
+			case tt.wantErr == nil && check.Err != nil:
+				t.Errorf("unexpected error: %v", check.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package revocation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+)
+
+var (
+	// ErrIssuerNotPresent indicates that the issuer of a certificate is not
+	// present in the certificate chain and revocation information for the
+	// certificate could not be verified.
+	ErrIssuerNotPresent = errors.New("issuer not present in certificate chain")
+
+	// ErrStaleRevocationInfo indicates that revocation information is past
+	// its NextUpdate value.
+	ErrStaleRevocationInfo = errors.New("revocation information is stale")
+)
+
+// Check is the result of evaluating the revocation status of a certificate
+// using a single source of revocation information (e.g., CRL).
+type Check struct {
+	// Status is the revocation status (e.g., not_checked, good, revoked,
+	// unknown).
+	Status string
+
+	// Reason is the revocation reason for a revoked certificate (e.g.,
+	// keyCompromise).
+	Reason string
+
+	// RevokedAt is the time the certificate was revoked.
+	RevokedAt time.Time
+
+	// ThisUpdate is the time the revocation information was issued.
+	ThisUpdate time.Time
+
+	// NextUpdate is the time by which newer revocation information is
+	// expected to be available.
+	NextUpdate time.Time
+
+	// Stale indicates whether the revocation information is past its
+	// NextUpdate value.
+	Stale bool
+
+	// Signer is the subject of the certificate which signed the revocation
+	// information.
+	Signer string
+
+	// Err is the error encountered while evaluating the revocation
+	// information, if any.
+	Err error
+}
+
+// Result is the revocation status of a certificate from all evaluated
+// sources of revocation information.
+type Result struct {
+	// CRL is the revocation status per the provided CRLs.
+	CRL Check
+
+	// OCSP is the revocation status per the provided OCSP responses.
+	OCSP Check
+
+	// Stapled indicates whether an OCSP response was stapled for the
+	// certificate.
+	Stapled bool
+}
+
+// Combined returns the most significant result from all evaluated sources
+// of revocation information (see Combine).
+func (r Result) Combined() Check {
+	return Combine(r.CRL, r.OCSP)
+}
+
+// NotChecked returns a Check indicating that revocation information was not
+// available for a certificate.
+func NotChecked() Check {
+	return Check{Status: input.RevocationStatusNotChecked}
+}
+
+// Revoked indicates whether the certificate was found to be revoked.
+func (c Check) Revoked() bool {
+	return c.Status == input.RevocationStatusRevoked
+}
+
+// statusRank is a helper function that ranks the given revocation status so
+// that the most significant result can be selected.
+func statusRank(status string) int {
+	switch status {
+	case input.RevocationStatusRevoked:
+		return 3
+	case input.RevocationStatusGood:
+		return 2
+	case input.RevocationStatusUnknown:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Combine returns the most significant of the given revocation checks. A
+// revoked status is preferred over a good status, which is preferred over
+// an unknown status. If no checks are given a not_checked result is
+// returned.
+func Combine(checks ...Check) Check {
+	combined := NotChecked()
+
+	for _, check := range checks {
+		if statusRank(check.Status) > statusRank(combined.Status) {
+			combined = check
+		}
+	}
+
+	return combined
+}
+
+// ReasonText returns the RFC 5280 name for the given CRL reason code (e.g.,
+// keyCompromise).
+func ReasonText(code int) string {
+	switch code {
+	case 0:
+		return "unspecified"
+	case 1:
+		return "keyCompromise"
+	case 2:
+		return "cACompromise"
+	case 3:
+		return "affiliationChanged"
+	case 4:
+		return "superseded"
+	case 5:
+		return "cessationOfOperation"
+	case 6:
+		return "certificateHold"
+	case 8:
+		return "removeFromCRL"
+	case 9:
+		return "privilegeWithdrawn"
+	case 10:
+		return "aACompromise"
+	default:
+		return "unrecognized"
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package testutil
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// NamedChain is a certificate chain fixture.
+type NamedChain struct {
+	Name  string
+	Chain []*x509.Certificate
+}
+
+// utf8Name returns the DER encoding of a distinguished name with the given
+// common name using the UTF8String type. The pkix package encodes the same
+// name using the PrintableString type; both have the same readable text.
+func utf8Name(t testing.TB, commonName string) []byte {
+	t.Helper()
+
+	der, err := asn1.Marshal(pkix.RDNSequence{
+		pkix.RelativeDistinguishedNameSET{
+			{
+				Type:  asn1.ObjectIdentifier{2, 5, 4, 3},
+				Value: asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(commonName)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+// IssuerLinkageChains returns certificate chains whose evaluation depends on
+// how issuer and subject names are linked: cross-signed intermediates,
+// issuer names with the same readable text but a different encoding and
+// certificates whose key identifiers match but whose names do not. The certificates are
+// valid from a year ago until ten years from now.
+func IssuerLinkageChains(t testing.TB) []NamedChain {
+	t.Helper()
+
+	now := time.Now()
+
+	ca := func(serial int64, commonName string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: commonName},
+			NotBefore:             now.AddDate(-1, 0, 0),
+			NotAfter:              now.AddDate(10, 0, 0),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+	}
+
+	leafTmpl := func(serial int64) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "www.example.com"},
+			DNSNames:     []string{"www.example.com"},
+			NotBefore:    now.AddDate(-1, 0, 0),
+			NotAfter:     now.AddDate(10, 0, 0),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+	}
+
+	root, rootKey := IssueCert(t, ca(1, "Test Root CA"), nil, nil)
+	legacyRoot, legacyRootKey := IssueCert(t, ca(2, "Test Legacy Root CA"), nil, nil)
+
+	intermediate, intermediateKey := IssueCert(t, ca(3, "Test Intermediate CA"), root, rootKey)
+	crossSigned := IssueCertForKey(t, ca(4, "Test Intermediate CA"), legacyRoot, legacyRootKey, intermediateKey.Public())
+
+	leaf, _ := IssueCert(t, leafTmpl(5), intermediate, intermediateKey)
+
+	// A self-signed root whose issuer name uses a different encoding than its
+	// subject name and which has no Authority Key Identifier.
+	encodedRootTmpl := ca(6, "Test Encoded Root CA")
+	// The self-signed certificate is only used for its key.
+	_, encodedRootKey := IssueCert(t, encodedRootTmpl, nil, nil)
+	encodedIssuer := *encodedRootTmpl
+	encodedIssuer.RawSubject = utf8Name(t, "Test Encoded Root CA")
+	encodedRoot := IssueCertForKey(t, encodedRootTmpl, &encodedIssuer, encodedRootKey, encodedRootKey.Public())
+	encodedRootIntermediate, encodedRootIntermediateKey := IssueCert(
+		t, ca(7, "Test Encoded Root Intermediate CA"), encodedRoot, encodedRootKey,
+	)
+	encodedRootLeaf, _ := IssueCert(t, leafTmpl(8), encodedRootIntermediate, encodedRootIntermediateKey)
+
+	// A self-signed root whose issuer name differs from its subject name but
+	// whose Authority and Subject Key Identifiers match.
+	keyIDRootTmpl := ca(9, "Test Key ID Root CA")
+	keyIDRootTmpl.SubjectKeyId = []byte{0x01, 0x02, 0x03, 0x04}
+	// The self-signed certificate is only used for its key.
+	_, keyIDRootKey := IssueCert(t, keyIDRootTmpl, nil, nil)
+	keyIDIssuer := *keyIDRootTmpl
+	keyIDIssuer.Subject = pkix.Name{CommonName: "Test Key ID Root CA (renamed)"}
+	keyIDRoot := IssueCertForKey(t, keyIDRootTmpl, &keyIDIssuer, keyIDRootKey, keyIDRootKey.Public())
+	keyIDRootIntermediate, keyIDRootIntermediateKey := IssueCert(
+		t, ca(10, "Test Key ID Root Intermediate CA"), keyIDRoot, keyIDRootKey,
+	)
+	keyIDRootLeaf, _ := IssueCert(t, leafTmpl(11), keyIDRootIntermediate, keyIDRootIntermediateKey)
+
+	// A self-signed leaf whose issuer name uses a different encoding than its
+	// subject name.
+	encodedLeafTmpl := leafTmpl(12)
+	// The self-signed certificate is only used for its key.
+	_, encodedLeafKey := IssueCert(t, encodedLeafTmpl, nil, nil)
+	encodedLeafIssuer := *encodedLeafTmpl
+	encodedLeafIssuer.RawSubject = utf8Name(t, "www.example.com")
+	encodedLeaf := IssueCertForKey(t, encodedLeafTmpl, &encodedLeafIssuer, encodedLeafKey, encodedLeafKey.Public())
+
+	return []NamedChain{
+		{
+			Name:  "ordered_chain",
+			Chain: []*x509.Certificate{leaf, intermediate, root},
+		},
+		{
+			Name:  "cross_signed_intermediate",
+			Chain: []*x509.Certificate{leaf, intermediate, crossSigned, legacyRoot},
+		},
+		{
+			Name:  "root_issuer_name_encoding",
+			Chain: []*x509.Certificate{encodedRootLeaf, encodedRootIntermediate, encodedRoot},
+		},
+		{
+			Name:  "root_key_identifier_linkage",
+			Chain: []*x509.Certificate{keyIDRootLeaf, keyIDRootIntermediate, keyIDRoot},
+		},
+		{
+			Name:  "self_signed_leaf_issuer_name_encoding",
+			Chain: []*x509.Certificate{encodedLeaf},
+		},
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package testutil provides common/shared fixtures and helpers used by the
+// tests of this module. It is not intended for use outside of tests.
+package testutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update indicates that golden files should be updated with the output of
+// the tests instead of compared against it.
+var update = flag.Bool("update", false, "update golden files")
+
+// IssueCert creates a certificate with a new ECDSA P-256 key for the given
+// template signed by the given parent and key. The certificate is
+// self-signed if parent is nil.
+func IssueCert(
+	t testing.TB,
+	tmpl *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey crypto.Signer,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	return IssueCertForKey(t, tmpl, parent, parentKey, &key.PublicKey), key
+}
+
+// IssueCertForKey creates a certificate with the given subject public key
+// for the given template signed by the given parent and key.
+func IssueCertForKey(
+	t testing.TB,
+	tmpl *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey crypto.Signer,
+	pub crypto.PublicKey,
+) *x509.Certificate {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+// AssertGolden compares the given output against the named golden file in
+// the testdata directory of the calling package, updating the golden file
+// instead if the -update flag is given.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("output does not match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
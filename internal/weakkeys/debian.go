@@ -0,0 +1,173 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package weakkeys
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // required by the blocklist format
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidBlocklist indicates that a Debian weak key blocklist could not
+// be parsed.
+var ErrInvalidBlocklist = errors.New("invalid weak key blocklist")
+
+// blocklistEntryLen is the length in hex digits of a blocklist entry; the
+// last 80 bits of a SHA-1 hash.
+const blocklistEntryLen int = 20
+
+//go:generate go run gen_debian_blocklist.go -o debian_weak_keys.txt blocklists
+
+// embeddedDebianBlocklist is the embedded Debian weak key blocklist generated
+// from the blocklist files in the blocklists directory.
+//
+//go:embed debian_weak_keys.txt
+var embeddedDebianBlocklist []byte
+
+// Blocklist is a set of truncated modulus hashes for RSA keys generated by
+// the vulnerable Debian OpenSSL package (CVE-2008-0166).
+type Blocklist struct {
+	entries map[[blocklistEntryLen / 2]byte]struct{}
+}
+
+var (
+	defaultBlocklist     *Blocklist
+	defaultBlocklistErr  error
+	defaultBlocklistOnce sync.Once
+)
+
+// DefaultBlocklist returns the embedded Debian weak key blocklist. The
+// blocklist is parsed once on first use.
+//
+// The embedded blocklist is only a sample of the vulnerable keys (see
+// debian_weak_keys.txt for the sources). Use LoadBlocklist with the
+// blacklist.RSA-* files of the openssl-blacklist package for complete
+// coverage.
+func DefaultBlocklist() (*Blocklist, error) {
+	defaultBlocklistOnce.Do(func() {
+		defaultBlocklist, defaultBlocklistErr = ParseBlocklist(embeddedDebianBlocklist)
+	})
+
+	return defaultBlocklist, defaultBlocklistErr
+}
+
+// ParseBlocklist parses the given Debian weak key blocklist data using the
+// format of the openssl-blacklist package: one entry per line consisting of
+// the last 20 hex digits of the SHA-1 hash of "Modulus=<hex modulus>\n".
+// Lines starting with '#' and blank lines are ignored.
+func ParseBlocklist(data []byte) (*Blocklist, error) {
+	blocklist := &Blocklist{
+		entries: make(map[[blocklistEntryLen / 2]byte]struct{}),
+	}
+
+	if err := blocklist.add(data); err != nil {
+		return nil, err
+	}
+
+	return blocklist, nil
+}
+
+// LoadBlocklist returns a copy of the embedded Debian weak key blocklist
+// extended with the entries from the given blocklist files (e.g.,
+// /usr/share/openssl-blacklist/blacklist.RSA-2048).
+func LoadBlocklist(files []string) (*Blocklist, error) {
+	base, err := DefaultBlocklist()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return base, nil
+	}
+
+	blocklist := &Blocklist{
+		entries: make(map[[blocklistEntryLen / 2]byte]struct{}, len(base.entries)),
+	}
+
+	for entry := range base.entries {
+		blocklist.entries[entry] = struct{}{}
+	}
+
+	for _, filename := range files {
+		data, err := os.ReadFile(filename) // #nosec G304 -- caller specified blocklist
+		if err != nil {
+			return nil, fmt.Errorf("error reading weak key blocklist %s: %w", filename, err)
+		}
+
+		if err := blocklist.add(data); err != nil {
+			return nil, fmt.Errorf("error parsing weak key blocklist %s: %w", filename, err)
+		}
+	}
+
+	return blocklist, nil
+}
+
+// Len returns the number of entries in the blocklist.
+func (b *Blocklist) Len() int {
+	if b == nil {
+		return 0
+	}
+
+	return len(b.entries)
+}
+
+// Contains indicates whether the given RSA public key is listed in the
+// blocklist.
+func (b *Blocklist) Contains(pub *rsa.PublicKey) bool {
+	if b == nil || pub == nil || pub.N == nil {
+		return false
+	}
+
+	_, ok := b.entries[modulusHash(pub)]
+
+	return ok
+}
+
+// add is a helper method that adds the entries from the given blocklist
+// data.
+func (b *Blocklist) add(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var entry [blocklistEntryLen / 2]byte
+
+		decoded, err := hex.DecodeString(line)
+		if err != nil || len(decoded) != len(entry) {
+			return fmt.Errorf("line %d: %w", lineNum, ErrInvalidBlocklist)
+		}
+
+		copy(entry[:], decoded)
+		b.entries[entry] = struct{}{}
+	}
+
+	return scanner.Err()
+}
+
+// modulusHash is a helper function that returns the blocklist entry for the
+// given RSA public key.
+func modulusHash(pub *rsa.PublicKey) [blocklistEntryLen / 2]byte {
+	sum := sha1.Sum([]byte(fmt.Sprintf("Modulus=%X\n", pub.N))) //nolint:gosec // required by the blocklist format
+
+	var entry [blocklistEntryLen / 2]byte
+	copy(entry[:], sum[len(sum)-len(entry):])
+
+	return entry
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package weakkeys_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/atc0005/cert-payload/internal/weakkeys"
+)
+
+// The testdata/blacklist.RSA-2048 fixture lists the public key in
+// testdata/fixture_rsa2048.pem. The entry was generated the same way as the
+// entries of the openssl-blacklist package (see openssl-vulnkey):
+//
+//	openssl rsa -noout -modulus | sha1sum | cut -c 21-40
+const fixtureBlocklist = "testdata/blacklist.RSA-2048"
+
+// loadPublicKeys returns the RSA public keys from the given PEM file.
+func loadPublicKeys(t *testing.T, filename string) []*rsa.PublicKey {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []*rsa.PublicKey
+
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			t.Fatalf("unexpected public key type %T", pub)
+		}
+
+		keys = append(keys, rsaPub)
+	}
+
+	if len(keys) == 0 {
+		t.Fatalf("no public keys found in %s", filename)
+	}
+
+	return keys
+}
+
+// generateKey returns the public key of a newly generated RSA key.
+func generateKey(t testing.TB, bits int) *rsa.PublicKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &key.PublicKey
+}
+
+func TestBlocklistContains(t *testing.T) {
+	data, err := os.ReadFile(fixtureBlocklist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocklist, err := weakkeys.ParseBlocklist(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if blocklist.Len() != 1 {
+		t.Fatalf("got %d entries, want 1", blocklist.Len())
+	}
+
+	tests := []struct {
+		name string
+		pub  *rsa.PublicKey
+		want bool
+	}{
+		{
+			name: "listed modulus",
+			pub:  loadPublicKeys(t, "testdata/fixture_rsa2048.pem")[0],
+			want: true,
+		},
+		{
+			name: "clean modulus",
+			pub:  generateKey(t, 2048),
+			want: false,
+		},
+		{
+			name: "nil key",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blocklist.Contains(tt.pub); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// knownWeakModulus is the modulus of a Debian weak RSA-2048 key listed in the
+// embedded blocklist (see blocklists/boulder-known.RSA-2048).
+const knownWeakModulus = "D673252AF6723C3F72529403EAB7C30DEF3C52F97E799825F4A70191C616ADCF" +
+	"1ECE1113F1625971074C492C592025FDEADBDB146A081826BDF0D77C3C913DCF" +
+	"1B6F0B3B78F5108D2E493AD0EEE8CA5C021711ADC13D358E61133870FCD19C8E" +
+	"5C22403959782AA82E72AEE53A3D491E3912CE27B27E1A85EA69C19A527D28F7" +
+	"934C9823B7E56FDD657DAC83FDC65BB22A98D843DF73238919781B714C81A5E2" +
+	"AFEC71F5C54AA2A27C590AD94C03C1062D50EFCFFAC743E3C8A3AE056846A1D7" +
+	"56EB862BF4224169D467C35215ADE0AFCC11E85FE629AFB802C4786FF2E9C929" +
+	"BCCF502B3D3B8876C6A11785CC398B389F1D86BDD9CB0BD4EC13956EC3FA270D"
+
+func TestDefaultBlocklistContainsKnownWeakKey(t *testing.T) {
+	modulus, err := hex.DecodeString(knownWeakModulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocklist, err := weakkeys.DefaultBlocklist()
+	if err != nil {
+		t.Fatalf("error parsing embedded blocklist: %v", err)
+	}
+
+	if blocklist.Len() == 0 {
+		t.Fatal("embedded blocklist is empty")
+	}
+
+	weak := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537}
+	if !blocklist.Contains(weak) {
+		t.Error("known weak modulus not found in embedded blocklist")
+	}
+
+	if blocklist.Contains(generateKey(t, 2048)) {
+		t.Error("embedded blocklist contains a newly generated key")
+	}
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	listed := loadPublicKeys(t, "testdata/fixture_rsa2048.pem")[0]
+	clean := generateKey(t, 2048)
+
+	base, err := weakkeys.DefaultBlocklist()
+	if err != nil {
+		t.Fatalf("error parsing embedded blocklist: %v", err)
+	}
+
+	if base.Contains(clean) {
+		t.Error("embedded blocklist contains a newly generated key")
+	}
+
+	blocklist, err := weakkeys.LoadBlocklist([]string{fixtureBlocklist})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	switch {
+	case blocklist.Len() != base.Len()+1:
+		t.Errorf("got %d entries, want %d", blocklist.Len(), base.Len()+1)
+	case !blocklist.Contains(listed):
+		t.Error("listed modulus not found")
+	case blocklist.Contains(clean):
+		t.Error("clean modulus found")
+	}
+
+	// The embedded blocklist is not modified by loading additional files.
+	if base.Contains(listed) {
+		t.Error("embedded blocklist modified by LoadBlocklist")
+	}
+}
+
+func TestParseBlocklistInvalid(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid")
+	if err := os.WriteFile(invalid, []byte("# Keysize: 2048\nnot-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := weakkeys.LoadBlocklist([]string{invalid}); !errors.Is(err, weakkeys.ErrInvalidBlocklist) {
+		t.Errorf("got error %v, want %v", err, weakkeys.ErrInvalidBlocklist)
+	}
+
+	if _, err := weakkeys.ParseBlocklist([]byte("974305ea886b7bac62\n")); !errors.Is(err, weakkeys.ErrInvalidBlocklist) {
+		t.Errorf("got error %v, want %v", err, weakkeys.ErrInvalidBlocklist)
+	}
+}
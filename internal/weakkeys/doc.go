@@ -0,0 +1,10 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package weakkeys provides common/shared utility code to detect RSA public
+// keys which are known to be vulnerable due to flawed key generation.
+package weakkeys
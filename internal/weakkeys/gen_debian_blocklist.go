@@ -0,0 +1,179 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+//go:build ignore
+
+// This program generates debian_weak_keys.txt from one or more Debian weak
+// key blocklists. Each input file is either a blacklist.RSA-* file of the
+// Debian/Ubuntu openssl-blacklist package or a JSON list of entries (e.g.,
+// the flattened lists used by the Boulder ACME CA). All files of a given
+// directory are used.
+//
+// Usage:
+//
+//	go run gen_debian_blocklist.go -o debian_weak_keys.txt PATH [PATH...]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// entryLen is the length in hex digits of a blocklist entry.
+const entryLen int = 20
+
+const header = `# Code generated by gen_debian_blocklist.go; DO NOT EDIT.
+#
+# Debian weak key blocklist (CVE-2008-0166).
+#
+# Each entry is the last 20 hex digits of the SHA-1 hash of the line
+# "Modulus=<uppercase hex modulus>\n" for a RSA key generated by the
+# vulnerable Debian OpenSSL package. This is the format used by the
+# blacklist.RSA-* files of the Debian/Ubuntu openssl-blacklist package.
+#
+# To regenerate with the full RSA-1024, RSA-2048 and RSA-4096 sets, copy the
+# blacklist.RSA-* files of an installed openssl-blacklist package
+# (/usr/share/openssl-blacklist) into the blocklists directory and run
+# "go generate".
+#
+# Sources:
+`
+
+func main() {
+	output := flag.String("o", "debian_weak_keys.txt", "output file")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("no blocklist files specified")
+	}
+
+	files, err := expand(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entries := make(map[string]struct{})
+	var sources []string
+
+	for _, filename := range files {
+		data, err := os.ReadFile(filename) // #nosec G304 -- generator input
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fileEntries, err := parse(data)
+		if err != nil {
+			log.Fatalf("error parsing %s: %v", filename, err)
+		}
+
+		for _, entry := range fileEntries {
+			entries[entry] = struct{}{}
+		}
+
+		sources = append(sources, fmt.Sprintf("#   %s (entries: %d)", filepath.ToSlash(filename), len(fileEntries)))
+	}
+
+	sorted := make([]string, 0, len(entries))
+	for entry := range entries {
+		sorted = append(sorted, entry)
+	}
+
+	sort.Strings(sorted)
+
+	var buf bytes.Buffer
+
+	buf.WriteString(header)
+	buf.WriteString(strings.Join(sources, "\n"))
+	buf.WriteString("\n\n")
+
+	for _, entry := range sorted {
+		buf.WriteString(entry)
+		buf.WriteString("\n")
+	}
+
+	if err := os.WriteFile(*output, buf.Bytes(), 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// expand returns the given paths with directories replaced by the files
+// they contain.
+func expand(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirEntries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dirEntry := range dirEntries {
+			if !dirEntry.IsDir() {
+				files = append(files, filepath.Join(path, dirEntry.Name()))
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// parse returns the validated, lowercase entries from the given blocklist
+// data.
+func parse(data []byte) ([]string, error) {
+	var lines []string
+
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &lines); err != nil {
+			return nil, err
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]string, 0, len(lines))
+
+	for i, line := range lines {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, err := hex.DecodeString(line); err != nil || len(line) != entryLen {
+			return nil, fmt.Errorf("entry %d: invalid entry %q", i+1, line)
+		}
+
+		entries = append(entries, line)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package weakkeys
+
+import (
+	"crypto/rsa"
+	"math/big"
+)
+
+// rocaGenerator is the generator used by the vulnerable Infineon RSA Library
+// to construct primes.
+const rocaGenerator int64 = 65537
+
+// rocaPrimes is the list of small primes used to fingerprint RSA moduli
+// generated by the vulnerable Infineon RSA Library.
+//
+// https://github.com/crocs-muni/roca
+var rocaPrimes = []int64{
+	11, 13, 17, 19, 37, 53, 61, 71, 73, 79, 97, 103, 107, 109, 127, 151, 157,
+}
+
+// rocaFingerprints is the list of residues generated by rocaGenerator
+// modulo each prime in rocaPrimes.
+var rocaFingerprints = func() []map[int64]bool {
+	fingerprints := make([]map[int64]bool, 0, len(rocaPrimes))
+
+	for _, prime := range rocaPrimes {
+		residues := make(map[int64]bool)
+
+		g := rocaGenerator % prime
+		for r := int64(1); !residues[r]; r = (r * g) % prime {
+			residues[r] = true
+		}
+
+		fingerprints = append(fingerprints, residues)
+	}
+
+	return fingerprints
+}()
+
+// IsROCAVulnerable indicates whether the given RSA public key matches the
+// fingerprint of keys generated by the Infineon RSA Library which are
+// vulnerable to factorization via the Return of Coppersmith's Attack (ROCA;
+// CVE-2017-15361).
+//
+// Primes generated by the library have the form k*M + (65537^a mod M) where
+// M is a primorial, so the modulus modulo each small prime dividing M is a
+// power of 65537. The false positive rate of this test is negligible.
+func IsROCAVulnerable(pub *rsa.PublicKey) bool {
+	if pub == nil || pub.N == nil {
+		return false
+	}
+
+	residue := new(big.Int)
+
+	for i, prime := range rocaPrimes {
+		residue.Mod(pub.N, big.NewInt(prime))
+
+		if !rocaFingerprints[i][residue.Int64()] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package weakkeys_test
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"testing"
+
+	"github.com/atc0005/cert-payload/internal/weakkeys"
+)
+
+func TestIsROCAVulnerable(t *testing.T) {
+	type testCase struct {
+		name string
+		pub  *rsa.PublicKey
+		want bool
+	}
+
+	var tests []testCase
+
+	for i, pub := range loadPublicKeys(t, "testdata/roca_vulnerable.pem") {
+		tests = append(tests, testCase{
+			name: fmt.Sprintf("published Infineon key %d", i),
+			pub:  pub,
+			want: true,
+		})
+	}
+
+	for i, pub := range loadPublicKeys(t, "testdata/roca_not_vulnerable.pem") {
+		tests = append(tests, testCase{
+			name: fmt.Sprintf("published unaffected key %d", i),
+			pub:  pub,
+		})
+	}
+
+	for _, bits := range []int{1024, 2048} {
+		tests = append(tests, testCase{
+			name: fmt.Sprintf("generated %d-bit key", bits),
+			pub:  generateKey(t, bits),
+		})
+	}
+
+	tests = append(tests, testCase{name: "nil key"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weakkeys.IsROCAVulnerable(tt.pub); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
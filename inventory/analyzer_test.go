@@ -0,0 +1,308 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package inventory_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/inventory"
+)
+
+// testIssuer signs certificates for the given RSA public keys.
+type testIssuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) testIssuer {
+	t.Helper()
+
+	cert, key := testutil.IssueCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Inventory CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	return testIssuer{cert: cert, key: key}
+}
+
+// pemCert returns a PEM encoded certificate for the given modulus.
+func (ti testIssuer) pemCert(t *testing.T, serial int64, modulus *big.Int) string {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "host.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(0, 3, 0),
+	}
+
+	pub := &rsa.PublicKey{N: modulus, E: 65537}
+
+	cert := testutil.IssueCertForKey(t, tmpl, ti.cert, ti.key, pub)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// modulusHash returns the ModulusSHA256 value for the given modulus.
+func modulusHash(modulus *big.Int) string {
+	sum := sha256.Sum256(modulus.Bytes())
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSharedFactors(t *testing.T) {
+	primes := make([]*big.Int, 6)
+	for i := range primes {
+		p, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		primes[i] = p
+	}
+
+	mul := func(a, b int) *big.Int { return new(big.Int).Mul(primes[a], primes[b]) }
+
+	// Both factors of the first modulus are shared: p0 with the second
+	// modulus and p1 with the third. The fourth modulus is unaffected.
+	bothShared := mul(0, 1)
+	firstShared := mul(0, 2)
+	secondShared := mul(1, 3)
+	clean := mul(4, 5)
+
+	issuer := newTestIssuer(t)
+	analyzer := inventory.NewAnalyzer()
+
+	chains := []struct {
+		endpoint inventory.Endpoint
+		moduli   []*big.Int
+	}{
+		{inventory.Endpoint{Host: "a.example.com", Port: 443}, []*big.Int{bothShared}},
+		{inventory.Endpoint{Host: "b.example.com", Port: 443}, []*big.Int{firstShared, clean}},
+		{inventory.Endpoint{Host: "c.example.com", Port: 443}, []*big.Int{secondShared}},
+
+		// A reused key is analyzed once.
+		{inventory.Endpoint{IPAddress: "192.0.2.10", Port: 8443}, []*big.Int{bothShared}},
+	}
+
+	var serial int64
+	for _, chain := range chains {
+		var pemCerts []string
+		for _, modulus := range chain.moduli {
+			serial++
+			pemCerts = append(pemCerts, issuer.pemCert(t, serial, modulus))
+		}
+
+		if err := analyzer.AddPEMChain(chain.endpoint, pemCerts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	report := analyzer.SharedFactors()
+
+	if report.KeysAnalyzed != 4 || report.CertificatesAnalyzed != 5 {
+		t.Errorf(
+			"got %d keys and %d certificates analyzed, want 4 and 5",
+			report.KeysAnalyzed,
+			report.CertificatesAnalyzed,
+		)
+	}
+
+	sorted := func(values ...string) []string {
+		sort.Strings(values)
+		return values
+	}
+
+	want := map[string][]string{
+		modulusHash(bothShared):   sorted(modulusHash(firstShared), modulusHash(secondShared)),
+		modulusHash(firstShared):  {modulusHash(bothShared)},
+		modulusHash(secondShared): {modulusHash(bothShared)},
+	}
+
+	if len(report.AffectedKeys) != len(want) {
+		t.Fatalf("got %d affected keys, want %d", len(report.AffectedKeys), len(want))
+	}
+
+	for _, key := range report.AffectedKeys {
+		wantShared, ok := want[key.ModulusSHA256]
+		if !ok {
+			t.Errorf("unexpected affected key %s", key.ModulusSHA256)
+			continue
+		}
+
+		if len(key.SharesFactorWith) != len(wantShared) {
+			t.Errorf("key %s: got shares factor with %v, want %v", key.ModulusSHA256, key.SharesFactorWith, wantShared)
+			continue
+		}
+
+		for i := range wantShared {
+			if key.SharesFactorWith[i] != wantShared[i] {
+				t.Errorf("key %s: got shares factor with %v, want %v", key.ModulusSHA256, key.SharesFactorWith, wantShared)
+				break
+			}
+		}
+
+		if key.Bits != 1024 {
+			t.Errorf("key %s: got %d bits, want 1024", key.ModulusSHA256, key.Bits)
+		}
+	}
+
+	if got := len(report.AffectedCertificates()); got != 4 {
+		t.Errorf("got %d affected certificates, want 4", got)
+	}
+
+	if got := len(report.AffectedEndpoints()); got != 4 {
+		t.Errorf("got %d affected endpoints, want 4", got)
+	}
+}
+
+func TestSharedFactorsNoKeys(t *testing.T) {
+	report := inventory.NewAnalyzer().SharedFactors()
+
+	if report.HasAffectedKeys() || report.KeysAnalyzed != 0 {
+		t.Errorf("unexpected report for empty analyzer: %+v", report)
+	}
+}
+
+func TestAddPEMChain(t *testing.T) {
+	primes := make([]*big.Int, 3)
+	for i := range primes {
+		p, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		primes[i] = p
+	}
+
+	// The moduli share the first prime factor.
+	first := new(big.Int).Mul(primes[0], primes[1])
+	second := new(big.Int).Mul(primes[0], primes[2])
+
+	issuer := newTestIssuer(t)
+	firstPEM := issuer.pemCert(t, 1, first)
+	secondPEM := issuer.pemCert(t, 2, second)
+
+	endpoint := inventory.Endpoint{Host: "a.example.com", Port: 443}
+	otherEndpoint := inventory.Endpoint{Host: "b.example.com", Port: 443}
+
+	type chain struct {
+		endpoint inventory.Endpoint
+		pemCerts []string
+		wantErr  bool
+	}
+
+	tests := []struct {
+		name             string
+		chains           []chain
+		wantKeys         int
+		wantCertificates int
+		wantAffected     int
+	}{
+		{
+			name: "invalid certificate after valid certificate",
+			chains: []chain{
+				{endpoint: endpoint, pemCerts: []string{firstPEM, "not a certificate"}, wantErr: true},
+				{endpoint: otherEndpoint, pemCerts: []string{secondPEM}},
+			},
+			wantKeys:         1,
+			wantCertificates: 1,
+		},
+		{
+			name: "chain added twice",
+			chains: []chain{
+				{endpoint: endpoint, pemCerts: []string{firstPEM, secondPEM}},
+				{endpoint: endpoint, pemCerts: []string{firstPEM, secondPEM}},
+			},
+			wantKeys:         2,
+			wantCertificates: 2,
+			wantAffected:     2,
+		},
+		{
+			name: "same chain at different endpoints",
+			chains: []chain{
+				{endpoint: endpoint, pemCerts: []string{firstPEM, secondPEM}},
+				{endpoint: otherEndpoint, pemCerts: []string{firstPEM, secondPEM}},
+			},
+			wantKeys:         2,
+			wantCertificates: 4,
+			wantAffected:     4,
+		},
+		{
+			name: "renewed certificate with new key at same endpoint",
+			chains: []chain{
+				{endpoint: endpoint, pemCerts: []string{firstPEM}},
+				{endpoint: endpoint, pemCerts: []string{secondPEM}},
+			},
+			wantKeys:         2,
+			wantCertificates: 2,
+			wantAffected:     2,
+		},
+		{
+			name: "different chains without endpoint",
+			chains: []chain{
+				{pemCerts: []string{firstPEM}},
+				{pemCerts: []string{secondPEM}},
+			},
+			wantKeys:         2,
+			wantCertificates: 2,
+			wantAffected:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := inventory.NewAnalyzer()
+
+			for _, c := range tt.chains {
+				err := analyzer.AddPEMChain(c.endpoint, c.pemCerts)
+
+				switch {
+				case c.wantErr && !errors.Is(err, inventory.ErrInvalidPEMCert):
+					t.Fatalf("got error %v, want %v", err, inventory.ErrInvalidPEMCert)
+				case !c.wantErr && err != nil:
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			report := analyzer.SharedFactors()
+
+			if report.KeysAnalyzed != tt.wantKeys || report.CertificatesAnalyzed != tt.wantCertificates {
+				t.Errorf(
+					"got %d keys and %d certificates analyzed, want %d and %d",
+					report.KeysAnalyzed,
+					report.CertificatesAnalyzed,
+					tt.wantKeys,
+					tt.wantCertificates,
+				)
+			}
+
+			if got := len(report.AffectedCertificates()); got != tt.wantAffected {
+				t.Errorf("got %d affected certificates, want %d", got, tt.wantAffected)
+			}
+		})
+	}
+}
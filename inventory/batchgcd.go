@@ -0,0 +1,115 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package inventory
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+// batchGCD computes the greatest common divisor of each given modulus and
+// the product of all other given moduli using a product tree and a
+// remainder tree (Bernstein's batch GCD). A result greater than one
+// indicates that the modulus shares a factor with at least one other
+// modulus. The given moduli must be unique.
+//
+// The cost is quasi-linear in the total size of the moduli instead of
+// quadratic in their number as with pairwise GCD.
+func batchGCD(moduli []*big.Int) []*big.Int {
+	gcds := make([]*big.Int, len(moduli))
+	if len(moduli) == 0 {
+		return gcds
+	}
+
+	tree := productTree(moduli)
+
+	// Descend the tree, reducing the product of all moduli modulo the
+	// square of each node until the leaves are reached.
+	remainders := tree[len(tree)-1]
+	for level := len(tree) - 2; level >= 0; level-- {
+		nodes := tree[level]
+		parents := remainders
+		next := make([]*big.Int, len(nodes))
+
+		parallel(len(nodes), func(i int) {
+			square := new(big.Int).Mul(nodes[i], nodes[i])
+			next[i] = square.Mod(parents[i/2], square)
+		})
+
+		remainders = next
+
+		// The level above is no longer needed.
+		tree[level+1] = nil
+	}
+
+	parallel(len(moduli), func(i int) {
+		quotient := new(big.Int).Quo(remainders[i], moduli[i])
+		gcds[i] = quotient.GCD(nil, nil, quotient, moduli[i])
+	})
+
+	return gcds
+}
+
+// productTree is a helper function that returns the levels of the product
+// tree for the given values, starting with the values themselves and ending
+// with the product of all values.
+func productTree(values []*big.Int) [][]*big.Int {
+	tree := [][]*big.Int{values}
+
+	for level := values; len(level) > 1; {
+		prev := level
+		next := make([]*big.Int, (len(prev)+1)/2)
+
+		parallel(len(next), func(i int) {
+			if 2*i+1 < len(prev) {
+				next[i] = new(big.Int).Mul(prev[2*i], prev[2*i+1])
+				return
+			}
+
+			next[i] = prev[2*i]
+		})
+
+		tree = append(tree, next)
+		level = next
+	}
+
+	return tree
+}
+
+// parallel is a helper function that calls fn for each index from 0 to n-1
+// using one worker per available CPU.
+func parallel(n int, fn func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+
+	indexes := make(chan int)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package inventory
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// testPrimes returns n distinct random primes of the given size.
+func testPrimes(t testing.TB, n int, bits int) []*big.Int {
+	t.Helper()
+
+	primes := make([]*big.Int, 0, n)
+	seen := make(map[string]bool, n)
+
+	for len(primes) < n {
+		p, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen[p.String()] {
+			continue
+		}
+
+		seen[p.String()] = true
+		primes = append(primes, p)
+	}
+
+	return primes
+}
+
+// naiveGCDs returns the greatest common divisor of each given modulus and
+// the product of all other given moduli.
+func naiveGCDs(moduli []*big.Int) []*big.Int {
+	gcds := make([]*big.Int, len(moduli))
+
+	for i := range moduli {
+		others := big.NewInt(1)
+		for j, m := range moduli {
+			if j != i {
+				others.Mul(others, m)
+			}
+		}
+
+		gcds[i] = new(big.Int).GCD(nil, nil, moduli[i], others)
+	}
+
+	return gcds
+}
+
+func TestBatchGCD(t *testing.T) {
+	p := testPrimes(t, 8, 256)
+	mul := func(a, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }
+	one := big.NewInt(1)
+
+	tests := []struct {
+		name   string
+		moduli []*big.Int
+		want   []*big.Int
+	}{
+		{
+			name: "no moduli",
+		},
+		{
+			name:   "single modulus",
+			moduli: []*big.Int{mul(p[0], p[1])},
+			want:   []*big.Int{one},
+		},
+		{
+			name:   "no shared factors",
+			moduli: []*big.Int{mul(p[0], p[1]), mul(p[2], p[3]), mul(p[4], p[5])},
+			want:   []*big.Int{one, one, one},
+		},
+		{
+			name:   "one shared factor",
+			moduli: []*big.Int{mul(p[0], p[1]), mul(p[2], p[3]), mul(p[0], p[4])},
+			want:   []*big.Int{p[0], one, p[0]},
+		},
+		{
+			// The first modulus shares p0 with the second modulus and p1
+			// with the third modulus; the result is the modulus itself.
+			name: "both factors shared",
+			moduli: []*big.Int{
+				mul(p[0], p[1]),
+				mul(p[0], p[2]),
+				mul(p[1], p[3]),
+				mul(p[4], p[5]),
+			},
+			want: []*big.Int{mul(p[0], p[1]), p[0], p[1], one},
+		},
+		{
+			name: "odd number of moduli",
+			moduli: []*big.Int{
+				mul(p[0], p[1]),
+				mul(p[2], p[3]),
+				mul(p[4], p[5]),
+				mul(p[6], p[7]),
+				mul(p[3], p[6]),
+			},
+			want: []*big.Int{one, p[3], one, p[6], mul(p[3], p[6])},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchGCD(tt.moduli)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(got), len(tt.want))
+			}
+
+			for i := range got {
+				if got[i].Cmp(tt.want[i]) != 0 {
+					t.Errorf("modulus %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBatchGCDMatchesNaive(t *testing.T) {
+	primes := testPrimes(t, 48, 128)
+
+	// Build 33 moduli (an uneven product tree) from a pool of primes where
+	// every fourth modulus reuses a factor of the modulus before it.
+	moduli := make([]*big.Int, 0, 33)
+	for i := 0; len(moduli) < cap(moduli); i++ {
+		a, b := primes[(2*i)%len(primes)], primes[(2*i+1)%len(primes)]
+		if i%4 == 3 {
+			a = primes[(2*i-1)%len(primes)]
+		}
+
+		moduli = append(moduli, new(big.Int).Mul(a, b))
+	}
+
+	got := batchGCD(moduli)
+	want := naiveGCDs(moduli)
+
+	for i := range moduli {
+		if got[i].Cmp(want[i]) != 0 {
+			t.Errorf("modulus %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// BenchmarkBatchGCD measures the batch GCD computation for 2048-bit moduli.
+// Run with -cpu 1 to measure a single CPU:
+//
+//	go test -run '^$' -bench BatchGCD -benchtime 1x -cpu 1 ./inventory
+func BenchmarkBatchGCD(b *testing.B) {
+	for _, n := range []int{1000, 10000, 20000} {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			// Random odd 2048-bit values stand in for RSA moduli; the cost
+			// of the computation depends on the size of the values only.
+			moduli := make([]*big.Int, n)
+			limit := new(big.Int).Lsh(big.NewInt(1), 2047)
+
+			for i := range moduli {
+				v, err := rand.Int(rand.Reader, limit)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				moduli[i] = v.SetBit(v.SetBit(v, 2047, 1), 0, 1)
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				batchGCD(moduli)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package inventory provides analysis across a collection of certificate
+// metadata payloads (e.g., every payload collected by a monitoring system).
+//
+// The shared factor analysis collects the RSA public keys from the original
+// certificate chain (cert_chain_original) of each payload and uses a batch
+// GCD computation to identify RSA moduli which share a prime factor with
+// another modulus. Such keys can be trivially factored and usually point to
+// flawed key generation (e.g., insufficient entropy on embedded devices).
+// Payloads must be generated with the full certificate chain included for
+// their keys to be analyzed.
+package inventory
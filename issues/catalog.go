@@ -0,0 +1,303 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package issues
+
+import (
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// Issue severity values. These values match the service state labels used by
+// a certificate metadata payload; SeverityInfo issues do not affect the
+// service state.
+const (
+	SeverityCritical string = certs.StateCRITICALLabel
+	SeverityWarning  string = certs.StateWARNINGLabel
+	SeverityInfo     string = "INFO"
+)
+
+// Stable issue codes. Once published these values are not changed.
+const (
+	CodeMissingIntermediateCerts string = "CHAIN_MISSING_INTERMEDIATE"
+	CodeMissingSANsEntries       string = "LEAF_MISSING_SANS"
+	CodeDuplicateCerts           string = "CHAIN_DUPLICATE_CERTS"
+	CodeMisorderedCerts          string = "CHAIN_MISORDERED_CERTS"
+	CodeExpiredCerts             string = "CHAIN_EXPIRED_CERTS"
+	CodeHostnameMismatch         string = "LEAF_HOSTNAME_MISMATCH"
+	CodeSelfSignedLeafCert       string = "LEAF_SELF_SIGNED"
+	CodeWeakSignatureAlgorithm   string = "CHAIN_WEAK_SIGNATURE_ALGORITHM"
+	CodeNotYetValidCerts         string = "CHAIN_NOT_YET_VALID_CERTS"
+	CodeLeafOutlivesIssuer       string = "LEAF_OUTLIVES_ISSUER"
+	CodeUntrustedChain           string = "CHAIN_UNTRUSTED"
+	CodeRevokedCerts             string = "CHAIN_REVOKED_CERTS"
+	CodeMissingOCSPStaple        string = "LEAF_MUST_STAPLE_MISSING"
+	CodeExpiringCrossSign        string = "CHAIN_CROSS_SIGN_EXPIRING"
+	CodeWeakKey                  string = "CHAIN_WEAK_KEY"
+	CodeROCAVulnerableKey        string = "CHAIN_ROCA_VULNERABLE_KEY"
+	CodeDebianWeakKey            string = "CHAIN_DEBIAN_WEAK_KEY"
+	CodeRenewalOverdue           string = "LEAF_RENEWAL_OVERDUE"
+)
+
+// Definition describes a certificate chain issue.
+type Definition struct {
+	// Name is the name of the issue as used by the boolean certificate chain
+	// issue fields of a payload (e.g., `expired_certs`).
+	Name string
+
+	// Code is the stable identifier for the issue (e.g.,
+	// `CHAIN_MISSING_INTERMEDIATE`).
+	Code string
+
+	// Severity is the default severity of the issue (e.g., CRITICAL).
+	Severity string
+
+	// Description is a short description of the issue.
+	Description string
+
+	// Remediation is guidance for resolving the issue.
+	Remediation string
+}
+
+// Catalog returns the collection of all known certificate chain issue
+// definitions. Definitions are returned in the same order as the boolean
+// certificate chain issue fields of a payload.
+func Catalog() []Definition {
+	return []Definition{
+		{
+			Name:        input.IssueMissingIntermediateCerts,
+			Code:        CodeMissingIntermediateCerts,
+			Severity:    SeverityWarning,
+			Description: "Intermediate certificates are missing from the certificate chain.",
+			Remediation: "Configure the service to send the full certificate chain " +
+				"(leaf and all intermediate certificates) as provided by the issuing CA.",
+		},
+		{
+			Name:        input.IssueMissingSANsEntries,
+			Code:        CodeMissingSANsEntries,
+			Severity:    SeverityCritical,
+			Description: "The leaf certificate does not contain Subject Alternate Names (SANs) entries.",
+			Remediation: "Replace the certificate with one which lists all valid " +
+				"host names in the Subject Alternate Names extension; most clients " +
+				"no longer consult the Common Name field.",
+		},
+		{
+			Name:        input.IssueDuplicateCerts,
+			Code:        CodeDuplicateCerts,
+			Severity:    SeverityWarning,
+			Description: "One or more certificates are present more than once in the certificate chain.",
+			Remediation: "Remove the duplicate certificates from the certificate " +
+				"chain file or bundle used by the service.",
+		},
+		{
+			Name:        input.IssueMisorderedCerts,
+			Code:        CodeMisorderedCerts,
+			Severity:    SeverityWarning,
+			Description: "Certificates in the chain are not in the expected leaf to root order.",
+			Remediation: "Reorder the certificate chain so that each certificate " +
+				"is followed by the certificate which issued it, starting with the " +
+				"leaf certificate.",
+		},
+		{
+			Name:        input.IssueExpiredCerts,
+			Code:        CodeExpiredCerts,
+			Severity:    SeverityCritical,
+			Description: "One or more certificates in the chain have expired.",
+			Remediation: "Renew the expired leaf certificate or replace expired " +
+				"intermediate certificates with current versions from the issuing CA.",
+		},
+		{
+			Name:        input.IssueHostnameMismatch,
+			Code:        CodeHostnameMismatch,
+			Severity:    SeverityCritical,
+			Description: "The leaf certificate is not valid for the host name used to connect to the service.",
+			Remediation: "Replace the certificate with one which lists the host " +
+				"name in the Subject Alternate Names extension or connect using a " +
+				"listed host name.",
+		},
+		{
+			Name:        input.IssueSelfSignedLeafCert,
+			Code:        CodeSelfSignedLeafCert,
+			Severity:    SeverityWarning,
+			Description: "The leaf certificate is self-signed.",
+			Remediation: "Replace the self-signed certificate with one issued by " +
+				"a public or internal certificate authority trusted by clients.",
+		},
+		{
+			Name:        input.IssueWeakSignatureAlgorithm,
+			Code:        CodeWeakSignatureAlgorithm,
+			Severity:    SeverityCritical,
+			Description: "A certificate in the chain is signed using a cryptographically weak signature algorithm.",
+			Remediation: "Replace certificates signed using MD2, MD5 or SHA1 based " +
+				"signature algorithms with certificates signed using SHA-256 or " +
+				"stronger.",
+		},
+		{
+			Name:        input.IssueNotYetValidCerts,
+			Code:        CodeNotYetValidCerts,
+			Severity:    SeverityCritical,
+			Description: "One or more certificates in the chain are not yet valid.",
+			Remediation: "Verify the system clock of the service and the evaluating " +
+				"system; if the clocks are correct, replace the certificate with one " +
+				"whose validity period has started or wait until it becomes valid.",
+		},
+		{
+			Name:        input.IssueLeafOutlivesIssuer,
+			Code:        CodeLeafOutlivesIssuer,
+			Severity:    SeverityWarning,
+			Description: "The leaf certificate expires after the certificate which issued it.",
+			Remediation: "Replace the issuing intermediate certificate with a current " +
+				"version from the issuing CA before it expires; the chain stops " +
+				"working when the issuer expires regardless of the leaf expiration.",
+		},
+		{
+			Name:        input.IssueUntrustedChain,
+			Code:        CodeUntrustedChain,
+			Severity:    SeverityCritical,
+			Description: "The certificate chain could not be verified to a trusted root certificate.",
+			Remediation: "Configure the service to send the intermediate certificates " +
+				"provided by the issuing CA or replace the certificate with one issued " +
+				"by a CA trusted by clients; for an internal PKI, add the root " +
+				"certificate to the CA bundle used for verification.",
+		},
+		{
+			Name:        input.IssueRevokedCerts,
+			Code:        CodeRevokedCerts,
+			Severity:    SeverityCritical,
+			Description: "One or more certificates in the chain have been revoked by the issuing CA.",
+			Remediation: "Replace the revoked leaf certificate or replace revoked " +
+				"intermediate certificates with current versions from the issuing CA.",
+		},
+		{
+			Name:        input.IssueMissingOCSPStaple,
+			Code:        CodeMissingOCSPStaple,
+			Severity:    SeverityCritical,
+			Description: "The leaf certificate requires OCSP stapling (must-staple) but the service did not staple an OCSP response.",
+			Remediation: "Enable OCSP stapling for the service and verify that the " +
+				"service can reach the OCSP responder of the issuing CA; clients " +
+				"which honor must-staple reject the connection without a staple.",
+		},
+		{
+			Name:        input.IssueExpiringCrossSign,
+			Code:        CodeExpiringCrossSign,
+			Severity:    SeverityWarning,
+			Description: "The served certificate chain reaches a trusted root only through a cross-signed intermediate certificate which is expiring or has expired.",
+			Remediation: "Serve the chain for the newer root instead of the cross-signed " +
+				"intermediate certificate, or confirm that clients trust the newer " +
+				"root before the cross-sign expires.",
+		},
+		{
+			Name:        input.IssueWeakKey,
+			Code:        CodeWeakKey,
+			Severity:    SeverityCritical,
+			Description: "A certificate in the chain has a public key which does not meet the key strength policy (e.g., RSA below 2048 bits, DSA, an unusual curve or a small RSA exponent).",
+			Remediation: "Replace the affected certificates with certificates for a new " +
+				"RSA key of at least 2048 bits (exponent 65537) or an ECDSA key on " +
+				"a widely supported curve such as P-256 or P-384.",
+		},
+		{
+			Name:        input.IssueROCAVulnerableKey,
+			Code:        CodeROCAVulnerableKey,
+			Severity:    SeverityCritical,
+			Description: "A certificate in the chain has a RSA key generated by the Infineon RSA Library which is vulnerable to factorization (ROCA, CVE-2017-15361).",
+			Remediation: "Update the firmware of the affected device or token, generate a " +
+				"new key pair outside of the vulnerable library and replace and " +
+				"revoke the affected certificates.",
+		},
+		{
+			Name:        input.IssueDebianWeakKey,
+			Code:        CodeDebianWeakKey,
+			Severity:    SeverityCritical,
+			Description: "A certificate in the chain has a RSA key generated by the vulnerable Debian OpenSSL package whose private key is publicly known (CVE-2008-0166).",
+			Remediation: "Generate a new key pair using an up-to-date OpenSSL package and " +
+				"replace and revoke the affected certificates.",
+		},
+		{
+			Name:        input.IssueRenewalOverdue,
+			Code:        CodeRenewalOverdue,
+			Severity:    SeverityWarning,
+			Description: "A leaf certificate has passed the point at which it was expected to be renewed by the renewal policy.",
+			Remediation: "Check the renewal job or ACME client for the certificate " +
+				"(e.g., failed challenges, expired credentials or a stopped " +
+				"scheduler) and renew the certificate.",
+		},
+	}
+}
+
+// Lookup returns the issue definition for the given issue name (e.g.,
+// `expired_certs`) and a boolean value indicating whether a definition was
+// found.
+func Lookup(name string) (Definition, bool) {
+	for _, def := range Catalog() {
+		if def.Name == name {
+			return def, true
+		}
+	}
+
+	return Definition{}, false
+}
+
+// LookupCode returns the issue definition for the given issue code (e.g.,
+// `CHAIN_EXPIRED_CERTS`) and a boolean value indicating whether a definition
+// was found.
+func LookupCode(code string) (Definition, bool) {
+	for _, def := range Catalog() {
+		if def.Code == code {
+			return def, true
+		}
+	}
+
+	return Definition{}, false
+}
+
+// FromNames returns the issue definitions for the given issue names, in the
+// order given. Unrecognized issue names are skipped. This is intended for
+// converting the boolean-only issue fields of earlier payload format
+// versions into a richer issue list.
+func FromNames(names []string) []Definition {
+	defs := make([]Definition, 0, len(names))
+
+	for _, name := range names {
+		if def, ok := Lookup(name); ok {
+			defs = append(defs, def)
+		}
+	}
+
+	return defs
+}
+
+// ServiceState returns the service state label (e.g., "WARNING") associated
+// with the given severity. Issues with SeverityInfo do not affect the service
+// state and map to "OK".
+func ServiceState(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return certs.StateCRITICALLabel
+	case SeverityWarning:
+		return certs.StateWARNINGLabel
+	case SeverityInfo:
+		return certs.StateOKLabel
+	default:
+		return certs.StateUNKNOWNLabel
+	}
+}
+
+// SeverityFromServiceState returns the severity associated with the given
+// service state label (e.g., "WARNING"). This is used to apply caller
+// specified service states for an issue to the issue severity.
+func SeverityFromServiceState(state string) string {
+	switch state {
+	case certs.StateCRITICALLabel:
+		return SeverityCritical
+	case certs.StateWARNINGLabel:
+		return SeverityWarning
+	case certs.StateOKLabel:
+		return SeverityInfo
+	default:
+		return state
+	}
+}
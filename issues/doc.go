@@ -0,0 +1,16 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package issues provides the catalogue of certificate chain issues which
+// may be detected when generating a certificate metadata payload.
+//
+// Each catalogue entry pairs the issue name used by the boolean certificate
+// chain issue fields of a payload (e.g., `expired_certs`) with a stable issue
+// code, a default severity, a short description and remediation guidance.
+// Client code may use this catalogue to convert the boolean-only issue
+// fields of earlier payload format versions into a richer issue list.
+package issues
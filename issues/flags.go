@@ -0,0 +1,90 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package issues
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Flag pairs the name of a certificate chain issue with a boolean value
+// indicating whether the issue was detected.
+type Flag struct {
+	// Name is the JSON field name of the issue (e.g., `expired_certs`).
+	Name string
+
+	// Detected indicates whether the issue was detected for the certificate
+	// chain.
+	Detected bool
+}
+
+// Flags returns each certificate chain issue provided by the given
+// certificate chain issues value (e.g., the CertificateChainIssues type of a
+// payload format version) paired with a boolean value indicating whether the
+// issue was detected.
+//
+// The boolean fields of the given value are matched to the issue catalogue
+// by JSON field name. Issues are returned in catalogue order and issues
+// without a matching field are omitted. These names are intended to remain
+// stable for use as metric labels or item keys by reporting and monitoring
+// tools.
+func Flags(chainIssues any) []Flag {
+	fields := boolFields(chainIssues)
+	flags := make([]Flag, 0, len(fields))
+
+	for _, def := range Catalog() {
+		if detected, ok := fields[def.Name]; ok {
+			flags = append(flags, Flag{Name: def.Name, Detected: detected})
+		}
+	}
+
+	return flags
+}
+
+// Detected returns the names of all detected certificate chain issues of
+// the given certificate chain issues value. See Flags for the supported
+// values.
+func Detected(chainIssues any) []string {
+	flags := Flags(chainIssues)
+	detected := make([]string, 0, len(flags))
+
+	for _, flag := range flags {
+		if flag.Detected {
+			detected = append(detected, flag.Name)
+		}
+	}
+
+	return detected
+}
+
+// boolFields is a helper function that returns the values of the boolean
+// fields of the given struct value indexed by JSON field name.
+func boolFields(value any) map[string]bool {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make(map[string]bool, v.NumField())
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Bool {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fields[name] = v.Field(i).Bool()
+	}
+
+	return fields
+}
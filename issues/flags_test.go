@@ -0,0 +1,106 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package issues_test
+
+import (
+	"reflect"
+	"testing"
+
+	format0 "github.com/atc0005/cert-payload/format/v0"
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/issues"
+)
+
+func TestFlags(t *testing.T) {
+	v1Names := []string{
+		input.IssueMissingIntermediateCerts,
+		input.IssueMissingSANsEntries,
+		input.IssueDuplicateCerts,
+		input.IssueMisorderedCerts,
+		input.IssueExpiredCerts,
+		input.IssueHostnameMismatch,
+		input.IssueSelfSignedLeafCert,
+		input.IssueWeakSignatureAlgorithm,
+	}
+
+	catalogNames := make([]string, 0, len(issues.Catalog()))
+	for _, def := range issues.Catalog() {
+		catalogNames = append(catalogNames, def.Name)
+	}
+
+	tests := []struct {
+		name         string
+		chainIssues  any
+		wantNames    []string
+		wantDetected []string
+	}{
+		{
+			name:         "format 0",
+			chainIssues:  format0.CertificateChainIssues{ExpiredCerts: true},
+			wantNames:    v1Names,
+			wantDetected: []string{input.IssueExpiredCerts},
+		},
+		{
+			name: "format 1",
+			chainIssues: format1.CertificateChainIssues{
+				MisorderedCerts:        true,
+				WeakSignatureAlgorithm: true,
+			},
+			wantNames:    v1Names,
+			wantDetected: []string{input.IssueMisorderedCerts, input.IssueWeakSignatureAlgorithm},
+		},
+		{
+			name: "format 2",
+			chainIssues: format2.CertificateChainIssues{
+				MissingSANsEntries: true,
+				RenewalOverdue:     true,
+			},
+			wantNames:    catalogNames,
+			wantDetected: []string{input.IssueMissingSANsEntries, input.IssueRenewalOverdue},
+		},
+		{
+			name:         "pointer",
+			chainIssues:  &format1.CertificateChainIssues{HostnameMismatch: true},
+			wantNames:    v1Names,
+			wantDetected: []string{input.IssueHostnameMismatch},
+		},
+		{
+			name:         "nil pointer",
+			chainIssues:  (*format1.CertificateChainIssues)(nil),
+			wantNames:    []string{},
+			wantDetected: []string{},
+		},
+		{
+			name:         "not a struct",
+			chainIssues:  true,
+			wantNames:    []string{},
+			wantDetected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := issues.Flags(tt.chainIssues)
+
+			names := make([]string, 0, len(flags))
+			for _, flag := range flags {
+				names = append(names, flag.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("got issue names %v, want %v", names, tt.wantNames)
+			}
+
+			if got := issues.Detected(tt.chainIssues); !reflect.DeepEqual(got, tt.wantDetected) {
+				t.Errorf("got detected issues %v, want %v", got, tt.wantDetected)
+			}
+		})
+	}
+}
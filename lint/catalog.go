@@ -0,0 +1,257 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package lint
+
+import (
+	"github.com/atc0005/cert-payload/input"
+)
+
+// Lint severity values.
+const (
+	// SeverityError indicates that a certificate violates a requirement.
+	SeverityError string = "ERROR"
+
+	// SeverityWarning indicates that a certificate does not follow a
+	// recommendation or violates a requirement commonly relaxed for the
+	// selected profile.
+	SeverityWarning string = "WARNING"
+
+	// SeverityNotice indicates a potential problem which does not violate a
+	// requirement for the selected profile.
+	SeverityNotice string = "NOTICE"
+)
+
+// Stable lint IDs. Once published these values are not changed.
+const (
+	IDSerialNotPositive          string = "CERT_SERIAL_NOT_POSITIVE"
+	IDSerialTooLong              string = "CERT_SERIAL_TOO_LONG"
+	IDSerialLowEntropy           string = "CERT_SERIAL_LOW_ENTROPY"
+	IDVersionNotV3               string = "CERT_VERSION_NOT_V3"
+	IDValidityInverted           string = "CERT_VALIDITY_INVERTED"
+	IDUnrecognizedCriticalExt    string = "CERT_UNRECOGNIZED_CRITICAL_EXTENSION"
+	IDEmptySubjectSANNotCritical string = "CERT_EMPTY_SUBJECT_SAN_NOT_CRITICAL"
+	IDMissingAuthorityKeyID      string = "CERT_MISSING_AUTHORITY_KEY_ID"
+	IDKeyCertSignWithoutCA       string = "CERT_KEY_CERT_SIGN_WITHOUT_CA"
+	IDKeyUsageInvalidForKeyType  string = "CERT_KEY_USAGE_INVALID_FOR_KEY_TYPE"
+	IDCAMissingSubjectKeyID      string = "CA_MISSING_SUBJECT_KEY_ID"
+	IDCAMissingBasicConstraints  string = "CA_MISSING_BASIC_CONSTRAINTS"
+	IDCABasicConstraintsNotCrit  string = "CA_BASIC_CONSTRAINTS_NOT_CRITICAL"
+	IDCAMissingKeyCertSign       string = "CA_MISSING_KEY_CERT_SIGN"
+	IDLeafIsCA                   string = "LEAF_IS_CA"
+	IDLeafMissingSAN             string = "LEAF_MISSING_SAN"
+	IDLeafCommonNameNotInSAN     string = "LEAF_COMMON_NAME_NOT_IN_SAN"
+	IDLeafInvalidDNSName         string = "LEAF_INVALID_DNS_NAME"
+	IDLeafMissingExtKeyUsage     string = "LEAF_MISSING_EXT_KEY_USAGE"
+	IDLeafAnyExtKeyUsage         string = "LEAF_ANY_EXT_KEY_USAGE"
+	IDLeafInternalName           string = "LEAF_INTERNAL_NAME"
+	IDLeafValidityPeriodExceeded string = "LEAF_VALIDITY_PERIOD_EXCEEDED"
+	IDLeafValidityPeriodUpcoming string = "LEAF_VALIDITY_PERIOD_EXCEEDS_UPCOMING_LIMIT"
+)
+
+// Definition describes a certificate lint.
+type Definition struct {
+	// ID is the stable identifier for the lint (e.g.,
+	// `CERT_SERIAL_TOO_LONG`).
+	ID string
+
+	// Description is a short description of the problem detected by the
+	// lint.
+	Description string
+
+	// Citation is the requirement checked by the lint (e.g., `RFC 5280,
+	// 4.1.2.2`).
+	Citation string
+
+	// Severities is the severity of a failed check for each lint profile
+	// (e.g., input.LintProfilePublicCA). The lint is not run for profiles
+	// which are not listed.
+	Severities map[string]string
+}
+
+// Catalog returns the collection of all known certificate lint definitions.
+func Catalog() []Definition {
+	return []Definition{
+		{
+			ID:          IDSerialNotPositive,
+			Description: "The serial number is not a positive integer.",
+			Citation:    "RFC 5280, 4.1.2.2",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDSerialTooLong,
+			Description: "The serial number is longer than 20 octets.",
+			Citation:    "RFC 5280, 4.1.2.2",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDSerialLowEntropy,
+			Description: "The serial number is too short to contain 64 bits of output from a CSPRNG.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1",
+			Severities:  allProfiles(SeverityNotice, SeverityError),
+		},
+		{
+			ID:          IDVersionNotV3,
+			Description: "The certificate is not a version 3 certificate.",
+			Citation:    "RFC 5280, 4.1.2.1",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDValidityInverted,
+			Description: "The certificate expires before it becomes valid.",
+			Citation:    "RFC 5280, 4.1.2.5",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDUnrecognizedCriticalExt,
+			Description: "The certificate contains a critical extension which is not recognized.",
+			Citation:    "RFC 5280, 4.2",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDEmptySubjectSANNotCritical,
+			Description: "The subject is empty and the Subject Alternative Name extension is missing or not marked critical.",
+			Citation:    "RFC 5280, 4.2.1.6",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDMissingAuthorityKeyID,
+			Description: "The certificate is not self-signed and does not contain the Authority Key Identifier extension.",
+			Citation:    "RFC 5280, 4.2.1.1",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDKeyCertSignWithoutCA,
+			Description: "The keyCertSign key usage is asserted but the basic constraints cA value is not.",
+			Citation:    "RFC 5280, 4.2.1.3",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDKeyUsageInvalidForKeyType,
+			Description: "The key usage extension asserts a usage which is not valid for the subject public key type.",
+			Citation:    "RFC 3279, 2.3; RFC 8410, 5; RFC 8813, 3",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDCAMissingSubjectKeyID,
+			Description: "The CA certificate does not contain the Subject Key Identifier extension.",
+			Citation:    "RFC 5280, 4.2.1.2",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDCAMissingBasicConstraints,
+			Description: "The CA certificate does not contain the basic constraints extension with the cA value asserted.",
+			Citation:    "RFC 5280, 4.2.1.9",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDCABasicConstraintsNotCrit,
+			Description: "The basic constraints extension of the CA certificate is not marked critical.",
+			Citation:    "RFC 5280, 4.2.1.9",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDCAMissingKeyCertSign,
+			Description: "The CA certificate does not contain the key usage extension with keyCertSign asserted.",
+			Citation:    "RFC 5280, 4.2.1.3",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDLeafIsCA,
+			Description: "The leaf certificate asserts the basic constraints cA value.",
+			Citation:    "RFC 5280, 4.2.1.9; CA/B Forum Baseline Requirements, 7.1.2.7.8",
+			Severities:  allProfiles(SeverityError, SeverityError),
+		},
+		{
+			ID:          IDLeafMissingSAN,
+			Description: "The leaf certificate does not contain the Subject Alternative Name extension.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1.2.7.12",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDLeafCommonNameNotInSAN,
+			Description: "The Common Name of the leaf certificate is not listed in the Subject Alternative Name extension.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1.4.3",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDLeafInvalidDNSName,
+			Description: "A DNS name in the Subject Alternative Name extension of the leaf certificate is not a valid host name or wildcard.",
+			Citation:    "RFC 5280, 4.2.1.6; CA/B Forum Baseline Requirements, 7.1.2.7.12",
+			Severities:  allProfiles(SeverityWarning, SeverityError),
+		},
+		{
+			ID:          IDLeafMissingExtKeyUsage,
+			Description: "The leaf certificate does not contain the extended key usage extension.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1.2.7.10",
+			Severities: map[string]string{
+				input.LintProfilePublicCA: SeverityError,
+			},
+		},
+		{
+			ID:          IDLeafAnyExtKeyUsage,
+			Description: "The leaf certificate asserts the anyExtendedKeyUsage extended key usage.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1.2.7.10",
+			Severities: map[string]string{
+				input.LintProfilePublicCA: SeverityError,
+			},
+		},
+		{
+			ID:          IDLeafInternalName,
+			Description: "The leaf certificate lists an internal name or reserved IP Address.",
+			Citation:    "CA/B Forum Baseline Requirements, 7.1.2.7.12",
+			Severities: map[string]string{
+				input.LintProfilePublicCA: SeverityError,
+			},
+		},
+		{
+			ID:          IDLeafValidityPeriodExceeded,
+			Description: "The validity period of the leaf certificate exceeds the maximum in force when it was issued.",
+			Citation:    "CA/B Forum Baseline Requirements, 6.3.2",
+			Severities: map[string]string{
+				input.LintProfilePublicCA: SeverityError,
+			},
+		},
+		{
+			ID: IDLeafValidityPeriodUpcoming,
+			Description: "The validity period of the leaf certificate exceeds a scheduled maximum; " +
+				"renewals using the same validity period will not be compliant.",
+			Citation: "CA/B Forum Baseline Requirements, 6.3.2; Ballot SC-081",
+			Severities: map[string]string{
+				input.LintProfilePublicCA: SeverityWarning,
+			},
+		},
+	}
+}
+
+// Lookup returns the lint definition for the given lint ID.
+func Lookup(id string) (Definition, bool) {
+	for _, def := range Catalog() {
+		if def.ID == id {
+			return def, true
+		}
+	}
+
+	return Definition{}, false
+}
+
+// Profiles returns the supported lint profiles which run lints.
+func Profiles() []string {
+	return []string{
+		input.LintProfileInternalPKI,
+		input.LintProfilePublicCA,
+	}
+}
+
+// allProfiles is a helper function that returns the severities for a lint
+// run by both the internal PKI and public CA profiles.
+func allProfiles(internalPKI string, publicCA string) map[string]string {
+	return map[string]string{
+		input.LintProfileInternalPKI: internalPKI,
+		input.LintProfilePublicCA:    publicCA,
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package lint provides conformance checks (lints) for the certificates in a
+// certificate chain along with the catalogue of supported lints.
+//
+// Each catalogue entry pairs a stable lint ID with a description, a citation
+// of the requirement being checked (e.g., RFC 5280 or the CA/Browser Forum
+// Baseline Requirements) and the severity of a failed check for each lint
+// profile. The lint profile (see the input package) selects the lints which
+// are run: the internal PKI profile applies the RFC 5280 checks with relaxed
+// severities while the public CA profile adds the Baseline Requirements
+// checks and uses strict severities.
+//
+// Lint results are informational and do not affect the service state of a
+// certificate metadata payload.
+package lint
@@ -0,0 +1,670 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package lint
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/certs"
+)
+
+// ErrUnknownProfile indicates that an unsupported lint profile was
+// specified.
+var ErrUnknownProfile = errors.New("unknown lint profile")
+
+// Extension OIDs evaluated directly as the parsed certificate does not
+// record whether they are marked critical.
+var (
+	oidExtensionSubjectAltName   = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+)
+
+// internalTLDs is the list of top-level domains reserved for or commonly
+// used by internal networks. Names using these cannot be validated by a
+// public CA.
+var internalTLDs = []string{
+	"corp",
+	"example",
+	"home",
+	"internal",
+	"intranet",
+	"invalid",
+	"lan",
+	"local",
+	"localdomain",
+	"localhost",
+	"private",
+	"test",
+}
+
+// Result is a failed lint for a certificate.
+type Result struct {
+	// ID is the stable identifier for the lint (e.g.,
+	// `CERT_SERIAL_TOO_LONG`).
+	ID string
+
+	// Severity is the severity of the failed lint for the selected profile
+	// (e.g., ERROR).
+	Severity string
+
+	// Details is a human readable explanation of why the lint failed.
+	Details string
+}
+
+// target is a certificate being linted along with its role in the
+// certificate chain.
+type target struct {
+	cert       *x509.Certificate
+	leaf       bool
+	ca         bool
+	selfSigned bool
+}
+
+// check is a lint implementation. An empty string is returned if the
+// certificate passes the lint or the lint does not apply to the
+// certificate, otherwise the details of the failure are returned.
+type check func(t target) string
+
+// checks is the lint implementation for each lint ID in the catalogue.
+var checks = map[string]check{
+	IDSerialNotPositive:          checkSerialNotPositive,
+	IDSerialTooLong:              checkSerialTooLong,
+	IDSerialLowEntropy:           checkSerialLowEntropy,
+	IDVersionNotV3:               checkVersionNotV3,
+	IDValidityInverted:           checkValidityInverted,
+	IDUnrecognizedCriticalExt:    checkUnrecognizedCriticalExt,
+	IDEmptySubjectSANNotCritical: checkEmptySubjectSANNotCritical,
+	IDMissingAuthorityKeyID:      checkMissingAuthorityKeyID,
+	IDKeyCertSignWithoutCA:       checkKeyCertSignWithoutCA,
+	IDKeyUsageInvalidForKeyType:  checkKeyUsageInvalidForKeyType,
+	IDCAMissingSubjectKeyID:      checkCAMissingSubjectKeyID,
+	IDCAMissingBasicConstraints:  checkCAMissingBasicConstraints,
+	IDCABasicConstraintsNotCrit:  checkCABasicConstraintsNotCrit,
+	IDCAMissingKeyCertSign:       checkCAMissingKeyCertSign,
+	IDLeafIsCA:                   checkLeafIsCA,
+	IDLeafMissingSAN:             checkLeafMissingSAN,
+	IDLeafCommonNameNotInSAN:     checkLeafCommonNameNotInSAN,
+	IDLeafInvalidDNSName:         checkLeafInvalidDNSName,
+	IDLeafMissingExtKeyUsage:     checkLeafMissingExtKeyUsage,
+	IDLeafAnyExtKeyUsage:         checkLeafAnyExtKeyUsage,
+	IDLeafInternalName:           checkLeafInternalName,
+	IDLeafValidityPeriodExceeded: checkLeafValidityPeriodExceeded,
+	IDLeafValidityPeriodUpcoming: checkLeafValidityPeriodUpcoming,
+}
+
+// Chain runs the lints selected by the given lint profile against each
+// certificate in the given certificate chain and returns the failed lints
+// indexed by chain position. The role of each certificate (leaf or CA) is
+// determined from the certificate itself and the certificates it issued
+// (see certs.IsLeafRole) rather than its position in the chain. If an empty profile is given
+// input.LintProfileInternalPKI is used. An error is returned if an unknown
+// profile is given.
+func Chain(certChain []*x509.Certificate, profile string) ([][]Result, error) {
+	if profile == "" {
+		profile = input.LintProfileInternalPKI
+	}
+
+	results := make([][]Result, len(certChain))
+
+	switch profile {
+	case input.LintProfileDisabled:
+		return results, nil
+
+	case input.LintProfileInternalPKI, input.LintProfilePublicCA:
+
+	default:
+		return nil, fmt.Errorf("%q: %w", profile, ErrUnknownProfile)
+	}
+
+	defs := Catalog()
+
+	for idx, cert := range certChain {
+		if cert == nil {
+			continue
+		}
+
+		t := newTarget(cert, certChain)
+
+		for _, def := range defs {
+			severity, ok := def.Severities[profile]
+			if !ok {
+				continue
+			}
+
+			details := checks[def.ID](t)
+			if details == "" {
+				continue
+			}
+
+			results[idx] = append(results[idx], Result{
+				ID:       def.ID,
+				Severity: severity,
+				Details:  details,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// newTarget is a helper function that determines the role of the given
+// certificate within the given certificate chain. The role is determined as
+// it is for the leaf certificate validity period compliance evaluation of
+// the payload (see certs.IsLeafRole) so that a misordered chain is linted
+// for the correct roles. All certificates which are not leaf certificates
+// are linted as CA certificates.
+func newTarget(cert *x509.Certificate, certChain []*x509.Certificate) target {
+	leaf := certs.IsLeafRole(cert, certChain)
+
+	return target{
+		cert:       cert,
+		leaf:       leaf,
+		ca:         !leaf,
+		selfSigned: certs.IsSelfSigned(cert),
+	}
+}
+
+// extensionCriticality is a helper function that indicates whether the
+// given certificate contains the extension with the given OID and whether
+// the extension is marked critical.
+func extensionCriticality(cert *x509.Certificate, oid asn1.ObjectIdentifier) (present bool, critical bool) {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true, ext.Critical
+		}
+	}
+
+	return false, false
+}
+
+// checkSerialNotPositive asserts that the serial number is a positive integer.
+func checkSerialNotPositive(t target) string {
+	if t.cert.SerialNumber == nil || t.cert.SerialNumber.Sign() <= 0 {
+		return fmt.Sprintf("serial number is %v", t.cert.SerialNumber)
+	}
+
+	return ""
+}
+
+// checkSerialTooLong asserts that the DER encoded serial number is no longer
+// than 20 octets.
+func checkSerialTooLong(t target) string {
+	sn := t.cert.SerialNumber
+	if sn == nil || sn.Sign() <= 0 {
+		return ""
+	}
+
+	// The DER encoding requires a leading zero octet if the high bit is set.
+	octets := len(sn.Bytes())
+	if sn.Bytes()[0]&0x80 != 0 {
+		octets++
+	}
+
+	if octets <= 20 {
+		return ""
+	}
+
+	return fmt.Sprintf("serial number is %d octets", octets)
+}
+
+// checkSerialLowEntropy asserts that the serial number of an issued
+// certificate is long enough to contain 64 bits of CSPRNG output. The
+// entropy itself cannot be measured.
+func checkSerialLowEntropy(t target) string {
+	sn := t.cert.SerialNumber
+	if t.selfSigned || sn == nil || sn.Sign() <= 0 {
+		return ""
+	}
+
+	if sn.BitLen() >= 64 {
+		return ""
+	}
+
+	return fmt.Sprintf("serial number is %d bits", sn.BitLen())
+}
+
+// checkVersionNotV3 asserts that the certificate is a version 3 certificate.
+func checkVersionNotV3(t target) string {
+	if t.cert.Version == 3 {
+		return ""
+	}
+
+	return fmt.Sprintf("certificate is version %d", t.cert.Version)
+}
+
+// checkValidityInverted asserts that the certificate does not expire before
+// it becomes valid.
+func checkValidityInverted(t target) string {
+	if !t.cert.NotAfter.Before(t.cert.NotBefore) {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"not after %s is before not before %s",
+		t.cert.NotAfter.UTC().Format(time.RFC3339),
+		t.cert.NotBefore.UTC().Format(time.RFC3339),
+	)
+}
+
+// checkUnrecognizedCriticalExt asserts that all critical extensions are
+// recognized.
+func checkUnrecognizedCriticalExt(t target) string {
+	if len(t.cert.UnhandledCriticalExtensions) == 0 {
+		return ""
+	}
+
+	oids := make([]string, 0, len(t.cert.UnhandledCriticalExtensions))
+	for _, oid := range t.cert.UnhandledCriticalExtensions {
+		oids = append(oids, oid.String())
+	}
+
+	return fmt.Sprintf("unrecognized critical extensions: %s", strings.Join(oids, ", "))
+}
+
+// checkEmptySubjectSANNotCritical asserts that a certificate with an empty
+// subject contains a critical Subject Alternative Name extension.
+func checkEmptySubjectSANNotCritical(t target) string {
+	if len(t.cert.RawSubject) == 0 || len(t.cert.Subject.Names) > 0 {
+		return ""
+	}
+
+	present, critical := extensionCriticality(t.cert, oidExtensionSubjectAltName)
+
+	switch {
+	case !present:
+		return "subject is empty and Subject Alternative Name extension is missing"
+	case !critical:
+		return "subject is empty and Subject Alternative Name extension is not critical"
+	default:
+		return ""
+	}
+}
+
+// checkMissingAuthorityKeyID asserts that a certificate which is not
+// self-signed contains the Authority Key Identifier extension.
+func checkMissingAuthorityKeyID(t target) string {
+	if t.selfSigned || len(t.cert.AuthorityKeyId) > 0 {
+		return ""
+	}
+
+	return "Authority Key Identifier extension is missing"
+}
+
+// checkKeyCertSignWithoutCA asserts that the keyCertSign key usage is only
+// asserted along with the basic constraints cA value.
+func checkKeyCertSignWithoutCA(t target) string {
+	if t.cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return ""
+	}
+
+	if t.cert.BasicConstraintsValid && t.cert.IsCA {
+		return ""
+	}
+
+	return "keyCertSign key usage asserted without basic constraints cA"
+}
+
+// checkKeyUsageInvalidForKeyType asserts that the key usage extension only
+// asserts usages valid for the subject public key type.
+func checkKeyUsageInvalidForKeyType(t target) string {
+	var invalid x509.KeyUsage
+	var keyType string
+
+	switch t.cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		keyType = "RSA"
+		invalid = x509.KeyUsageKeyAgreement | x509.KeyUsageEncipherOnly | x509.KeyUsageDecipherOnly
+
+	case *ecdsa.PublicKey:
+		keyType = "ECDSA"
+		invalid = x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment
+
+	case ed25519.PublicKey:
+		keyType = "Ed25519"
+		invalid = x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment |
+			x509.KeyUsageKeyAgreement | x509.KeyUsageEncipherOnly | x509.KeyUsageDecipherOnly
+
+	default:
+		return ""
+	}
+
+	usages := keyUsageNames(t.cert.KeyUsage & invalid)
+	if len(usages) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"key usage %s not valid for %s public key",
+		strings.Join(usages, ", "),
+		keyType,
+	)
+}
+
+// checkCAMissingSubjectKeyID asserts that a CA certificate contains the
+// Subject Key Identifier extension.
+func checkCAMissingSubjectKeyID(t target) string {
+	if !t.ca || len(t.cert.SubjectKeyId) > 0 {
+		return ""
+	}
+
+	return "Subject Key Identifier extension is missing"
+}
+
+// checkCAMissingBasicConstraints asserts that a CA certificate contains the
+// basic constraints extension with the cA value asserted.
+func checkCAMissingBasicConstraints(t target) string {
+	switch {
+	case !t.ca:
+		return ""
+	case !t.cert.BasicConstraintsValid:
+		return "basic constraints extension is missing"
+	case !t.cert.IsCA:
+		return "basic constraints cA value is not asserted"
+	default:
+		return ""
+	}
+}
+
+// checkCABasicConstraintsNotCrit asserts that the basic constraints
+// extension of a CA certificate is marked critical.
+func checkCABasicConstraintsNotCrit(t target) string {
+	if !t.ca {
+		return ""
+	}
+
+	present, critical := extensionCriticality(t.cert, oidExtensionBasicConstraints)
+	if !present || critical {
+		return ""
+	}
+
+	return "basic constraints extension is not critical"
+}
+
+// checkCAMissingKeyCertSign asserts that a CA certificate contains the key
+// usage extension with keyCertSign asserted.
+func checkCAMissingKeyCertSign(t target) string {
+	switch {
+	case !t.ca:
+		return ""
+	case t.cert.KeyUsage == 0:
+		return "key usage extension is missing"
+	case t.cert.KeyUsage&x509.KeyUsageCertSign == 0:
+		return fmt.Sprintf(
+			"keyCertSign not asserted; key usage is %s",
+			strings.Join(keyUsageNames(t.cert.KeyUsage), ", "),
+		)
+	default:
+		return ""
+	}
+}
+
+// checkLeafIsCA asserts that a leaf certificate does not assert the basic
+// constraints cA value.
+func checkLeafIsCA(t target) string {
+	if !t.leaf || !t.cert.BasicConstraintsValid || !t.cert.IsCA {
+		return ""
+	}
+
+	return "basic constraints cA value is asserted"
+}
+
+// checkLeafMissingSAN asserts that a leaf certificate contains the Subject
+// Alternative Name extension.
+func checkLeafMissingSAN(t target) string {
+	if present, _ := extensionCriticality(t.cert, oidExtensionSubjectAltName); !t.leaf || present {
+		return ""
+	}
+
+	return "Subject Alternative Name extension is missing"
+}
+
+// checkLeafCommonNameNotInSAN asserts that the Common Name of a leaf
+// certificate is listed as a DNS name or IP Address SANs entry.
+func checkLeafCommonNameNotInSAN(t target) string {
+	commonName := t.cert.Subject.CommonName
+	if !t.leaf || commonName == "" {
+		return ""
+	}
+
+	for _, name := range t.cert.DNSNames {
+		if strings.EqualFold(name, commonName) {
+			return ""
+		}
+	}
+
+	if ip := net.ParseIP(commonName); ip != nil {
+		for _, sanIP := range t.cert.IPAddresses {
+			if sanIP.Equal(ip) {
+				return ""
+			}
+		}
+	}
+
+	return fmt.Sprintf("common name %q not listed in Subject Alternative Name extension", commonName)
+}
+
+// checkLeafInvalidDNSName asserts that each DNS name SANs entry of a leaf
+// certificate is a valid host name or wildcard.
+func checkLeafInvalidDNSName(t target) string {
+	if !t.leaf {
+		return ""
+	}
+
+	var invalid []string
+	for _, name := range t.cert.DNSNames {
+		if !validDNSName(name) {
+			invalid = append(invalid, fmt.Sprintf("%q", name))
+		}
+	}
+
+	if len(invalid) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("invalid DNS names: %s", strings.Join(invalid, ", "))
+}
+
+// checkLeafMissingExtKeyUsage asserts that a leaf certificate contains the
+// extended key usage extension.
+func checkLeafMissingExtKeyUsage(t target) string {
+	if !t.leaf || len(t.cert.ExtKeyUsage) > 0 || len(t.cert.UnknownExtKeyUsage) > 0 {
+		return ""
+	}
+
+	return "extended key usage extension is missing"
+}
+
+// checkLeafAnyExtKeyUsage asserts that a leaf certificate does not assert
+// anyExtendedKeyUsage.
+func checkLeafAnyExtKeyUsage(t target) string {
+	if !t.leaf {
+		return ""
+	}
+
+	for _, usage := range t.cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageAny {
+			return "anyExtendedKeyUsage asserted"
+		}
+	}
+
+	return ""
+}
+
+// checkLeafInternalName asserts that a leaf certificate does not list
+// internal names or reserved IP Addresses.
+func checkLeafInternalName(t target) string {
+	if !t.leaf {
+		return ""
+	}
+
+	var internal []string
+
+	for _, name := range t.cert.DNSNames {
+		if internalName(name) {
+			internal = append(internal, name)
+		}
+	}
+
+	for _, ip := range t.cert.IPAddresses {
+		if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			internal = append(internal, ip.String())
+		}
+	}
+
+	if len(internal) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("internal names or reserved IP Addresses: %s", strings.Join(internal, ", "))
+}
+
+// checkLeafValidityPeriodExceeded asserts that the validity period of a leaf
+// certificate does not exceed the maximum in force when it was issued.
+func checkLeafValidityPeriodExceeded(t target) string {
+	if !t.leaf {
+		return ""
+	}
+
+	limit, ok := certs.ValidityLimitAt(t.cert.NotBefore)
+	if !ok || !certs.ExceedsValidityLimit(certs.ValidityPeriod(t.cert), limit) {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"validity period of %s exceeds maximum of %d days in force since %s (%s)",
+		validityPeriodDays(t.cert),
+		limit.MaxDays,
+		limit.EffectiveOn.Format("2006-01-02"),
+		limit.Reference,
+	)
+}
+
+// checkLeafValidityPeriodUpcoming asserts that the validity period of a
+// compliant leaf certificate does not exceed a maximum scheduled to take
+// effect after it was issued.
+func checkLeafValidityPeriodUpcoming(t target) string {
+	if !t.leaf {
+		return ""
+	}
+
+	if limit, ok := certs.ValidityLimitAt(t.cert.NotBefore); ok &&
+		certs.ExceedsValidityLimit(certs.ValidityPeriod(t.cert), limit) {
+		return ""
+	}
+
+	next, ok := certs.NextExceededValidityLimit(t.cert)
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"validity period of %s exceeds maximum of %d days for certificates issued from %s (%s)",
+		validityPeriodDays(t.cert),
+		next.MaxDays,
+		next.EffectiveOn.Format("2006-01-02"),
+		next.Reference,
+	)
+}
+
+// validityPeriodDays is a helper function that returns the validity period
+// of the given certificate in days with two digit decimal precision.
+func validityPeriodDays(cert *x509.Certificate) string {
+	return fmt.Sprintf("%.2f days", certs.ValidityPeriod(cert).Hours()/24)
+}
+
+// validDNSName is a helper function that indicates whether the given name
+// is a valid host name using the preferred name syntax, optionally prefixed
+// by a wildcard label.
+func validDNSName(name string) bool {
+	name = strings.TrimPrefix(name, "*.")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 {
+			return false
+		}
+
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			switch {
+			case r >= 'a' && r <= 'z':
+			case r >= 'A' && r <= 'Z':
+			case r >= '0' && r <= '9':
+			case r == '-':
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// internalName is a helper function that indicates whether the given DNS
+// name is a single label name or uses a top-level domain reserved for
+// internal networks.
+func internalName(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+
+	dot := strings.LastIndex(name, ".")
+	if dot == -1 {
+		return true
+	}
+
+	tld := name[dot+1:]
+	for _, internalTLD := range internalTLDs {
+		if tld == internalTLD {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyUsageNames is a helper function that returns the RFC 5280 names of the
+// given key usage bits.
+func keyUsageNames(usage x509.KeyUsage) []string {
+	names := []struct {
+		usage x509.KeyUsage
+		name  string
+	}{
+		{x509.KeyUsageDigitalSignature, "digitalSignature"},
+		{x509.KeyUsageContentCommitment, "contentCommitment"},
+		{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+		{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+		{x509.KeyUsageKeyAgreement, "keyAgreement"},
+		{x509.KeyUsageCertSign, "keyCertSign"},
+		{x509.KeyUsageCRLSign, "cRLSign"},
+		{x509.KeyUsageEncipherOnly, "encipherOnly"},
+		{x509.KeyUsageDecipherOnly, "decipherOnly"},
+	}
+
+	var matched []string
+	for _, n := range names {
+		if usage&n.usage != 0 {
+			matched = append(matched, n.name)
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,426 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package lint_test
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/cert-payload/input"
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/lint"
+)
+
+// issued is the issue date of the test certificates; a 200 day maximum
+// validity period is in force for publicly trusted TLS certificates issued
+// on this date.
+var issued = time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+// testChain returns a leaf, intermediate and root certificate chain. The
+// given functions modify the leaf and intermediate templates before the
+// certificates are created. Without modifications the chain passes all
+// lints in every profile.
+func testChain(t *testing.T, leafFn func(*x509.Certificate), intermediateFn func(*x509.Certificate)) []*x509.Certificate {
+	t.Helper()
+
+	randomSerial := func() *big.Int {
+		sn, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return sn.SetBit(sn, 126, 1)
+	}
+
+	caTmpl := func(cn string, serial *big.Int) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          serial,
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             issued.AddDate(-5, 0, 0),
+			NotAfter:              issued.AddDate(10, 0, 0),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+	}
+
+	root, rootKey := testutil.IssueCert(t, caTmpl("Test Lint Root CA", big.NewInt(1)), nil, nil)
+
+	intermediateTmpl := caTmpl("Test Lint Intermediate CA", randomSerial())
+	if intermediateFn != nil {
+		intermediateFn(intermediateTmpl)
+	}
+
+	intermediate, intermediateKey := testutil.IssueCert(t, intermediateTmpl, root, rootKey)
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: "www.atc0005.net"},
+		DNSNames:     []string{"www.atc0005.net", "atc0005.net"},
+		NotBefore:    issued,
+		NotAfter:     issued.AddDate(0, 0, 45),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if leafFn != nil {
+		leafFn(leafTmpl)
+	}
+
+	leaf, _ := testutil.IssueCert(t, leafTmpl, intermediate, intermediateKey)
+
+	return []*x509.Certificate{leaf, intermediate, root}
+}
+
+func TestChain(t *testing.T) {
+	const (
+		leafIdx         = 0
+		intermediateIdx = 1
+	)
+
+	// want is the expected severity of each failed lint for the internal
+	// PKI and public CA profiles.
+	type want struct {
+		internalPKI map[string]string
+		publicCA    map[string]string
+	}
+
+	tests := []struct {
+		name           string
+		leafFn         func(*x509.Certificate)
+		intermediateFn func(*x509.Certificate)
+		certIdx        int
+		want           want
+	}{
+		{
+			name:    "compliant chain",
+			certIdx: leafIdx,
+		},
+		{
+			name: "short serial number",
+			leafFn: func(c *x509.Certificate) {
+				c.SerialNumber = big.NewInt(0x1234)
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDSerialLowEntropy: lint.SeverityNotice},
+				publicCA:    map[string]string{lint.IDSerialLowEntropy: lint.SeverityError},
+			},
+		},
+		{
+			name: "serial number too long",
+			leafFn: func(c *x509.Certificate) {
+				c.SerialNumber = new(big.Int).Lsh(big.NewInt(1), 160)
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDSerialTooLong: lint.SeverityError},
+				publicCA:    map[string]string{lint.IDSerialTooLong: lint.SeverityError},
+			},
+		},
+		{
+			name: "missing SAN",
+			leafFn: func(c *x509.Certificate) {
+				c.DNSNames = nil
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{
+					lint.IDLeafMissingSAN:         lint.SeverityWarning,
+					lint.IDLeafCommonNameNotInSAN: lint.SeverityWarning,
+				},
+				publicCA: map[string]string{
+					lint.IDLeafMissingSAN:         lint.SeverityError,
+					lint.IDLeafCommonNameNotInSAN: lint.SeverityError,
+				},
+			},
+		},
+		{
+			// The leaf role is determined from the certificates issued by
+			// the certificate rather than the basic constraints extension.
+			name: "leaf is CA",
+			leafFn: func(c *x509.Certificate) {
+				c.BasicConstraintsValid = true
+				c.IsCA = true
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDLeafIsCA: lint.SeverityError},
+				publicCA:    map[string]string{lint.IDLeafIsCA: lint.SeverityError},
+			},
+		},
+		{
+			name: "key usage invalid for ECDSA key",
+			leafFn: func(c *x509.Certificate) {
+				c.KeyUsage |= x509.KeyUsageKeyEncipherment
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDKeyUsageInvalidForKeyType: lint.SeverityError},
+				publicCA:    map[string]string{lint.IDKeyUsageInvalidForKeyType: lint.SeverityError},
+			},
+		},
+		{
+			name: "missing extended key usage",
+			leafFn: func(c *x509.Certificate) {
+				c.ExtKeyUsage = nil
+			},
+			certIdx: leafIdx,
+			want: want{
+				publicCA: map[string]string{lint.IDLeafMissingExtKeyUsage: lint.SeverityError},
+			},
+		},
+		{
+			name: "any extended key usage",
+			leafFn: func(c *x509.Certificate) {
+				c.ExtKeyUsage = append(c.ExtKeyUsage, x509.ExtKeyUsageAny)
+			},
+			certIdx: leafIdx,
+			want: want{
+				publicCA: map[string]string{lint.IDLeafAnyExtKeyUsage: lint.SeverityError},
+			},
+		},
+		{
+			name: "internal name",
+			leafFn: func(c *x509.Certificate) {
+				c.DNSNames = append(c.DNSNames, "intranet.corp")
+			},
+			certIdx: leafIdx,
+			want: want{
+				publicCA: map[string]string{lint.IDLeafInternalName: lint.SeverityError},
+			},
+		},
+		{
+			name: "invalid DNS name",
+			leafFn: func(c *x509.Certificate) {
+				c.DNSNames = append(c.DNSNames, "bad_name.atc0005.net")
+			},
+			certIdx: leafIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDLeafInvalidDNSName: lint.SeverityWarning},
+				publicCA:    map[string]string{lint.IDLeafInvalidDNSName: lint.SeverityError},
+			},
+		},
+		{
+			name: "validity period exceeds limit in force",
+			leafFn: func(c *x509.Certificate) {
+				c.NotAfter = c.NotBefore.AddDate(0, 0, 397)
+			},
+			certIdx: leafIdx,
+			want: want{
+				publicCA: map[string]string{lint.IDLeafValidityPeriodExceeded: lint.SeverityError},
+			},
+		},
+		{
+			name: "validity period exceeds upcoming limit",
+			leafFn: func(c *x509.Certificate) {
+				c.NotAfter = c.NotBefore.AddDate(0, 0, 150)
+			},
+			certIdx: leafIdx,
+			want: want{
+				publicCA: map[string]string{lint.IDLeafValidityPeriodUpcoming: lint.SeverityWarning},
+			},
+		},
+		{
+			name: "CA missing keyCertSign",
+			intermediateFn: func(c *x509.Certificate) {
+				c.KeyUsage = x509.KeyUsageDigitalSignature
+			},
+			certIdx: intermediateIdx,
+			want: want{
+				internalPKI: map[string]string{lint.IDCAMissingKeyCertSign: lint.SeverityError},
+				publicCA:    map[string]string{lint.IDCAMissingKeyCertSign: lint.SeverityError},
+			},
+		},
+		{
+			name: "CA missing basic constraints",
+			intermediateFn: func(c *x509.Certificate) {
+				c.BasicConstraintsValid = false
+				c.IsCA = false
+				c.KeyUsage = x509.KeyUsageCRLSign
+				c.SubjectKeyId = []byte{0x01, 0x02, 0x03, 0x04}
+			},
+			certIdx: intermediateIdx,
+			want: want{
+				internalPKI: map[string]string{
+					lint.IDCAMissingBasicConstraints: lint.SeverityError,
+					lint.IDCAMissingKeyCertSign:      lint.SeverityError,
+				},
+				publicCA: map[string]string{
+					lint.IDCAMissingBasicConstraints: lint.SeverityError,
+					lint.IDCAMissingKeyCertSign:      lint.SeverityError,
+				},
+			},
+		},
+	}
+
+	profiles := []struct {
+		profile string
+		want    func(want) map[string]string
+	}{
+		{"", func(w want) map[string]string { return w.internalPKI }},
+		{input.LintProfileInternalPKI, func(w want) map[string]string { return w.internalPKI }},
+		{input.LintProfilePublicCA, func(w want) map[string]string { return w.publicCA }},
+		{input.LintProfileDisabled, func(want) map[string]string { return nil }},
+	}
+
+	for _, tt := range tests {
+		certChain := testChain(t, tt.leafFn, tt.intermediateFn)
+
+		for _, p := range profiles {
+			name := p.profile
+			if name == "" {
+				name = "default"
+			}
+
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				results, err := lint.Chain(certChain, p.profile)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if len(results) != len(certChain) {
+					t.Fatalf("got results for %d certs, want %d", len(results), len(certChain))
+				}
+
+				for idx, certResults := range results {
+					want := map[string]string{}
+					if idx == tt.certIdx {
+						for id, severity := range p.want(tt.want) {
+							want[id] = severity
+						}
+					}
+
+					got := make(map[string]string, len(certResults))
+					for _, result := range certResults {
+						got[result.ID] = result.Severity
+
+						if result.Details == "" {
+							t.Errorf("cert %d: lint %s has no details", idx, result.ID)
+						}
+					}
+
+					if len(got) != len(want) {
+						t.Errorf("cert %d: got lints %v, want %v", idx, got, want)
+						continue
+					}
+
+					for id, severity := range want {
+						if got[id] != severity {
+							t.Errorf("cert %d: got lints %v, want %v", idx, got, want)
+							break
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestChainMisordered(t *testing.T) {
+	ordered := testChain(
+		t,
+		func(c *x509.Certificate) {
+			c.DNSNames = nil
+		},
+		func(c *x509.Certificate) {
+			c.BasicConstraintsValid = false
+			c.IsCA = false
+			c.SubjectKeyId = []byte{0x01, 0x02, 0x03, 0x04}
+		},
+	)
+
+	leaf, intermediate, root := ordered[0], ordered[1], ordered[2]
+
+	// want is the expected lints for each certificate; the roles follow the
+	// certificates rather than their position in the chain.
+	want := map[*x509.Certificate][]string{
+		leaf:         {lint.IDLeafCommonNameNotInSAN, lint.IDLeafMissingSAN},
+		intermediate: {lint.IDCAMissingBasicConstraints, lint.IDKeyCertSignWithoutCA},
+		root:         nil,
+	}
+
+	tests := []struct {
+		name      string
+		certChain []*x509.Certificate
+	}{
+		{
+			name:      "ordered",
+			certChain: ordered,
+		},
+		{
+			name:      "intermediate first",
+			certChain: []*x509.Certificate{intermediate, leaf, root},
+		},
+		{
+			name:      "reversed",
+			certChain: []*x509.Certificate{root, intermediate, leaf},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := lint.Chain(tt.certChain, input.LintProfileInternalPKI)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for idx, cert := range tt.certChain {
+				got := make([]string, 0, len(results[idx]))
+				for _, result := range results[idx] {
+					got = append(got, result.ID)
+				}
+
+				sort.Strings(got)
+
+				if strings.Join(got, ",") != strings.Join(want[cert], ",") {
+					t.Errorf("cert %d (%s): got lints %v, want %v", idx, cert.Subject.CommonName, got, want[cert])
+				}
+			}
+		})
+	}
+}
+
+func TestChainUnknownProfile(t *testing.T) {
+	certChain := testChain(t, nil, nil)
+
+	if _, err := lint.Chain(certChain, "strict"); !errors.Is(err, lint.ErrUnknownProfile) {
+		t.Errorf("got error %v, want %v", err, lint.ErrUnknownProfile)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for _, def := range lint.Catalog() {
+		if seen[def.ID] {
+			t.Errorf("duplicate lint ID %s", def.ID)
+		}
+		seen[def.ID] = true
+
+		if len(def.Severities) == 0 {
+			t.Errorf("lint %s is not run by any profile", def.ID)
+		}
+
+		for profile := range def.Severities {
+			if profile != input.LintProfileInternalPKI && profile != input.LintProfilePublicCA {
+				t.Errorf("lint %s lists unsupported profile %q", def.ID, profile)
+			}
+		}
+
+		if got, ok := lint.Lookup(def.ID); !ok || got.ID != def.ID {
+			t.Errorf("lookup of lint %s failed", def.ID)
+		}
+	}
+}
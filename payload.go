@@ -15,6 +15,7 @@ import (
 
 	format0 "github.com/atc0005/cert-payload/format/v0"
 	format1 "github.com/atc0005/cert-payload/format/v1"
+	format2 "github.com/atc0005/cert-payload/format/v2"
 	"github.com/atc0005/cert-payload/input"
 )
 
@@ -43,7 +44,7 @@ const (
 	// by this project. This value does not necessarily indicate the latest
 	// stable version. Update to the very latest project release to support
 	// the most recent format version.
-	MaxPayloadVersion int = MaxStablePayloadVersion
+	MaxPayloadVersion int = 2
 
 	// MinPayloadVersion indicates the minimum payload format version
 	// supported by this project.
@@ -100,6 +101,9 @@ func Encode(payloadVersion int, inputData input.Values) ([]byte, error) {
 	case payloadVersion == 1:
 		return format1.Encode(inputData)
 
+	case payloadVersion == 2:
+		return format2.Encode(inputData)
+
 	default:
 		return nil, fmt.Errorf("payload version %d specified: %w",
 			payloadVersion,
@@ -109,7 +113,7 @@ func Encode(payloadVersion int, inputData input.Values) ([]byte, error) {
 }
 
 // EncodeLatest processes the given input data and returns a JSON payload in
-// the latest format version. An error is returned if one occurs during
+// the latest stable format version. An error is returned if one occurs during
 // processing or if an invalid payload version format is specified.
 func EncodeLatest(inputData input.Values) ([]byte, error) {
 	// 	latestEncoder := latestVersionEncoder()
@@ -156,6 +160,9 @@ func Decode(inputPayload string, dest interface{}) error {
 
 	case *format1.CertChainPayload:
 		return format1.Decode(v, inputReader, false)
+
+	case *format2.CertChainPayload:
+		return format2.Decode(v, inputReader, false)
 	default:
 
 	}
@@ -167,10 +174,13 @@ func Decode(inputPayload string, dest interface{}) error {
 // client applications may choose from when encoding or decoding certificate
 // metadata payloads.
 func AvailableFormatVersions() []int {
-	return []int{
-		UnstablePayloadVersion,
-		MaxStablePayloadVersion,
+	formats := make([]int, 0, MaxPayloadVersion-MinPayloadVersion+1)
+
+	for i := MinPayloadVersion; i <= MaxPayloadVersion; i++ {
+		formats = append(formats, i)
 	}
+
+	return formats
 }
 
 // AvailableStableFormatVersions provides a list of all available stable
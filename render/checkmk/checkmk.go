@@ -0,0 +1,482 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package checkmk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/issues"
+	"github.com/atc0005/cert-payload/render/internal/shared"
+)
+
+// Checkmk local check state values.
+const (
+	StateOK       int = 0
+	StateWARNING  int = 1
+	StateCRITICAL int = 2
+	StateUNKNOWN  int = 3
+)
+
+// Default settings used when a Config value is not specified. The threshold
+// values are used only for a threshold override which specifies a single
+// threshold and match the defaults used by the check_cert plugin.
+const (
+	DefaultExpirationAgeInDaysWarningThreshold  int    = shared.DefaultExpirationAgeInDaysWarningThreshold
+	DefaultExpirationAgeInDaysCriticalThreshold int    = shared.DefaultExpirationAgeInDaysCriticalThreshold
+	DefaultServicePrefix                        string = "Certificate"
+)
+
+// Metric names used in local check output.
+const (
+	MetricDaysRemaining    string = "days_remaining"
+	MetricLifetimePercent  string = "lifetime_remaining_percent"
+	MetricChainIssuesCount string = "chain_issues"
+)
+
+// Section headers used to wrap local check output in a piggyback section.
+const (
+	localSectionHeader  string = "<<<local:sep(0)>>>"
+	piggybackHeaderFmt  string = "<<<<%s>>>>"
+	piggybackTerminator string = "<<<<>>>>"
+)
+
+// Config controls how local check output is rendered.
+type Config struct {
+	// ExpirationAgeInDaysWarningThreshold is the optional number of days
+	// remaining before certificate expiration when the certificate should be
+	// considered to be expiring and in a WARNING state.
+	//
+	// By default the state of each certificate follows the expiration state
+	// recorded in the payload using the thresholds applied when the payload
+	// was generated. If either threshold is specified, the state of each
+	// certificate is instead evaluated against the specified thresholds.
+	ExpirationAgeInDaysWarningThreshold int
+
+	// ExpirationAgeInDaysCriticalThreshold is the optional number of days
+	// remaining before certificate expiration when the certificate should be
+	// considered to be expiring and in a CRITICAL state. See also
+	// ExpirationAgeInDaysWarningThreshold.
+	ExpirationAgeInDaysCriticalThreshold int
+
+	// ServicePrefix is the leading text used for generated service names.
+	ServicePrefix string
+
+	// Piggyback indicates whether local check output should be wrapped in a
+	// piggyback section so that the services are attached to the host which
+	// served the certificate chain.
+	Piggyback bool
+
+	// PiggybackHost is the name of the host that piggyback data is attached
+	// to. If not specified the payload Server.HostValue field is used.
+	PiggybackHost string
+}
+
+// LocalCheck is a single Checkmk local check result.
+type LocalCheck struct {
+	// State is the local check state (e.g., StateOK, StateCRITICAL).
+	State int
+
+	// ServiceName is the name of the service in Checkmk. Spaces and control
+	// characters are replaced when the local check output is generated.
+	ServiceName string
+
+	// Metrics is the collection of performance metrics for the service.
+	Metrics []Metric
+
+	// Summary is the single line status detail text for the service.
+	Summary string
+}
+
+// Metric is a single local check performance metric.
+type Metric struct {
+	Name     string
+	Value    float64
+	Warning  float64
+	Critical float64
+}
+
+// String implements the fmt.Stringer interface by returning the metric in
+// the local check metric format. Threshold values are omitted if not set.
+func (m Metric) String() string {
+	if m.Warning == 0 && m.Critical == 0 {
+		return fmt.Sprintf("%s=%s", m.Name, formatFloat(m.Value))
+	}
+
+	return fmt.Sprintf(
+		"%s=%s;%s;%s",
+		m.Name,
+		formatFloat(m.Value),
+		formatFloat(m.Warning),
+		formatFloat(m.Critical),
+	)
+}
+
+// String implements the fmt.Stringer interface by returning the local check
+// result as a single line of local check output. The service name is printed
+// bare as local check output fields are separated by spaces.
+func (lc LocalCheck) String() string {
+	metrics := "-"
+	if len(lc.Metrics) > 0 {
+		metricsText := make([]string, 0, len(lc.Metrics))
+		for _, metric := range lc.Metrics {
+			metricsText = append(metricsText, metric.String())
+		}
+
+		metrics = strings.Join(metricsText, "|")
+	}
+
+	return fmt.Sprintf(
+		"%d %s %s %s",
+		lc.State,
+		sanitizeServiceName(lc.ServiceName),
+		metrics,
+		singleLine(lc.Summary),
+	)
+}
+
+// LocalChecks generates a local check result for the certificate chain as a
+// whole followed by a local check result for each certificate in the chain.
+func LocalChecks(payload format2.CertChainPayload, cfg Config) []LocalCheck {
+	cfg = cfg.withDefaults()
+
+	chainService := serviceName(payload, cfg)
+	results := make([]LocalCheck, 0, len(payload.CertChainSubset)+1)
+
+	results = append(results, chainCheck(payload, cfg, chainService))
+
+	for _, cert := range payload.CertChainSubset {
+		results = append(results, LocalCheck{
+			State:       certState(cert, cfg),
+			ServiceName: fmt.Sprintf("%s %s %s", chainService, cert.CommonName, cert.SerialNumber),
+			Metrics:     certMetrics(cert, payload.ExpirationThresholds, cfg),
+			Summary:     fmt.Sprintf("%s certificate %s", cert.Type, cert.Summary),
+		})
+	}
+
+	return results
+}
+
+// Render generates local check output for the given payload. If requested
+// and a host value is available the output is wrapped in a piggyback section.
+func Render(payload format2.CertChainPayload, cfg Config) string {
+	var sb strings.Builder
+
+	piggybackHost := cfg.PiggybackHost
+	if piggybackHost == "" {
+		piggybackHost = payload.Server.HostValue
+	}
+
+	piggyback := cfg.Piggyback && piggybackHost != ""
+
+	if piggyback {
+		sb.WriteString(fmt.Sprintf(piggybackHeaderFmt, piggybackHost))
+		sb.WriteString("\n")
+		sb.WriteString(localSectionHeader)
+		sb.WriteString("\n")
+	}
+
+	for _, result := range LocalChecks(payload, cfg) {
+		sb.WriteString(result.String())
+		sb.WriteString("\n")
+	}
+
+	if piggyback {
+		sb.WriteString(piggybackTerminator)
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// withDefaults is a helper method that returns a copy of the configuration
+// with default values applied for any settings which were not specified.
+// Default threshold values are applied only if the other threshold was
+// specified.
+func (cfg Config) withDefaults() Config {
+	if cfg.overridesThresholds() {
+		if cfg.ExpirationAgeInDaysWarningThreshold == 0 {
+			cfg.ExpirationAgeInDaysWarningThreshold = DefaultExpirationAgeInDaysWarningThreshold
+		}
+
+		if cfg.ExpirationAgeInDaysCriticalThreshold == 0 {
+			cfg.ExpirationAgeInDaysCriticalThreshold = DefaultExpirationAgeInDaysCriticalThreshold
+		}
+	}
+
+	if cfg.ServicePrefix == "" {
+		cfg.ServicePrefix = DefaultServicePrefix
+	}
+
+	return cfg
+}
+
+// overridesThresholds is a helper method that indicates whether expiration
+// thresholds were specified to override the expiration state recorded in the
+// payload.
+func (cfg Config) overridesThresholds() bool {
+	return cfg.ExpirationAgeInDaysWarningThreshold != 0 ||
+		cfg.ExpirationAgeInDaysCriticalThreshold != 0
+}
+
+// chainCheck is a helper function that generates the local check result for
+// the certificate chain as a whole. The state is the most severe state of the
+// certificates in the chain and the severity of each certificate chain issue
+// which is not ignored per a suppression.
+func chainCheck(payload format2.CertChainPayload, cfg Config, name string) LocalCheck {
+	certChain := format2.Certificates(payload.CertChainSubset)
+
+	if len(certChain) == 0 {
+		summary := format2.CertChainNotFound
+		if len(payload.Errors) > 0 {
+			summary = fmt.Sprintf("%s: %s", summary, strings.Join(payload.Errors, "; "))
+		}
+
+		return LocalCheck{
+			State:       StateUNKNOWN,
+			ServiceName: name,
+			Summary:     summary,
+		}
+	}
+
+	state := StateOK
+	for _, cert := range certChain {
+		state = worstState(state, certState(cert, cfg))
+	}
+
+	for _, issue := range payload.IssueDetails {
+		if issue.Ignored {
+			continue
+		}
+
+		state = worstState(state, labelState(issues.ServiceState(issue.Severity)))
+	}
+
+	detected := payload.Issues.Detected()
+
+	summary := fmt.Sprintf("leaf expires: %s", certChain.LeafExpirationDescription())
+	if len(detected) > 0 {
+		summary += fmt.Sprintf(", issues: %s", strings.Join(detected, ", "))
+	}
+
+	leaf := certChain.FirstLeaf()
+	if leaf.IssuedOn.IsZero() {
+		leaf = certChain[0]
+	}
+
+	metrics := append(
+		certMetrics(leaf, payload.ExpirationThresholds, cfg),
+		Metric{
+			Name:  MetricChainIssuesCount,
+			Value: float64(len(detected)),
+		},
+	)
+
+	return LocalCheck{
+		State:       state,
+		ServiceName: name,
+		Metrics:     metrics,
+		Summary:     summary,
+	}
+}
+
+// certState is a helper function that returns the state of a certificate.
+// If threshold overrides are configured the expiration of the certificate is
+// evaluated against them, otherwise the expiration status recorded in the
+// payload for the certificate is used. A revoked or not yet valid
+// certificate is always in a CRITICAL state.
+func certState(cert format2.Certificate, cfg Config) int {
+	switch {
+	case cert.Status.Revoked, cert.Status.NotYetValid:
+		return StateCRITICAL
+
+	case cfg.overridesThresholds():
+		switch {
+		case cert.DaysRemaining < float64(cfg.ExpirationAgeInDaysCriticalThreshold):
+			return StateCRITICAL
+		case cert.DaysRemaining < float64(cfg.ExpirationAgeInDaysWarningThreshold):
+			return StateWARNING
+		default:
+			return StateOK
+		}
+
+	case cert.Status.OK, cert.Status.ExpirationIgnored:
+		return StateOK
+
+	case cert.Status.Expired:
+		return StateCRITICAL
+
+	case cert.Status.Expiring:
+		return StateWARNING
+
+	default:
+		return StateOK
+	}
+}
+
+// certMetrics is a helper function that generates the performance metrics
+// for a certificate. If threshold overrides are configured they are used for
+// the days remaining metric, otherwise the expiration thresholds recorded in
+// the payload for the role of the certificate are used for the days remaining
+// and lifetime remaining metrics.
+func certMetrics(cert format2.Certificate, thresholds format2.ExpirationThresholds, cfg Config) []Metric {
+	if cfg.overridesThresholds() {
+		return []Metric{
+			{
+				Name:     MetricDaysRemaining,
+				Value:    cert.DaysRemaining,
+				Warning:  float64(cfg.ExpirationAgeInDaysWarningThreshold),
+				Critical: float64(cfg.ExpirationAgeInDaysCriticalThreshold),
+			},
+			{
+				Name:  MetricLifetimePercent,
+				Value: float64(cert.LifetimePercent),
+			},
+		}
+	}
+
+	roleThresholds := thresholdsForRole(thresholds, cert.Type)
+
+	return []Metric{
+		{
+			Name:     MetricDaysRemaining,
+			Value:    cert.DaysRemaining,
+			Warning:  thresholdDays(roleThresholds.Warning),
+			Critical: thresholdDays(roleThresholds.Critical),
+		},
+		{
+			Name:     MetricLifetimePercent,
+			Value:    float64(cert.LifetimePercent),
+			Warning:  roleThresholds.Warning.LifetimePercent,
+			Critical: roleThresholds.Critical.LifetimePercent,
+		},
+	}
+}
+
+// thresholdsForRole is a helper function that returns the expiration
+// thresholds applied to certificates of the given type (e.g., leaf,
+// intermediate or root). The leaf certificate thresholds are returned for an
+// unrecognized type.
+func thresholdsForRole(thresholds format2.ExpirationThresholds, certType string) format2.ThresholdSet {
+	switch certType {
+	case certs.CertChainPositionIntermediate:
+		return thresholds.Intermediate
+	case certs.CertChainPositionRoot:
+		return thresholds.Root
+	default:
+		return thresholds.Leaf
+	}
+}
+
+// thresholdDays is a helper function that returns the number of days remaining
+// before expiration at which the day or hour value of the given threshold is
+// reached, whichever is larger.
+func thresholdDays(threshold format2.Threshold) float64 {
+	days := float64(threshold.Days)
+	if hours := float64(threshold.Hours) / 24; hours > days {
+		days = hours
+	}
+
+	return days
+}
+
+// serviceName is a helper function that generates the service name for the
+// certificate chain.
+func serviceName(payload format2.CertChainPayload, cfg Config) string {
+	host := payload.DNSName
+	if host == "" {
+		host = payload.Server.HostValue
+	}
+
+	if payload.TCPPort != 0 {
+		host = fmt.Sprintf("%s:%d", host, payload.TCPPort)
+	}
+
+	return strings.TrimSpace(cfg.ServicePrefix + " " + host)
+}
+
+// sanitizeServiceName is a helper function that replaces spaces and control
+// characters in a service name with underscores.
+func sanitizeServiceName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+}
+
+// worstState is a helper function that returns the more severe of two local
+// check states. The UNKNOWN state is considered less severe than CRITICAL.
+func worstState(a int, b int) int {
+	severity := func(state int) int {
+		switch state {
+		case StateCRITICAL:
+			return 3
+		case StateUNKNOWN:
+			return 2
+		case StateWARNING:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	if severity(b) > severity(a) {
+		return b
+	}
+
+	return a
+}
+
+// labelState is a helper function that returns the local check state for a
+// Nagios-style state label (e.g., "OK", "CRITICAL").
+func labelState(label string) int {
+	switch label {
+	case certs.StateOKLabel:
+		return StateOK
+	case certs.StateWARNINGLabel:
+		return StateWARNING
+	case certs.StateCRITICALLabel:
+		return StateCRITICAL
+	default:
+		return StateUNKNOWN
+	}
+}
+
+// StateLabel returns the Nagios-style state label (e.g., "OK", "CRITICAL")
+// for a local check state.
+func StateLabel(state int) string {
+	switch state {
+	case StateOK:
+		return certs.StateOKLabel
+	case StateWARNING:
+		return certs.StateWARNINGLabel
+	case StateCRITICAL:
+		return certs.StateCRITICALLabel
+	default:
+		return certs.StateUNKNOWNLabel
+	}
+}
+
+// singleLine is a helper function that replaces newlines in the given text so
+// that it does not break the line-based local check output format.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// formatFloat is a helper function that formats a floating point metric
+// value, omitting unnecessary trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
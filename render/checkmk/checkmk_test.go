@@ -0,0 +1,305 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package checkmk_test
+
+import (
+	"testing"
+	"time"
+
+	format2 "github.com/atc0005/cert-payload/format/v2"
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/issues"
+	"github.com/atc0005/cert-payload/render/checkmk"
+)
+
+// testPayload returns a decoded payload for a certificate chain with a leaf
+// certificate which was evaluated as expiring (WARNING) using the payload
+// thresholds.
+func testPayload() format2.CertChainPayload {
+	issuedOn := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	return format2.CertChainPayload{
+		FormatVersion: 2,
+		Server:        format2.Server{HostValue: "web1.example.com"},
+		DNSName:       "www.example.com",
+		TCPPort:       443,
+		CertChainSubset: []format2.Certificate{
+			{
+				CommonName:         "www.example.com",
+				SerialNumber:       "04:A1:B2:C3",
+				IssuedOn:           issuedOn,
+				ExpiresOn:          issuedOn.AddDate(0, 0, 90),
+				DaysRemaining:      40.5,
+				LifetimePercent:    45,
+				ValidityPeriodDays: 90,
+				Summary:            "[WARNING] 40d 12h remaining (45%)",
+				Status:             format2.CertificateStatus{Expiring: true},
+				Type:               "leaf",
+			},
+			{
+				CommonName:         "Example Intermediate CA",
+				SerialNumber:       "7F:00:01",
+				IssuedOn:           issuedOn,
+				ExpiresOn:          issuedOn.AddDate(5, 0, 0),
+				DaysRemaining:      1500,
+				LifetimePercent:    82,
+				ValidityPeriodDays: 1826,
+				Summary:            "[OK] 1500d 0h remaining (82%)",
+				Status:             format2.CertificateStatus{OK: true},
+				Type:               "intermediate",
+			},
+		},
+		Issues: format2.CertificateChainIssues{
+			MisorderedCerts: true,
+		},
+		IssueDetails: []format2.Issue{
+			{
+				Code:         issues.CodeMisorderedCerts,
+				Name:         "misordered_certs",
+				Severity:     issues.SeverityWarning,
+				ChainIndexes: []int{0, 1},
+			},
+		},
+		ExpirationThresholds: format2.ExpirationThresholds{
+			Leaf: format2.ThresholdSet{
+				Warning:  format2.Threshold{Days: 45, LifetimePercent: 33},
+				Critical: format2.Threshold{Days: 7, Hours: 240},
+			},
+			Intermediate: format2.ThresholdSet{
+				Warning:  format2.Threshold{Days: 90},
+				Critical: format2.Threshold{Days: 30},
+			},
+			Root: format2.ThresholdSet{
+				Warning:  format2.Threshold{Days: 365},
+				Critical: format2.Threshold{Days: 90},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		cfg    checkmk.Config
+	}{
+		{
+			name:   "payload state",
+			golden: "local.golden",
+		},
+		{
+			name:   "threshold override",
+			golden: "local_override.golden",
+			cfg: checkmk.Config{
+				ExpirationAgeInDaysWarningThreshold:  90,
+				ExpirationAgeInDaysCriticalThreshold: 45,
+			},
+		},
+		{
+			name:   "piggyback",
+			golden: "local_piggyback.golden",
+			cfg: checkmk.Config{
+				Piggyback:     true,
+				ServicePrefix: "TLS",
+			},
+		},
+		{
+			name:   "piggyback custom host",
+			golden: "local_piggyback_host.golden",
+			cfg: checkmk.Config{
+				Piggyback:     true,
+				PiggybackHost: "lb1.example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertGolden(t, tt.golden, []byte(checkmk.Render(testPayload(), tt.cfg)))
+		})
+	}
+}
+
+func TestRenderMissingChain(t *testing.T) {
+	payload := format2.CertChainPayload{
+		Server: format2.Server{HostValue: "web1.example.com"},
+		Errors: []string{"connection refused"},
+	}
+
+	testutil.AssertGolden(t, "local_missing_chain.golden", []byte(checkmk.Render(payload, checkmk.Config{})))
+}
+
+func TestLocalChecksCertState(t *testing.T) {
+	tests := []struct {
+		name   string
+		status format2.CertificateStatus
+		days   float64
+		cfg    checkmk.Config
+		want   int
+	}{
+		{
+			name:   "ok",
+			status: format2.CertificateStatus{OK: true},
+			days:   40.5,
+			want:   checkmk.StateOK,
+		},
+		{
+			name:   "expiring",
+			status: format2.CertificateStatus{Expiring: true},
+			days:   40.5,
+			want:   checkmk.StateWARNING,
+		},
+		{
+			name:   "expired",
+			status: format2.CertificateStatus{Expired: true},
+			days:   -2,
+			want:   checkmk.StateCRITICAL,
+		},
+		{
+			name:   "expiration ignored",
+			status: format2.CertificateStatus{Expired: true, ExpirationIgnored: true},
+			days:   -2,
+			want:   checkmk.StateOK,
+		},
+		{
+			name:   "not yet valid",
+			status: format2.CertificateStatus{NotYetValid: true},
+			days:   90,
+			want:   checkmk.StateCRITICAL,
+		},
+		{
+			name:   "revoked",
+			status: format2.CertificateStatus{Revoked: true, RevokedPerOCSP: true},
+			days:   40.5,
+			want:   checkmk.StateCRITICAL,
+		},
+		{
+			name:   "revoked with threshold override",
+			status: format2.CertificateStatus{Revoked: true},
+			days:   40.5,
+			cfg:    checkmk.Config{ExpirationAgeInDaysWarningThreshold: 10},
+			want:   checkmk.StateCRITICAL,
+		},
+		{
+			name:   "ok with threshold override",
+			status: format2.CertificateStatus{OK: true},
+			days:   40.5,
+			cfg:    checkmk.Config{ExpirationAgeInDaysWarningThreshold: 60},
+			want:   checkmk.StateWARNING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := testPayload()
+			payload.Issues = format2.CertificateChainIssues{}
+			payload.IssueDetails = nil
+			payload.CertChainSubset[0].Status = tt.status
+			payload.CertChainSubset[0].DaysRemaining = tt.days
+
+			results := checkmk.LocalChecks(payload, tt.cfg)
+
+			if got := results[1].State; got != tt.want {
+				t.Errorf("got certificate state %d, want %d", got, tt.want)
+			}
+
+			if got := results[0].State; got != tt.want {
+				t.Errorf("got chain state %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalChecksChainIssueState(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		ignored  bool
+		want     int
+	}{
+		{
+			name:     "critical issue",
+			severity: issues.SeverityCritical,
+			want:     checkmk.StateCRITICAL,
+		},
+		{
+			name:     "warning issue",
+			severity: issues.SeverityWarning,
+			want:     checkmk.StateWARNING,
+		},
+		{
+			name:     "informational issue",
+			severity: issues.SeverityInfo,
+			want:     checkmk.StateOK,
+		},
+		{
+			name:     "ignored critical issue",
+			severity: issues.SeverityCritical,
+			ignored:  true,
+			want:     checkmk.StateOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := testPayload()
+			payload.CertChainSubset[0].Status = format2.CertificateStatus{OK: true}
+			payload.Issues = format2.CertificateChainIssues{}
+			payload.IssueDetails = []format2.Issue{
+				{
+					Code:     issues.CodeExpiredCerts,
+					Name:     "expired_certs",
+					Severity: tt.severity,
+					Ignored:  tt.ignored,
+				},
+			}
+
+			if got := checkmk.LocalChecks(payload, checkmk.Config{})[0].State; got != tt.want {
+				t.Errorf("got chain state %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalCheckStringServiceName(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceName string
+		want        string
+	}{
+		{
+			name:        "spaces",
+			serviceName: "Certificate www.example.com:443",
+			want:        "0 Certificate_www.example.com:443 - ok",
+		},
+		{
+			name:        "control characters",
+			serviceName: "Certificate\tExample\nCA\x00",
+			want:        "0 Certificate_Example_CA_ - ok",
+		},
+		{
+			name:        "quotes are kept",
+			serviceName: `Certificate "Example"`,
+			want:        `0 Certificate_"Example" - ok`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := checkmk.LocalCheck{
+				State:       checkmk.StateOK,
+				ServiceName: tt.serviceName,
+				Summary:     "ok",
+			}
+
+			if got := lc.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
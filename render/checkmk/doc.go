@@ -0,0 +1,20 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package checkmk provides support for rendering a decoded format version 2
+// certificate metadata payload as Checkmk local check output.
+//
+// A service is emitted for the certificate chain as a whole along with a
+// service for each certificate in the chain. Output may optionally be wrapped
+// in a piggyback section so that the services are attached to the host which
+// served the certificate chain instead of the host which ran the check.
+//
+// See also:
+//
+//   - https://docs.checkmk.com/latest/en/localchecks.html
+//   - https://docs.checkmk.com/latest/en/piggyback.html
+package checkmk
@@ -0,0 +1,16 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package shared
+
+// Default expiration thresholds used by renderers when a threshold value is
+// needed but not recorded by the payload (e.g., performance data ranges).
+// These values match the defaults used by the check_cert plugin.
+const (
+	DefaultExpirationAgeInDaysWarningThreshold  int = 30
+	DefaultExpirationAgeInDaysCriticalThreshold int = 15
+)
@@ -0,0 +1,22 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package nagios provides support for generating Nagios performance data
+// from a decoded certificate metadata payload.
+//
+// Performance data labels are intended to remain stable across releases of
+// this library so that graphs built from them continue to work after client
+// code is updated. Metrics which do not apply to a given certificate chain
+// (e.g., intermediate expiration for a chain without intermediate
+// certificates) are omitted instead of being reported with a placeholder
+// value.
+//
+// See also:
+//
+//   - https://nagios-plugins.org/doc/guidelines.html#AEN200
+//   - https://github.com/atc0005/go-nagios
+package nagios
@@ -0,0 +1,241 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package nagios
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	"github.com/atc0005/cert-payload/internal/certs"
+	"github.com/atc0005/cert-payload/issues"
+	"github.com/atc0005/cert-payload/render/internal/shared"
+)
+
+// Performance data labels. These values are considered part of the stable
+// API of this package and are not changed between releases.
+const (
+	LabelExpiresLeaf               = "expires_leaf"
+	LabelExpiresIntermediate       = "expires_intermediate"
+	LabelExpiresRoot               = "expires_root"
+	LabelLifeRemainingLeaf         = "life_remaining_leaf"
+	LabelLifeRemainingIntermediate = "life_remaining_intermediate"
+	LabelSANsEntriesLeaf           = "sans_entries_leaf"
+	LabelCertsPresent              = "certs_present"
+	LabelChainIssues               = "chain_issues"
+)
+
+// unitOfMeasurementPercent is the Nagios unit of measurement for percentage
+// values.
+const unitOfMeasurementPercent string = "%"
+
+// Default threshold values used when a Config value is not specified. These
+// match the defaults used by the check_cert plugin.
+const (
+	DefaultExpirationAgeInDaysWarningThreshold  int = shared.DefaultExpirationAgeInDaysWarningThreshold
+	DefaultExpirationAgeInDaysCriticalThreshold int = shared.DefaultExpirationAgeInDaysCriticalThreshold
+)
+
+// Config controls how performance data is generated.
+type Config struct {
+	// ExpirationAgeInDaysWarningThreshold is the number of days remaining
+	// before certificate expiration when the certificate should be considered
+	// to be expiring and in a WARNING state. This should match the value used
+	// when the payload was generated.
+	ExpirationAgeInDaysWarningThreshold int
+
+	// ExpirationAgeInDaysCriticalThreshold is the number of days remaining
+	// before certificate expiration when the certificate should be considered
+	// to be expiring and in a CRITICAL state. This should match the value
+	// used when the payload was generated.
+	ExpirationAgeInDaysCriticalThreshold int
+}
+
+// PerformanceData represents a single performance data metric. The fields
+// mirror the PerformanceData type provided by the atc0005/go-nagios project
+// to allow for easy conversion.
+type PerformanceData struct {
+	// Label is the single quoted text string used as a label for the metric.
+	Label string
+
+	// Value is the data point associated with the performance data label.
+	Value string
+
+	// UnitOfMeasurement is an optional unit of measurement (UOM).
+	UnitOfMeasurement string
+
+	// Warn is the optional WARNING threshold range.
+	Warn string
+
+	// Crit is the optional CRITICAL threshold range.
+	Crit string
+
+	// Min is the optional minimum value.
+	Min string
+
+	// Max is the optional maximum value.
+	Max string
+}
+
+// String implements the fmt.Stringer interface by returning the metric in
+// the Nagios performance data format:
+//
+//	'label'=value[UOM];[warn];[crit];[min];[max]
+func (pd PerformanceData) String() string {
+	s := fmt.Sprintf(
+		"'%s'=%s%s;%s;%s;%s;%s",
+		pd.Label,
+		pd.Value,
+		pd.UnitOfMeasurement,
+		pd.Warn,
+		pd.Crit,
+		pd.Min,
+		pd.Max,
+	)
+
+	// Trailing semicolons for unspecified fields are optional.
+	return strings.TrimRight(s, ";")
+}
+
+// PerfData generates performance data metrics for the given payload.
+//
+// Expiration metrics are reported in days remaining. Nagios does not define
+// a unit of measurement for days, so those values are unitless. Expiration
+// thresholds use the Nagios range syntax to alert when the value falls below
+// the configured number of days.
+func PerfData(payload format1.CertChainPayload, cfg Config) []PerformanceData {
+	cfg = cfg.withDefaults()
+
+	certChain := format1.Certificates(payload.CertChainSubset)
+	perfData := make([]PerformanceData, 0, 8)
+
+	expWarn := fmt.Sprintf("%d:", cfg.ExpirationAgeInDaysWarningThreshold)
+	expCrit := fmt.Sprintf("%d:", cfg.ExpirationAgeInDaysCriticalThreshold)
+
+	if leaf := certChain.FirstLeaf(); !leaf.IssuedOn.IsZero() {
+		perfData = append(perfData,
+			PerformanceData{
+				Label: LabelExpiresLeaf,
+				Value: formatFloat(leaf.DaysRemaining),
+				Warn:  expWarn,
+				Crit:  expCrit,
+			},
+			lifeRemaining(LabelLifeRemainingLeaf, leaf),
+			PerformanceData{
+				Label: LabelSANsEntriesLeaf,
+				Value: strconv.Itoa(leaf.SANsEntriesCount),
+				Min:   "0",
+			},
+		)
+	}
+
+	if intermediate := certChain.IntermediateExpiringFirst(); !intermediate.IssuedOn.IsZero() {
+		perfData = append(perfData,
+			PerformanceData{
+				Label: LabelExpiresIntermediate,
+				Value: formatFloat(intermediate.DaysRemaining),
+				Warn:  expWarn,
+				Crit:  expCrit,
+			},
+			lifeRemaining(LabelLifeRemainingIntermediate, intermediate),
+		)
+	}
+
+	if root := rootExpiringFirst(certChain); !root.IssuedOn.IsZero() {
+		perfData = append(perfData, PerformanceData{
+			Label: LabelExpiresRoot,
+			Value: formatFloat(root.DaysRemaining),
+			Warn:  expWarn,
+			Crit:  expCrit,
+		})
+	}
+
+	perfData = append(perfData,
+		PerformanceData{
+			Label: LabelCertsPresent,
+			Value: strconv.Itoa(len(certChain)),
+			Min:   "0",
+		},
+		PerformanceData{
+			Label: LabelChainIssues,
+			Value: strconv.Itoa(len(issues.Detected(payload.Issues))),
+			Min:   "0",
+			Max:   strconv.Itoa(len(issues.Flags(payload.Issues))),
+		},
+	)
+
+	return perfData
+}
+
+// Render generates performance data for the given payload as a single
+// space-separated string suitable for use after the pipe character in plugin
+// output.
+func Render(payload format1.CertChainPayload, cfg Config) string {
+	perfData := PerfData(payload, cfg)
+
+	metrics := make([]string, 0, len(perfData))
+	for _, pd := range perfData {
+		metrics = append(metrics, pd.String())
+	}
+
+	return strings.Join(metrics, " ")
+}
+
+// withDefaults is a helper method that returns a copy of the configuration
+// with default values applied for any settings which were not specified.
+func (cfg Config) withDefaults() Config {
+	if cfg.ExpirationAgeInDaysWarningThreshold == 0 {
+		cfg.ExpirationAgeInDaysWarningThreshold = DefaultExpirationAgeInDaysWarningThreshold
+	}
+
+	if cfg.ExpirationAgeInDaysCriticalThreshold == 0 {
+		cfg.ExpirationAgeInDaysCriticalThreshold = DefaultExpirationAgeInDaysCriticalThreshold
+	}
+
+	return cfg
+}
+
+// lifeRemaining is a helper function that generates the remaining lifetime
+// percentage metric for a certificate.
+func lifeRemaining(label string, cert format1.Certificate) PerformanceData {
+	lifetime := cert.LifetimePercent
+	if lifetime < 0 {
+		lifetime = 0
+	}
+
+	return PerformanceData{
+		Label:             label,
+		Value:             strconv.Itoa(lifetime),
+		UnitOfMeasurement: unitOfMeasurementPercent,
+		Min:               "0",
+		Max:               "100",
+	}
+}
+
+// rootExpiringFirst is a helper function that returns the root certificate
+// expiring first in the certificate chain or a zero value Certificate.
+func rootExpiringFirst(cs format1.Certificates) format1.Certificate {
+	var lowestRoot format1.Certificate
+
+	for _, cert := range cs {
+		if cert.Type == certs.CertChainPositionRoot {
+			if lowestRoot.IssuedOn.IsZero() || cert.DaysRemaining < lowestRoot.DaysRemaining {
+				lowestRoot = cert
+			}
+		}
+	}
+
+	return lowestRoot
+}
+
+// formatFloat is a helper function that formats a floating point metric
+// value using two decimal places of precision.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
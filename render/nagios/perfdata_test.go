@@ -0,0 +1,143 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package nagios_test
+
+import (
+	"testing"
+	"time"
+
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/render/nagios"
+)
+
+// testChain returns a certificate chain subset with a leaf, two
+// intermediate and a root certificate.
+func testChain() []format1.Certificate {
+	issuedOn := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	return []format1.Certificate{
+		{
+			CommonName:       "www.example.com",
+			IssuedOn:         issuedOn,
+			DaysRemaining:    40.456,
+			LifetimePercent:  45,
+			SANsEntriesCount: 3,
+			Type:             "leaf",
+		},
+		{
+			CommonName:      "Example Intermediate CA R2",
+			IssuedOn:        issuedOn,
+			DaysRemaining:   1500,
+			LifetimePercent: 82,
+			Type:            "intermediate",
+		},
+		{
+			CommonName:      "Example Intermediate CA R1",
+			IssuedOn:        issuedOn,
+			DaysRemaining:   20.1,
+			LifetimePercent: -3,
+			Type:            "intermediate",
+		},
+		{
+			CommonName:      "Example Root CA",
+			IssuedOn:        issuedOn,
+			DaysRemaining:   3650,
+			LifetimePercent: 90,
+			Type:            "root",
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		golden  string
+		payload format1.CertChainPayload
+		cfg     nagios.Config
+	}{
+		{
+			name:   "default thresholds",
+			golden: "perfdata.golden",
+			payload: format1.CertChainPayload{
+				CertChainSubset: testChain(),
+				Issues: format1.CertificateChainIssues{
+					MisorderedCerts:  true,
+					HostnameMismatch: true,
+				},
+			},
+		},
+		{
+			name:   "custom thresholds",
+			golden: "perfdata_thresholds.golden",
+			payload: format1.CertChainPayload{
+				CertChainSubset: testChain(),
+			},
+			cfg: nagios.Config{
+				ExpirationAgeInDaysWarningThreshold:  60,
+				ExpirationAgeInDaysCriticalThreshold: 20,
+			},
+		},
+		{
+			name:   "intermediates bundle",
+			golden: "perfdata_no_leaf.golden",
+			payload: format1.CertChainPayload{
+				CertChainSubset: testChain()[1:3],
+			},
+		},
+		{
+			name:    "empty chain",
+			golden:  "perfdata_empty.golden",
+			payload: format1.CertChainPayload{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertGolden(t, tt.golden, []byte(nagios.Render(tt.payload, tt.cfg)+"\n"))
+		})
+	}
+}
+
+func TestPerformanceDataString(t *testing.T) {
+	tests := []struct {
+		name string
+		pd   nagios.PerformanceData
+		want string
+	}{
+		{
+			name: "value only",
+			pd:   nagios.PerformanceData{Label: "certs_present", Value: "3"},
+			want: "'certs_present'=3",
+		},
+		{
+			name: "unit and range",
+			pd: nagios.PerformanceData{
+				Label:             "life_remaining_leaf",
+				Value:             "45",
+				UnitOfMeasurement: "%",
+				Min:               "0",
+				Max:               "100",
+			},
+			want: "'life_remaining_leaf'=45%;;;0;100",
+		},
+		{
+			name: "thresholds without range",
+			pd:   nagios.PerformanceData{Label: "expires_leaf", Value: "40.46", Warn: "30:", Crit: "15:"},
+			want: "'expires_leaf'=40.46;30:;15:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pd.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package zabbix provides support for converting a decoded certificate
+// metadata payload into Zabbix low-level discovery (LLD) JSON and a matching
+// set of item values in the input file format used by the zabbix_sender
+// utility.
+//
+// Each certificate in the payload's CertChainSubset is exposed as a
+// discovered entity. Item prototypes created from the discovery rule are
+// expected to use the serial number macro as the key parameter. For a
+// certificate without a serial number the chain index prefixed with "index-"
+// (e.g., index-2) is provided as the serial number macro value. For example:
+//
+//	cert.days_remaining[{#SERIAL}]
+//	cert.lifetime_remaining_percent[{#SERIAL}]
+//
+// Certificate chain issues are provided as chain-level items (e.g.,
+// cert.chain.issue[expired_certs]) which do not require discovery.
+//
+// See also:
+//
+//   - https://www.zabbix.com/documentation/current/en/manual/discovery/low_level_discovery
+//   - https://www.zabbix.com/documentation/current/en/manpages/zabbix_sender
+package zabbix
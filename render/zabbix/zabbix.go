@@ -0,0 +1,247 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package zabbix
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	"github.com/atc0005/cert-payload/issues"
+)
+
+// noSerialPrefix is the prefix of the placeholder used in place of the serial
+// number for a certificate without a serial number.
+const noSerialPrefix = "index-"
+
+// Low-level discovery (LLD) macro names provided for each discovered
+// certificate.
+const (
+	MacroCommonName = "{#CN}"
+	MacroType       = "{#TYPE}"
+	MacroSerial     = "{#SERIAL}"
+	MacroSubject    = "{#SUBJECT}"
+	MacroIssuer     = "{#ISSUER}"
+	MacroIndex      = "{#INDEX}"
+)
+
+// Item keys used for per-certificate values. Each key is used with the
+// certificate serial number as the sole key parameter. A placeholder based
+// on the chain index of the certificate (e.g., "index-2") is used instead for
+// a certificate without a serial number.
+const (
+	KeyDaysRemaining    = "cert.days_remaining"
+	KeyLifetimePercent  = "cert.lifetime_remaining_percent"
+	KeyStatusExpiring   = "cert.status.expiring"
+	KeyStatusExpired    = "cert.status.expired"
+	KeyChainIssue       = "cert.chain.issue"
+	KeyChainIssuesCount = "cert.chain.issues.count"
+)
+
+// DefaultHost is the host value used for zabbix_sender input when a host
+// value is not specified. zabbix_sender substitutes the Hostname value from
+// the agent configuration file for this value.
+const DefaultHost = "-"
+
+// Discovery is the low-level discovery JSON document for a certificate chain.
+type Discovery struct {
+	// Data is the collection of discovered certificates. Each entry is a
+	// mapping of LLD macro name to value.
+	Data []map[string]string `json:"data"`
+}
+
+// Item is a single value intended for submission to a Zabbix server or proxy
+// using the zabbix_sender utility.
+type Item struct {
+	// Host is the name of the monitored host as registered in Zabbix.
+	Host string
+
+	// Key is the item key, including any key parameters.
+	Key string
+
+	// Value is the item value.
+	Value string
+}
+
+// Items is a collection of Item values.
+type Items []Item
+
+// String implements the fmt.Stringer interface by returning the item in the
+// zabbix_sender input file format.
+func (i Item) String() string {
+	host := i.Host
+	if host == "" {
+		host = DefaultHost
+	}
+
+	return strings.Join(
+		[]string{
+			quoteField(host),
+			quoteField(i.Key),
+			quoteField(i.Value),
+		},
+		" ",
+	)
+}
+
+// String implements the fmt.Stringer interface by returning the collection
+// of items in the zabbix_sender input file format, one item per line.
+func (items Items) String() string {
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(item.String())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// NewDiscovery generates a low-level discovery document for the certificates
+// in the given payload.
+func NewDiscovery(payload format1.CertChainPayload) Discovery {
+	data := make([]map[string]string, 0, len(payload.CertChainSubset))
+
+	for idx, cert := range payload.CertChainSubset {
+		data = append(data, map[string]string{
+			MacroCommonName: cert.CommonName,
+			MacroType:       cert.Type,
+			MacroSerial:     certKeyParam(cert, idx),
+			MacroSubject:    cert.Subject,
+			MacroIssuer:     cert.Issuer,
+			MacroIndex:      strconv.Itoa(idx),
+		})
+	}
+
+	return Discovery{Data: data}
+}
+
+// DiscoveryJSON generates low-level discovery JSON for the certificates in
+// the given payload. An error is returned if one occurs while marshaling the
+// discovery document.
+func DiscoveryJSON(payload format1.CertChainPayload) ([]byte, error) {
+	discoveryJSON, err := json.Marshal(NewDiscovery(payload))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error marshaling discovery data as JSON: %w",
+			err,
+		)
+	}
+
+	return discoveryJSON, nil
+}
+
+// NewItems generates the item values for the given payload using the
+// specified host name. If not specified, DefaultHost is used.
+//
+// Per-certificate items use the keys matching item prototypes created from
+// the low-level discovery document; chain-level items are provided for each
+// certificate chain issue.
+func NewItems(payload format1.CertChainPayload, host string) Items {
+	if host == "" {
+		host = DefaultHost
+	}
+
+	issueFlags := issues.Flags(payload.Issues)
+	items := make(Items, 0, len(payload.CertChainSubset)*4+len(issueFlags)+1)
+
+	for idx, cert := range payload.CertChainSubset {
+		serial := keyParam(certKeyParam(cert, idx))
+
+		items = append(items,
+			Item{
+				Host:  host,
+				Key:   itemKey(KeyDaysRemaining, serial),
+				Value: strconv.FormatFloat(cert.DaysRemaining, 'f', 2, 64),
+			},
+			Item{
+				Host:  host,
+				Key:   itemKey(KeyLifetimePercent, serial),
+				Value: strconv.Itoa(cert.LifetimePercent),
+			},
+			Item{
+				Host:  host,
+				Key:   itemKey(KeyStatusExpiring, serial),
+				Value: boolValue(cert.Status.Expiring),
+			},
+			Item{
+				Host:  host,
+				Key:   itemKey(KeyStatusExpired, serial),
+				Value: boolValue(cert.Status.Expired),
+			},
+		)
+	}
+
+	for _, flag := range issueFlags {
+		items = append(items, Item{
+			Host:  host,
+			Key:   itemKey(KeyChainIssue, flag.Name),
+			Value: boolValue(flag.Detected),
+		})
+	}
+
+	items = append(items, Item{
+		Host:  host,
+		Key:   KeyChainIssuesCount,
+		Value: strconv.Itoa(len(issues.Detected(payload.Issues))),
+	})
+
+	return items
+}
+
+// certKeyParam is a helper function that returns the value used to identify
+// the given certificate in item keys and the serial number LLD macro. The
+// certificate serial number is used if available, otherwise a placeholder
+// based on the chain index of the certificate. The placeholder is prefixed so
+// that it cannot collide with the serial number of another certificate.
+func certKeyParam(cert format1.Certificate, idx int) string {
+	if cert.SerialNumber == "" {
+		return noSerialPrefix + strconv.Itoa(idx)
+	}
+
+	return cert.SerialNumber
+}
+
+// itemKey is a helper function that assembles an item key from the given key
+// name and key parameter.
+func itemKey(name string, param string) string {
+	return name + "[" + param + "]"
+}
+
+// keyParam is a helper function that quotes an item key parameter if it
+// contains characters which are not permitted in an unquoted parameter.
+func keyParam(param string) string {
+	if !strings.ContainsAny(param, `,]"[ `) {
+		return param
+	}
+
+	return `"` + strings.ReplaceAll(param, `"`, `\"`) + `"`
+}
+
+// quoteField is a helper function that quotes a zabbix_sender input file
+// field if it contains whitespace, double quotes or backslashes.
+func quoteField(field string) string {
+	if field != "" && !strings.ContainsAny(field, " \t\"\\") {
+		return field
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	return `"` + replacer.Replace(field) + `"`
+}
+
+// boolValue is a helper function that converts a boolean value to the
+// numeric representation used for Zabbix items.
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+
+	return "0"
+}
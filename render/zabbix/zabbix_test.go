@@ -0,0 +1,81 @@
+// Copyright 2024 Adam Chalkley
+//
+// https://github.com/atc0005/cert-payload
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package zabbix_test
+
+import (
+	"testing"
+
+	format1 "github.com/atc0005/cert-payload/format/v1"
+	"github.com/atc0005/cert-payload/internal/testutil"
+	"github.com/atc0005/cert-payload/render/zabbix"
+)
+
+// testPayload returns a decoded payload for a certificate chain with an
+// expiring leaf certificate, an intermediate certificate and a root
+// certificate without a serial number.
+func testPayload() format1.CertChainPayload {
+	return format1.CertChainPayload{
+		FormatVersion: 1,
+		Server:        format1.Server{HostValue: "www.example.com"},
+		CertChainSubset: []format1.Certificate{
+			{
+				CommonName:      "www.example.com",
+				Subject:         "CN=www.example.com,O=Example Org",
+				Issuer:          "CN=Example Intermediate CA",
+				SerialNumber:    "04:A1:B2:C3",
+				DaysRemaining:   12.5,
+				LifetimePercent: 13,
+				Status:          format1.CertificateStatus{Expiring: true},
+				Type:            "leaf",
+			},
+			{
+				CommonName:      "Example Intermediate CA",
+				Subject:         "CN=Example Intermediate CA",
+				Issuer:          "CN=Example Root CA",
+				SerialNumber:    "7F:00:01",
+				DaysRemaining:   1000,
+				LifetimePercent: 55,
+				Status:          format1.CertificateStatus{Expiring: true},
+				Type:            "intermediate",
+			},
+			{
+				CommonName:      "Example Root CA",
+				Subject:         "CN=Example Root CA",
+				Issuer:          "CN=Example Root CA",
+				DaysRemaining:   3000.25,
+				LifetimePercent: 82,
+				Status:          format1.CertificateStatus{Expiring: true},
+				Type:            "root",
+			},
+		},
+		Issues: format1.CertificateChainIssues{
+			HostnameMismatch: true,
+		},
+	}
+}
+
+func TestDiscoveryJSON(t *testing.T) {
+	got, err := zabbix.DiscoveryJSON(testPayload())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testutil.AssertGolden(t, "discovery.json.golden", append(got, '\n'))
+}
+
+func TestItems(t *testing.T) {
+	t.Run("default host", func(t *testing.T) {
+		got := zabbix.NewItems(testPayload(), "").String()
+		testutil.AssertGolden(t, "items_default_host.golden", []byte(got))
+	})
+
+	t.Run("named host", func(t *testing.T) {
+		got := zabbix.NewItems(testPayload(), "web server 1").String()
+		testutil.AssertGolden(t, "items_named_host.golden", []byte(got))
+	})
+}